@@ -3,24 +3,31 @@ package main
 import (
 	"context"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 	"github.com/redis/go-redis/v9"
 
+	"nusatek-backend/internal/blobstore"
 	"nusatek-backend/internal/config"
 	"nusatek-backend/internal/delivery/http"
+	"nusatek-backend/internal/imaging"
 	"nusatek-backend/internal/repository/postgres"
 	redisRepo "nusatek-backend/internal/repository/redis"
 	"nusatek-backend/internal/usecase"
 	"nusatek-backend/pkg/database"
 	"nusatek-backend/pkg/rabbitmq"
+	"nusatek-backend/pkg/webhook"
 )
 
 func main() {
 	// 1. Load Config
 	cfg := config.LoadConfig()
+	if err := cfg.CheckJWTSecret(); err != nil {
+		log.Fatal("Refusing to start: ", err)
+	}
 
 	// 2. Connect to Database
 	db, err := database.ConnectPostgres(cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName)
@@ -38,6 +45,9 @@ func main() {
 	}
 
 	// 4. Connect to RabbitMQ
+	// mq stays nil without a connection, so publishing and replaying fail
+	// instead of panicking
+	var mq rabbitmq.Channel
 	rabbitConn, rabbitCh, err := rabbitmq.ConnectRabbitMQ(cfg.RabbitMQURL)
 	if err != nil {
 		// Log but don't fatal, allowing app to run without MQ for demo purposes if needed,
@@ -46,6 +56,7 @@ func main() {
 	} else {
 		defer rabbitConn.Close()
 		defer rabbitCh.Close()
+		mq = rabbitCh
 	}
 
 	// 5. Init Layers
@@ -56,15 +67,105 @@ func main() {
 	propertyRepo := postgres.NewPropertyRepository(db)
 	customerRepo := postgres.NewCustomerRepository(db)
 	cacheRepo := redisRepo.NewPropertyCacheRepository(rdb)
+	webhookRepo := postgres.NewWebhookRepository(db)
+	streamRepo := redisRepo.NewEventStreamRepository(rdb, 10000)
+	changeRepo := postgres.NewChangeRepository(db)
+	userRepo := postgres.NewUserRepository(db)
+	tokenRepo := redisRepo.NewTokenRepository(rdb, cfg.RefreshTokenTTL)
+	permissionRepo := postgres.NewPermissionRepository(db)
+	apiKeyRepo := postgres.NewAPIKeyRepository(db)
+	auditRepo := postgres.NewAuditRepository(db)
+	revisionRepo := postgres.NewPropertyRevisionRepository(db)
+	priceRepo := postgres.NewPriceHistoryRepository(db)
+	regionRepo := postgres.NewRegionRepository(db)
+	mediaRepo := postgres.NewMediaRepository(db)
+	transactor := postgres.NewTransactor(db)
+	amenityRepo := postgres.NewAmenityRepository(db)
+	rateLimiter := redisRepo.NewRateLimiter(rdb)
+	idempotencyRepo := redisRepo.NewIdempotencyRepository(rdb, time.Minute, cfg.IdempotencyTTL)
+
+	blobStore, err := blobstore.Open(cfg.Media)
+	if err != nil {
+		log.Fatal("Failed to open media store:", err)
+	}
+
+	// Policy
+	authorizer := usecase.NewAuthorizer(permissionRepo, time.Minute)
 
 	// Usecase
-	propertyUsecase := usecase.NewPropertyUsecase(propertyRepo, cacheRepo, rabbitCh, timeoutContext)
-	customerUsecase := usecase.NewCustomerUsecase(customerRepo, timeoutContext)
+	propertyDeps := usecase.PropertyDeps{
+		Properties: propertyRepo,
+		Cache:      cacheRepo,
+		MQ:         mq,
+		Stream:     streamRepo,
+		Authorizer: authorizer,
+		Audit:      auditRepo,
+		Revisions:  revisionRepo,
+		Prices:     priceRepo,
+		Regions:    regionRepo,
+		Media:      mediaRepo,
+		Blobs:      blobStore,
+		Images:     imaging.NewProcessor(imaging.DefaultVariants, 82),
+		Amenities:  amenityRepo,
+		Tx:         transactor,
+		Timeout:    timeoutContext,
+	}
+	propertyUsecase := usecase.NewPropertyUsecase(propertyDeps)
+	customerUsecase := usecase.NewCustomerUsecase(customerRepo, mq, streamRepo, authorizer, auditRepo, transactor, timeoutContext)
+	deadLetterUsecase := usecase.NewDeadLetterUsecase(mq, authorizer)
+	streamUsecase := usecase.NewEventStreamUsecase(streamRepo, authorizer, 1000)
+	changeUsecase := usecase.NewChangeUsecase(changeRepo, authorizer, timeoutContext)
+	webhookUsecase := usecase.NewWebhookUsecase(webhookRepo, webhook.NewClient(10*time.Second), authorizer, 8, timeoutContext)
+	userUsecase := usecase.NewUserUsecase(userRepo, authorizer, timeoutContext)
+	authUsecase := usecase.NewAuthUsecase(userRepo, tokenRepo, cfg.JWTSecret, cfg.AccessTokenTTL, cfg.RefreshTokenTTL, timeoutContext)
+	apiKeyUsecase := usecase.NewAPIKeyUsecase(apiKeyRepo, authorizer, timeoutContext)
+	auditUsecase := usecase.NewAuditUsecase(auditRepo, authorizer, timeoutContext)
+	trashUsecase := usecase.NewTrashUsecase(propertyRepo, customerRepo, mediaRepo, blobStore, authorizer, timeoutContext)
+	priceUsecase := usecase.NewPriceHistoryUsecase(priceRepo, authorizer, timeoutContext)
+	regionUsecase := usecase.NewRegionUsecase(regionRepo, timeoutContext)
+	duplicateUsecase := usecase.NewDuplicateUsecase(propertyDeps)
+	amenityUsecase := usecase.NewAmenityUsecase(propertyDeps)
+	mediaUsecase := usecase.NewMediaUsecase(propertyDeps)
+
+	if err := userUsecase.EnsureAdmin(context.Background(), cfg.AdminEmail, cfg.AdminPassword); err != nil {
+		log.Printf("Warning: Failed to create admin user: %v", err)
+	}
 
 	// 6. Init Router & Handlers
 	r := gin.Default()
-	http.NewPropertyHandler(r, propertyUsecase)
-	http.NewCustomerHandler(r, customerUsecase)
+	// Clients must not pick their rate limit bucket with a forged X-Forwarded-For
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES: ", err)
+	}
+	r.Use(http.RequestID())
+	rateLimit := http.RateLimit(rateLimiter, cfg.RateLimit, cfg.RouteRateLimits)
+	public := r.Group("/api/v1", rateLimit)
+	// Retries only replay the creation of listings and customers; uploads are too
+	// large to buffer and credentials must never be stored
+	idempotency := http.Idempotency(idempotencyRepo, "POST /api/v1/properties", "POST /api/v1/customers")
+	api := r.Group("/api/v1", http.AuthMiddleware(authUsecase, apiKeyUsecase), rateLimit, idempotency)
+	http.NewAuthHandler(public, api, authUsecase, userUsecase)
+	http.NewPropertyHandler(api, propertyUsecase)
+	http.NewCustomerHandler(api, customerUsecase)
+	http.NewDeadLetterHandler(api, deadLetterUsecase)
+	http.NewWebhookHandler(api, webhookUsecase)
+	http.NewChangeHandler(api, changeUsecase)
+	http.NewAPIKeyHandler(api, apiKeyUsecase)
+	http.NewAuditHandler(api, auditUsecase)
+	http.NewTrashHandler(api, trashUsecase)
+	http.NewPriceHistoryHandler(api, priceUsecase)
+	http.NewMediaHandler(api, mediaUsecase)
+	http.NewDuplicateHandler(api, duplicateUsecase)
+	http.NewAmenityHandler(api, amenityUsecase)
+	http.NewListingHandler(public, propertyUsecase)
+	http.NewRegionHandler(public, regionUsecase)
+	http.NewStreamHandler(r.Group("/api/v1", http.StreamAuthMiddleware(authUsecase, apiKeyUsecase), rateLimit), streamUsecase)
+
+	// Photos of drafts must stay private, so the API serves stored files itself
+	// unless they are published elsewhere, like a CDN in front of the bucket
+	if strings.HasPrefix(cfg.Media.BaseURL, "/") {
+		http.NewMediaFileHandler(r.Group(cfg.Media.BaseURL, http.OptionalAuthMiddleware(authUsecase, apiKeyUsecase), rateLimit), mediaUsecase)
+	}
 
 	// Serve Frontend
 	r.Static("/static", "./web")
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/redis/go-redis/v9"
+
+	"nusatek-backend/internal/blobstore"
+	"nusatek-backend/internal/config"
+	"nusatek-backend/internal/domain"
+	"nusatek-backend/internal/geocoder"
+	"nusatek-backend/internal/imaging"
+	"nusatek-backend/internal/repository/postgres"
+	redisRepo "nusatek-backend/internal/repository/redis"
+	"nusatek-backend/internal/usecase"
+	"nusatek-backend/internal/worker"
+	"nusatek-backend/pkg/database"
+	"nusatek-backend/pkg/rabbitmq"
+	"nusatek-backend/pkg/webhook"
+)
+
+func main() {
+	// 1. Load Config
+	cfg := config.LoadConfig()
+
+	// 2. Connect to Database
+	db, err := database.ConnectPostgres(cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.Close()
+
+	// 3. Connect to Redis (inbox for deduplication)
+	rdb := redis.NewClient(&redis.Options{
+		Addr: cfg.RedisHost + ":" + cfg.RedisPort,
+	})
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		log.Fatal("Failed to connect to Redis:", err)
+	}
+
+	// 4. Connect to RabbitMQ
+	rabbitConn, rabbitCh, err := rabbitmq.ConnectRabbitMQ(cfg.RabbitMQURL)
+	if err != nil {
+		log.Fatal("Failed to connect to RabbitMQ:", err)
+	}
+	defer rabbitConn.Close()
+	defer rabbitCh.Close()
+
+	// 5. Init Layers
+	timeoutContext := time.Duration(2) * time.Second
+
+	inboxRepo := redisRepo.NewInboxRepository(rdb, 5*time.Minute, 7*24*time.Hour)
+	webhookRepo := postgres.NewWebhookRepository(db)
+	authorizer := usecase.NewAuthorizer(postgres.NewPermissionRepository(db), time.Minute)
+	webhookUsecase := usecase.NewWebhookUsecase(webhookRepo, webhook.NewClient(10*time.Second), authorizer, 8, timeoutContext)
+	propertyRepo := postgres.NewPropertyRepository(db)
+
+	// Geocode with the HTTP provider if configured, falling back to the gazetteer
+	var geocoders geocoder.Chain
+	if cfg.GeocoderURL != "" {
+		geocoders = append(geocoders, geocoder.NewNominatim(cfg.GeocoderURL, cfg.GeocoderUserAgent, &http.Client{Timeout: 10 * time.Second}))
+	}
+	if gazetteer, err := geocoder.LoadGazetteer(cfg.GazetteerPath); err != nil {
+		log.Printf("Warning: Failed to load gazetteer: %v", err)
+	} else {
+		geocoders = append(geocoders, gazetteer)
+	}
+	cacheRepo := redisRepo.NewPropertyCacheRepository(rdb)
+	geocodeUsecase := usecase.NewGeocodeUsecase(propertyRepo, cacheRepo, geocoders, 30*time.Second)
+
+	blobStore, err := blobstore.Open(cfg.Media)
+	if err != nil {
+		log.Fatal("Failed to open media store:", err)
+	}
+	processor := imaging.NewProcessor(imaging.DefaultVariants, 82)
+	mediaRepo := postgres.NewMediaRepository(db)
+	mediaProcessingUsecase := usecase.NewMediaProcessingUsecase(mediaRepo, blobStore, processor, cacheRepo, 2*time.Minute)
+	trashUsecase := usecase.NewTrashUsecase(propertyRepo, postgres.NewCustomerRepository(db), mediaRepo, blobStore, authorizer, time.Minute)
+
+	// 6. Init Consumers
+	propertyConsumer := worker.NewConsumer("property-worker", "property_events", rabbitCh, inboxRepo, 5)
+	customerConsumer := worker.NewConsumer("customer-worker", "customer_events", rabbitCh, inboxRepo, 5)
+	for _, eventType := range []string{domain.EventPropertyCreated, domain.EventPropertyUpdated, domain.EventPropertyDeleted, domain.EventPropertyRestored, domain.EventPropertyStatusChanged, domain.EventPropertyMerged} {
+		propertyConsumer.Handle(eventType, worker.LogEvent)
+		propertyConsumer.Handle(eventType, worker.EnqueueWebhooks(webhookUsecase))
+	}
+	if len(geocoders) > 0 {
+		for _, eventType := range []string{domain.EventPropertyCreated, domain.EventPropertyUpdated, domain.EventPropertyRestored} {
+			propertyConsumer.Handle(eventType, worker.GeocodeProperties(geocodeUsecase))
+		}
+	}
+	propertyConsumer.Handle(domain.EventPropertyMediaUploaded, worker.LogEvent)
+	propertyConsumer.Handle(domain.EventPropertyMediaUploaded, worker.ProcessMedia(mediaProcessingUsecase))
+	for _, eventType := range []string{domain.EventCustomerCreated, domain.EventCustomerUpdated, domain.EventCustomerDeleted, domain.EventCustomerRestored} {
+		customerConsumer.Handle(eventType, worker.LogEvent)
+		customerConsumer.Handle(eventType, worker.EnqueueWebhooks(webhookUsecase))
+	}
+
+	// 7. Run until interrupted
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		worker.RunWebhookDispatcher(ctx, webhookUsecase, 5*time.Second, 50)
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		worker.RunTrashPurge(ctx, trashUsecase, time.Hour, cfg.TrashRetention)
+	}()
+
+	for _, consumer := range []*worker.Consumer{propertyConsumer, customerConsumer} {
+		wg.Add(1)
+		go func(consumer *worker.Consumer) {
+			defer wg.Done()
+			if err := consumer.Run(ctx); err != nil && err != context.Canceled {
+				log.Println("Consumer stopped:", err)
+				stop()
+			}
+		}(consumer)
+	}
+
+	log.Println("Worker consuming property_events and customer_events")
+	wg.Wait()
+}
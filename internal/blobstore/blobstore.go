@@ -0,0 +1,37 @@
+// Package blobstore keeps uploaded media on the local filesystem or in an S3
+// compatible object store.
+package blobstore
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"nusatek-backend/internal/domain"
+)
+
+// Options selects and configures a store; Driver is "local" or "s3"
+type Options struct {
+	Driver  string
+	Dir     string
+	BaseURL string
+
+	S3Endpoint  string
+	S3Bucket    string
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string
+}
+
+func Open(o Options) (domain.BlobStore, error) {
+	switch o.Driver {
+	case "", "local":
+		return NewLocal(o.Dir, o.BaseURL), nil
+	case "s3":
+		if o.S3Endpoint == "" || o.S3Bucket == "" {
+			return nil, fmt.Errorf("blobstore: s3 needs an endpoint and a bucket")
+		}
+		return NewS3(o.S3Endpoint, o.S3Bucket, o.S3Region, o.S3AccessKey, o.S3SecretKey, o.BaseURL, &http.Client{Timeout: 5 * time.Minute}), nil
+	}
+	return nil, fmt.Errorf("blobstore: unknown driver %q", o.Driver)
+}
@@ -0,0 +1,21 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckJWTSecret(t *testing.T) {
+	assert.Error(t, (&Config{JWTSecret: ""}).CheckJWTSecret())
+	assert.Error(t, (&Config{JWTSecret: "", DevMode: true}).CheckJWTSecret())
+	assert.Error(t, (&Config{JWTSecret: defaultJWTSecret}).CheckJWTSecret())
+	assert.NoError(t, (&Config{JWTSecret: defaultJWTSecret, DevMode: true}).CheckJWTSecret())
+	assert.NoError(t, (&Config{JWTSecret: "9f2c4e7a1b"}).CheckJWTSecret())
+}
+
+func TestGetEnvList(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", " 10.0.0.0/8, ,192.168.1.4 ")
+	assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.4"}, getEnvList("TRUSTED_PROXIES", ""))
+	assert.Nil(t, getEnvList("UNSET_LIST", ""))
+}
@@ -0,0 +1,125 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"nusatek-backend/internal/domain"
+)
+
+// AmenityHandler manages the tenant's catalogue of amenities, which
+// properties refer to by code
+type AmenityHandler struct {
+	AmenityUsecase domain.AmenityUsecase
+}
+
+type amenityRequest struct {
+	Code     string `json:"code" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Category string `json:"category" binding:"required"`
+	Unit     string `json:"unit"`
+}
+
+func NewAmenityHandler(r *gin.RouterGroup, us domain.AmenityUsecase) {
+	handler := &AmenityHandler{
+		AmenityUsecase: us,
+	}
+
+	r.GET("/amenities", handler.Fetch)
+	r.POST("/amenities", handler.Store)
+	r.PUT("/amenities/:id", handler.Update)
+	r.DELETE("/amenities/:id", handler.Delete)
+}
+
+func (h *AmenityHandler) Fetch(c *gin.Context) {
+	amenities, err := h.AmenityUsecase.Fetch(c.Request.Context())
+	if err != nil {
+		amenityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, amenities)
+}
+
+func (h *AmenityHandler) Store(c *gin.Context) {
+	var req amenityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	a := req.toAmenity()
+	if err := h.AmenityUsecase.Store(c.Request.Context(), &a); err != nil {
+		amenityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, a)
+}
+
+func (h *AmenityHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req amenityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	a := req.toAmenity()
+	a.ID = int64(id)
+	if err := h.AmenityUsecase.Update(c.Request.Context(), &a); err != nil {
+		amenityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, a)
+}
+
+// Delete removes an amenity from the catalogue and from every property
+func (h *AmenityHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := h.AmenityUsecase.Delete(c.Request.Context(), int64(id)); err != nil {
+		amenityError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Deleted successfully"})
+}
+
+func (r amenityRequest) toAmenity() domain.Amenity {
+	return domain.Amenity{
+		Code:     r.Code,
+		Name:     r.Name,
+		Category: r.Category,
+		Unit:     r.Unit,
+	}
+}
+
+func amenityError(c *gin.Context, err error) {
+	if respondForbidden(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, domain.ErrAmenityNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrAmenityExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrInvalidAmenity):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"nusatek-backend/internal/domain"
+)
+
+type APIKeyHandler struct {
+	APIKeyUsecase domain.APIKeyUsecase
+}
+
+type apiKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func NewAPIKeyHandler(r *gin.RouterGroup, us domain.APIKeyUsecase) {
+	handler := &APIKeyHandler{
+		APIKeyUsecase: us,
+	}
+
+	r.GET("/api-keys", handler.Fetch)
+	r.POST("/api-keys", handler.Issue)
+	r.POST("/api-keys/:id/rotate", handler.Rotate)
+	r.DELETE("/api-keys/:id", handler.Revoke)
+}
+
+func (h *APIKeyHandler) Fetch(c *gin.Context) {
+	keys, err := h.APIKeyUsecase.Fetch(c.Request.Context())
+	if err != nil {
+		apiKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+func (h *APIKeyHandler) Issue(c *gin.Context) {
+	var req apiKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	k := domain.APIKey{Name: req.Name, Scopes: req.Scopes, ExpiresAt: req.ExpiresAt}
+	issued, err := h.APIKeyUsecase.Issue(c.Request.Context(), &k)
+	if err != nil {
+		apiKeyError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, issued)
+}
+
+func (h *APIKeyHandler) Rotate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	issued, err := h.APIKeyUsecase.Rotate(c.Request.Context(), int64(id))
+	if err != nil {
+		apiKeyError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, issued)
+}
+
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := h.APIKeyUsecase.Revoke(c.Request.Context(), int64(id)); err != nil {
+		apiKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
+
+func apiKeyError(c *gin.Context, err error) {
+	if respondForbidden(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, domain.ErrAPIKeyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrInvalidAPIKey):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"nusatek-backend/internal/domain"
+)
+
+type AuditHandler struct {
+	AuditUsecase domain.AuditUsecase
+}
+
+func NewAuditHandler(r *gin.RouterGroup, us domain.AuditUsecase) {
+	handler := &AuditHandler{
+		AuditUsecase: us,
+	}
+
+	r.GET("/audit", handler.Fetch)
+}
+
+// Fetch lists audit entries, optionally for one entity: /audit?entity=property&id=12
+func (h *AuditHandler) Fetch(c *gin.Context) {
+	filter := domain.AuditFilter{
+		Entity: c.Query("entity"),
+		Limit:  50,
+	}
+	if id := c.Query("id"); id != "" {
+		entityID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil || filter.Entity == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a number and requires entity"})
+			return
+		}
+		filter.EntityID = entityID
+	}
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 && l <= 500 {
+		filter.Limit = l
+	}
+	if o, err := strconv.Atoi(c.Query("offset")); err == nil && o > 0 {
+		filter.Offset = o
+	}
+
+	entries, err := h.AuditUsecase.Fetch(c.Request.Context(), filter)
+	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,159 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"nusatek-backend/internal/domain"
+)
+
+type AuthHandler struct {
+	AuthUsecase domain.AuthUsecase
+	UserUsecase domain.UserUsecase
+}
+
+type loginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type logoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type createUserRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Role     string `json:"role"`
+	BranchID int64  `json:"branch_id"`
+}
+
+// NewAuthHandler registers login and refresh on public and the remaining
+// account routes on the authenticated group api.
+func NewAuthHandler(public *gin.RouterGroup, api *gin.RouterGroup, auth domain.AuthUsecase, users domain.UserUsecase) {
+	handler := &AuthHandler{
+		AuthUsecase: auth,
+		UserUsecase: users,
+	}
+
+	public.POST("/auth/login", handler.Login)
+	public.POST("/auth/refresh", handler.Refresh)
+
+	api.POST("/auth/logout", handler.Logout)
+	api.GET("/auth/me", handler.Me)
+	api.POST("/auth/stream-token", handler.StreamToken)
+	api.POST("/users", handler.StoreUser)
+}
+
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req loginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.AuthUsecase.Login(c.Request.Context(), req.Email, req.Password)
+	if err != nil {
+		authError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.AuthUsecase.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		authError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req logoutRequest
+	// The body is optional; without a refresh token only the access token is revoked
+	_ = c.ShouldBindJSON(&req)
+
+	principal, _ := domain.PrincipalFromContext(c.Request.Context())
+	if err := h.AuthUsecase.Logout(c.Request.Context(), principal, req.RefreshToken); err != nil {
+		authError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+func (h *AuthHandler) Me(c *gin.Context) {
+	principal, _ := domain.PrincipalFromContext(c.Request.Context())
+
+	user, err := h.UserUsecase.GetByID(c.Request.Context(), principal.UserID)
+	if err != nil {
+		authError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// StreamToken issues a token for opening GET /stream?access_token=
+func (h *AuthHandler) StreamToken(c *gin.Context) {
+	principal, _ := domain.PrincipalFromContext(c.Request.Context())
+
+	token, err := h.AuthUsecase.StreamToken(c.Request.Context(), principal)
+	if err != nil {
+		authError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, token)
+}
+
+func (h *AuthHandler) StoreUser(c *gin.Context) {
+	var req createUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := domain.User{Email: req.Email, Name: req.Name, Role: req.Role, BranchID: req.BranchID}
+	if err := h.UserUsecase.Store(c.Request.Context(), &user, req.Password); err != nil {
+		authError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+func authError(c *gin.Context, err error) {
+	if respondForbidden(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, domain.ErrInvalidCredentials), errors.Is(err, domain.ErrInvalidToken):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrInvalidUser):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
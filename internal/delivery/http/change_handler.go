@@ -0,0 +1,51 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"nusatek-backend/internal/domain"
+)
+
+type ChangeHandler struct {
+	ChangeUsecase domain.ChangeUsecase
+}
+
+func NewChangeHandler(r *gin.RouterGroup, us domain.ChangeUsecase) {
+	handler := &ChangeHandler{
+		ChangeUsecase: us,
+	}
+
+	r.GET("/changes", handler.Fetch)
+}
+
+// Fetch returns changes after ?since=<token>; pass next_token back to continue
+func (h *ChangeHandler) Fetch(c *gin.Context) {
+	limit := 100
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 && l <= 1000 {
+		limit = l
+	}
+
+	var kinds []string
+	if t := c.Query("types"); t != "" {
+		kinds = strings.Split(t, ",")
+	}
+
+	feed, err := h.ChangeUsecase.FetchSince(c.Request.Context(), c.Query("since"), kinds, limit)
+	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidChangeToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, feed)
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -13,14 +14,15 @@ type CustomerHandler struct {
 	CUsecase domain.CustomerUsecase
 }
 
-func NewCustomerHandler(r *gin.Engine, us domain.CustomerUsecase) {
+func NewCustomerHandler(r *gin.RouterGroup, us domain.CustomerUsecase) {
 	handler := &CustomerHandler{
 		CUsecase: us,
 	}
-	r.GET("/api/v1/customers", handler.Fetch)
-	r.POST("/api/v1/customers", handler.Store)
-	r.GET("/api/v1/customers/:id", handler.GetByID)
-	r.DELETE("/api/v1/customers/:id", handler.Delete)
+	r.GET("/customers", handler.Fetch)
+	r.POST("/customers", handler.Store)
+	r.GET("/customers/:id", handler.GetByID)
+	r.DELETE("/customers/:id", handler.Delete)
+	r.POST("/customers/:id/restore", handler.Restore)
 }
 
 func (h *CustomerHandler) Fetch(c *gin.Context) {
@@ -29,6 +31,9 @@ func (h *CustomerHandler) Fetch(c *gin.Context) {
 	
 	list, err := h.CUsecase.Fetch(c.Request.Context(), limit, offset)
 	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -44,7 +49,14 @@ func (h *CustomerHandler) GetByID(c *gin.Context) {
 
 	cust, err := h.CUsecase.GetByID(c.Request.Context(), int64(id))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		if respondForbidden(c, err) {
+			return
+		}
+		if errors.Is(err, domain.ErrCustomerNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, cust)
@@ -58,6 +70,13 @@ func (h *CustomerHandler) Store(c *gin.Context) {
 	}
 
 	if err := h.CUsecase.Store(c.Request.Context(), &cust); err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		if errors.Is(err, domain.ErrCustomerEmailTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -72,8 +91,40 @@ func (h *CustomerHandler) Delete(c *gin.Context) {
 	}
 
 	if err := h.CUsecase.Delete(c.Request.Context(), int64(id)); err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		if errors.Is(err, domain.ErrCustomerNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Deleted successfully"})
 }
+
+func (h *CustomerHandler) Restore(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := h.CUsecase.Restore(c.Request.Context(), int64(id)); err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		if errors.Is(err, domain.ErrNotInTrash) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found in trash"})
+			return
+		}
+		if errors.Is(err, domain.ErrCustomerEmailTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Restored successfully"})
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"nusatek-backend/internal/domain"
+)
+
+type DeadLetterHandler struct {
+	DLUsecase domain.DeadLetterUsecase
+}
+
+type replayRequest struct {
+	IDs []string `json:"ids"`
+	All bool     `json:"all"`
+}
+
+func NewDeadLetterHandler(r *gin.RouterGroup, us domain.DeadLetterUsecase) {
+	handler := &DeadLetterHandler{
+		DLUsecase: us,
+	}
+
+	r.GET("/admin/dead-letters", handler.Fetch)
+	r.POST("/admin/dead-letters/replay", handler.Replay)
+	r.POST("/admin/dead-letters/:id/replay", handler.ReplayOne)
+}
+
+func (h *DeadLetterHandler) Fetch(c *gin.Context) {
+	limit := 50
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	letters, err := h.DLUsecase.Fetch(c.Request.Context(), limit)
+	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, letters)
+}
+
+func (h *DeadLetterHandler) ReplayOne(c *gin.Context) {
+	replayed, err := h.DLUsecase.Replay(c.Request.Context(), []string{c.Param("id")})
+	if err != nil {
+		h.replayError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"replayed": replayed})
+}
+
+func (h *DeadLetterHandler) Replay(c *gin.Context) {
+	var req replayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.All && len(req.IDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "either ids or all must be set"})
+		return
+	}
+
+	var (
+		replayed int
+		err      error
+	)
+	if req.All {
+		replayed, err = h.DLUsecase.ReplayAll(c.Request.Context())
+	} else {
+		replayed, err = h.DLUsecase.Replay(c.Request.Context(), req.IDs)
+	}
+	if err != nil {
+		h.replayError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"replayed": replayed})
+}
+
+func (h *DeadLetterHandler) replayError(c *gin.Context, err error) {
+	if respondForbidden(c, err) {
+		return
+	}
+	if errors.Is(err, domain.ErrDeadLetterNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
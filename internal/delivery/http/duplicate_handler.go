@@ -0,0 +1,77 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"nusatek-backend/internal/domain"
+)
+
+type DuplicateHandler struct {
+	DuplicateUsecase domain.DuplicateUsecase
+}
+
+func NewDuplicateHandler(r *gin.RouterGroup, us domain.DuplicateUsecase) {
+	handler := &DuplicateHandler{
+		DuplicateUsecase: us,
+	}
+
+	r.GET("/properties/:id/possible-duplicates", handler.Fetch)
+	r.POST("/properties/:id/merge", handler.Merge)
+}
+
+func (h *DuplicateHandler) Fetch(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	matches, err := h.DuplicateUsecase.FetchDuplicates(c.Request.Context(), int64(id))
+	if err != nil {
+		respondDuplicateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, matches)
+}
+
+// Merge folds the listing in {"duplicate_id": 12} into :id, which is kept
+func (h *DuplicateHandler) Merge(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var body struct {
+		DuplicateID int64 `json:"duplicate_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	p, err := h.DuplicateUsecase.Merge(c.Request.Context(), int64(id), body.DuplicateID)
+	if err != nil {
+		respondDuplicateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, p)
+}
+
+func respondDuplicateError(c *gin.Context, err error) {
+	if respondForbidden(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, domain.ErrPropertyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+	case errors.Is(err, domain.ErrInvalidMerge):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,8 +1,10 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"nusatek-backend/internal/domain"
@@ -12,38 +14,254 @@ type PropertyHandler struct {
 	PropertyUsecase domain.PropertyUsecase
 }
 
-func NewPropertyHandler(r *gin.Engine, us domain.PropertyUsecase) {
+func NewPropertyHandler(r *gin.RouterGroup, us domain.PropertyUsecase) {
 	handler := &PropertyHandler{
 		PropertyUsecase: us,
 	}
 
-	api := r.Group("/api/v1")
-	{
-		api.GET("/properties", handler.Fetch)
-		api.GET("/properties/:id", handler.GetByID)
-		api.POST("/properties", handler.Store)
-		api.PUT("/properties/:id", handler.Update)
-		api.DELETE("/properties/:id", handler.Delete)
+	r.GET("/properties", handler.Fetch)
+	r.GET("/properties/nearby", handler.FetchNearby)
+	r.GET("/properties/in-bounds", handler.FetchInBounds)
+	r.GET("/properties/:id", handler.GetByID)
+	r.POST("/properties", handler.Store)
+	r.PUT("/properties/:id", handler.Update)
+	r.DELETE("/properties/:id", handler.Delete)
+	r.POST("/properties/:id/restore", handler.Restore)
+	r.GET("/properties/:id/revisions", handler.FetchRevisions)
+	r.GET("/properties/:id/revisions/diff", handler.DiffRevisions)
+	r.POST("/properties/:id/revisions/:revision/rollback", handler.Rollback)
+	r.POST("/properties/:id/status", handler.Transition)
+}
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// propertyFilter reads the list filters shared by the property and public
+// listing endpoints, e.g. ?property_type=rumah,ruko&bedrooms_min=3&price_max=2000000000.
+// It writes a 400 response and returns false when a filter is invalid.
+func propertyFilter(c *gin.Context) (domain.PropertyFilter, bool) {
+	filter := domain.PropertyFilter{Limit: defaultPageSize}
+
+	// Pages are kept small enough that nobody can dump every listing at once
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil {
+		filter.Limit = min(max(l, 1), maxPageSize)
+	}
+	if o, err := strconv.Atoi(c.Query("offset")); err == nil && o > 0 {
+		filter.Offset = o
+	}
+
+	q := queryParser{c: c}
+	filter.Statuses = q.list("status", domain.ValidPropertyStatus)
+	filter.PropertyTypes = q.list("property_type", allowed(domain.PropertyTypes))
+	if lt := q.list("listing_type", allowed(domain.ListingTypes)); len(lt) == 1 {
+		filter.ListingType = lt[0]
+	}
+	filter.Certificates = q.list("certificate", allowed(domain.Certificates))
+	filter.Furnishings = q.list("furnishing", allowed(domain.Furnishings))
+	filter.Amenities = q.list("amenities", domain.ValidAmenityCode)
+	filter.MinBedrooms = q.int("bedrooms_min")
+	filter.MaxBedrooms = q.int("bedrooms_max")
+	filter.MinBathrooms = q.int("bathrooms_min")
+	filter.MinFloors = q.int("floors_min")
+	filter.MaxFloors = q.int("floors_max")
+	filter.MinLandArea = q.float("land_area_min")
+	filter.MaxLandArea = q.float("land_area_max")
+	filter.MinBuildingArea = q.float("building_area_min")
+	filter.MaxBuildingArea = q.float("building_area_max")
+	filter.MinYearBuilt = q.int("year_built_min")
+	filter.MaxYearBuilt = q.int("year_built_max")
+	filter.MinPrice = q.money("price_min")
+	filter.MaxPrice = q.money("price_max")
+	filter.ProvinceCode = q.region("province", domain.RegionProvince)
+	filter.RegencyCode = q.region("regency", domain.RegionRegency)
+	filter.DistrictCode = q.region("district", domain.RegionDistrict)
+	filter.VillageCode = q.region("village", domain.RegionVillage)
+
+	if q.err != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": q.err})
+		return filter, false
+	}
+	return filter, true
+}
+
+// queryParser reads typed query parameters, keeping the first error
+type queryParser struct {
+	c   *gin.Context
+	err string
+}
+
+func (q *queryParser) fail(name string, value string) {
+	if q.err == "" {
+		q.err = "Invalid " + name + ": " + value
+	}
+}
+
+// list reads a comma-separated parameter whose values must pass valid
+func (q *queryParser) list(name string, valid func(string) bool) []string {
+	value := q.c.Query(name)
+	if value == "" {
+		return nil
+	}
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if !valid(v) {
+			q.fail(name, v)
+			return nil
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+func (q *queryParser) int(name string) int {
+	value := q.c.Query(name)
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		q.fail(name, value)
+	}
+	return n
+}
+
+func (q *queryParser) float(name string) float64 {
+	value := q.c.Query(name)
+	if value == "" {
+		return 0
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		q.fail(name, value)
+	}
+	return f
+}
+
+// money reads an amount in the currency given by the currency parameter
+func (q *queryParser) money(name string) *domain.Money {
+	value := q.c.Query(name)
+	if value == "" {
+		return nil
+	}
+	m, err := domain.ParseMoney(value, q.c.Query("currency"))
+	if err != nil {
+		q.fail(name, value)
+		return nil
+	}
+	return &m
+}
+
+// region reads a region code of the given level, e.g. regency=32.73
+func (q *queryParser) region(name string, level int) string {
+	value := q.c.Query(name)
+	if value != "" && domain.RegionLevel(value) != level {
+		q.fail(name, value)
+		return ""
+	}
+	return value
+}
+
+func allowed(values []string) func(string) bool {
+	return func(v string) bool {
+		for _, a := range values {
+			if v == a {
+				return true
+			}
+		}
+		return false
 	}
 }
 
 func (h *PropertyHandler) Fetch(c *gin.Context) {
-	limit := 10
-	offset := 0
+	filter, ok := propertyFilter(c)
+	if !ok {
+		return
+	}
 
-	// Basic query param parsing
-	if l, err := strconv.Atoi(c.Query("limit")); err == nil {
-		limit = l
+	properties, err := h.PropertyUsecase.Fetch(c.Request.Context(), filter)
+	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Query("facets") != "true" {
+		c.JSON(http.StatusOK, properties)
+		return
+	}
+
+	// ?facets=true wraps the page with the counts over every matching property
+	facets, err := h.PropertyUsecase.Facets(c.Request.Context(), filter)
+	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": properties, "facets": facets})
+}
+
+// FetchNearby lists properties within radius_km of lat,lng, nearest first
+func (h *PropertyHandler) FetchNearby(c *gin.Context) {
+	var q domain.GeoQuery
+	for _, p := range []struct {
+		name  string
+		value *float64
+	}{{"lat", &q.Center.Lat}, {"lng", &q.Center.Lng}, {"radius_km", &q.RadiusKm}} {
+		f, err := strconv.ParseFloat(c.Query(p.name), 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + p.name + ": " + c.Query(p.name)})
+			return
+		}
+		*p.value = f
 	}
-	if o, err := strconv.Atoi(c.Query("offset")); err == nil {
-		offset = o
+	h.fetchNearby(c, q)
+}
+
+// FetchInBounds lists properties inside bbox=south,west,north,east, nearest
+// to its centre first
+func (h *PropertyHandler) FetchInBounds(c *gin.Context) {
+	parts := strings.Split(c.Query("bbox"), ",")
+	var v [4]float64
+	ok := len(parts) == 4
+	for i := 0; ok && i < len(v); i++ {
+		var err error
+		v[i], err = strconv.ParseFloat(strings.TrimSpace(parts[i]), 64)
+		ok = err == nil
 	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bbox: expected south,west,north,east"})
+		return
+	}
+	h.fetchNearby(c, domain.GeoQuery{Box: &domain.BoundingBox{South: v[0], West: v[1], North: v[2], East: v[3]}})
+}
 
-	properties, err := h.PropertyUsecase.Fetch(c.Request.Context(), limit, offset)
+// fetchNearby runs a geo search with the same filters as Fetch
+func (h *PropertyHandler) fetchNearby(c *gin.Context, q domain.GeoQuery) {
+	filter, ok := propertyFilter(c)
+	if !ok {
+		return
+	}
+
+	properties, err := h.PropertyUsecase.FetchNearby(c.Request.Context(), filter, q)
 	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidGeoQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if properties == nil {
+		properties = []domain.NearbyProperty{}
+	}
 
 	c.JSON(http.StatusOK, properties)
 }
@@ -57,6 +275,9 @@ func (h *PropertyHandler) GetByID(c *gin.Context) {
 
 	property, err := h.PropertyUsecase.GetByID(c.Request.Context(), int64(id))
 	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
 		return
 	}
@@ -72,7 +293,7 @@ func (h *PropertyHandler) Store(c *gin.Context) {
 	}
 
 	if err := h.PropertyUsecase.Store(c.Request.Context(), &property); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondPropertyError(c, err)
 		return
 	}
 
@@ -94,7 +315,7 @@ func (h *PropertyHandler) Update(c *gin.Context) {
 	property.ID = int64(id)
 
 	if err := h.PropertyUsecase.Update(c.Request.Context(), &property); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondPropertyError(c, err)
 		return
 	}
 
@@ -109,9 +330,154 @@ func (h *PropertyHandler) Delete(c *gin.Context) {
 	}
 
 	if err := h.PropertyUsecase.Delete(c.Request.Context(), int64(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondPropertyError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Deleted successfully"})
 }
+
+func (h *PropertyHandler) Restore(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := h.PropertyUsecase.Restore(c.Request.Context(), int64(id)); err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		if errors.Is(err, domain.ErrNotInTrash) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Property not found in trash"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Restored successfully"})
+}
+
+func (h *PropertyHandler) FetchRevisions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	limit, offset := pageParams(c)
+
+	revisions, err := h.PropertyUsecase.FetchRevisions(c.Request.Context(), int64(id), limit, offset)
+	if err != nil {
+		respondPropertyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// DiffRevisions compares two revisions: /properties/12/revisions/diff?from=1&to=3
+func (h *PropertyHandler) DiffRevisions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	from, errFrom := strconv.Atoi(c.Query("from"))
+	to, errTo := strconv.Atoi(c.Query("to"))
+	if errFrom != nil || errTo != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be revision numbers"})
+		return
+	}
+
+	diff, err := h.PropertyUsecase.DiffRevisions(c.Request.Context(), int64(id), from, to)
+	if err != nil {
+		if errors.Is(err, domain.ErrRevisionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		respondPropertyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+func (h *PropertyHandler) Rollback(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision"})
+		return
+	}
+
+	property, err := h.PropertyUsecase.Rollback(c.Request.Context(), int64(id), revision)
+	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		if errors.Is(err, domain.ErrRevisionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+			return
+		}
+		respondPropertyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, property)
+}
+
+// Transition changes the listing status: {"status": "published"}
+func (h *PropertyHandler) Transition(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var req struct {
+		Status string `json:"status" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	property, err := h.PropertyUsecase.Transition(c.Request.Context(), int64(id), req.Status)
+	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, domain.ErrInvalidStatus):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrInvalidTransition):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrPropertyNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, property)
+}
+
+// respondPropertyError writes the response for a failed property write
+func respondPropertyError(c *gin.Context, err error) {
+	if respondForbidden(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, domain.ErrPropertyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+	case errors.Is(err, domain.ErrInvalidProperty), errors.Is(err, domain.ErrInvalidAddress):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
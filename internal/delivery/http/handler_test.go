@@ -0,0 +1,261 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"nusatek-backend/internal/domain"
+)
+
+func TestPropertyFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	parse := func(query string) (domain.PropertyFilter, *httptest.ResponseRecorder, bool) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/properties?"+query, nil)
+		f, ok := propertyFilter(c)
+		return f, w, ok
+	}
+
+	f, _, ok := parse("property_type=rumah,ruko&listing_type=sale&bedrooms_min=3&land_area_max=120.5&certificate=SHM&price_max=2000000000&limit=20")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"rumah", "ruko"}, f.PropertyTypes)
+	assert.Equal(t, "sale", f.ListingType)
+	assert.Equal(t, 3, f.MinBedrooms)
+	assert.Equal(t, 120.5, f.MaxLandArea)
+	assert.Equal(t, []string{"SHM"}, f.Certificates)
+	assert.Equal(t, &domain.Money{Amount: 200000000000, Currency: "IDR"}, f.MaxPrice)
+	assert.Equal(t, 20, f.Limit)
+
+	_, w, ok := parse("property_type=kastil")
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	_, _, ok = parse("bedrooms_min=-1")
+	assert.False(t, ok)
+
+	f, _, ok = parse("amenities=pool,near_toll_gate")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"pool", "near_toll_gate"}, f.Amenities)
+
+	_, _, ok = parse("amenities=Pool")
+	assert.False(t, ok)
+
+	for query, limit := range map[string]int{"": 20, "limit=50": 50, "limit=0": 1, "limit=-5": 1, "limit=100000": 100, "limit=all": 20} {
+		f, _, ok = parse(query)
+		assert.True(t, ok)
+		assert.Equal(t, limit, f.Limit, query)
+	}
+	f, _, _ = parse("offset=-10")
+	assert.Equal(t, 0, f.Offset)
+}
+
+func TestPropertyFilterRegions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/properties?regency=32.73&district=32.73.01", nil)
+	f, ok := propertyFilter(c)
+	assert.True(t, ok)
+	assert.Equal(t, "32.73", f.RegencyCode)
+	assert.Equal(t, "32.73.01", f.DistrictCode)
+
+	c, _ = gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/properties?regency=32", nil)
+	_, ok = propertyFilter(c)
+	assert.False(t, ok)
+}
+
+// stubProperties answers with fixed results; other methods are unused
+type stubProperties struct {
+	domain.PropertyUsecase
+	err        error
+	properties []domain.Property
+	facets     domain.PropertyFacets
+	filter     *domain.PropertyFilter
+	geo        *domain.GeoQuery
+}
+
+func (s stubProperties) Fetch(ctx context.Context, f domain.PropertyFilter) ([]domain.Property, error) {
+	return s.properties, s.err
+}
+
+func (s stubProperties) FetchPublished(ctx context.Context, f domain.PropertyFilter) ([]domain.Property, error) {
+	return s.properties, s.err
+}
+
+func (s stubProperties) GetPublished(ctx context.Context, id int64) (domain.Property, error) {
+	for _, p := range s.properties {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return domain.Property{}, domain.ErrPropertyNotFound
+}
+
+func (s stubProperties) FetchNearby(ctx context.Context, f domain.PropertyFilter, q domain.GeoQuery) ([]domain.NearbyProperty, error) {
+	if s.geo != nil {
+		*s.geo = q
+	}
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	return nil, s.err
+}
+
+func (s stubProperties) Facets(ctx context.Context, f domain.PropertyFilter) (domain.PropertyFacets, error) {
+	if s.filter != nil {
+		*s.filter = f
+	}
+	return s.facets, s.err
+}
+
+func (s stubProperties) Delete(ctx context.Context, id int64) error {
+	return s.err
+}
+
+func TestDeletePropertyNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for err, code := range map[error]int{
+		domain.ErrPropertyNotFound: http.StatusNotFound,
+		domain.ErrForbidden:        http.StatusForbidden,
+		errors.New("db down"):      http.StatusInternalServerError,
+	} {
+		r := gin.New()
+		NewPropertyHandler(r.Group(""), stubProperties{err: err})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/properties/9", nil))
+		assert.Equal(t, code, w.Code, err.Error())
+	}
+}
+
+func (s stubProperties) FetchRevisions(ctx context.Context, id int64, limit, offset int) ([]domain.PropertyRevision, error) {
+	return nil, s.err
+}
+
+func (s stubProperties) DiffRevisions(ctx context.Context, id int64, from, to int) (domain.RevisionDiff, error) {
+	return domain.RevisionDiff{}, s.err
+}
+
+func TestRevisionsErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for err, code := range map[error]int{
+		domain.ErrPropertyNotFound: http.StatusNotFound,
+		domain.ErrForbidden:        http.StatusForbidden,
+		errors.New("db down"):      http.StatusInternalServerError,
+	} {
+		r := gin.New()
+		NewPropertyHandler(r.Group(""), stubProperties{err: err})
+
+		for _, path := range []string{"/properties/999/revisions", "/properties/999/revisions/diff?from=1&to=2"} {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			assert.Equal(t, code, w.Code, path, err.Error())
+		}
+	}
+
+	r := gin.New()
+	NewPropertyHandler(r.Group(""), stubProperties{err: domain.ErrRevisionNotFound})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/properties/9/revisions/diff?from=1&to=7", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestFetchWithFacets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var filter domain.PropertyFilter
+	stub := stubProperties{
+		properties: []domain.Property{{ID: 9, Title: "Rumah"}},
+		facets: domain.PropertyFacets{
+			Total:         1,
+			PropertyTypes: []domain.FacetCount{{Value: "rumah", Count: 1}},
+			Amenities:     []domain.FacetCount{{Value: "pool", Name: "Kolam renang", Count: 1}},
+			Prices:        domain.PriceBuckets(domain.ListingSale),
+		},
+		filter: &filter,
+	}
+	r := gin.New()
+	NewPropertyHandler(r.Group(""), stub)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/properties?listing_type=sale&amenities=pool", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Body.String(), "["), "a plain list without ?facets=true")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/properties?listing_type=sale&amenities=pool&facets=true", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Data   []domain.Property     `json:"data"`
+		Facets domain.PropertyFacets `json:"facets"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Len(t, body.Data, 1)
+	assert.Equal(t, int64(1), body.Facets.Total)
+	assert.Equal(t, "pool", body.Facets.Amenities[0].Value)
+	assert.Len(t, body.Facets.Prices, 6)
+	assert.Equal(t, "sale", body.Facets.Prices[0].ListingType)
+	// The facets are counted over the same filter as the page
+	assert.Equal(t, "sale", filter.ListingType)
+	assert.Equal(t, []string{"pool"}, filter.Amenities)
+
+	r = gin.New()
+	NewPropertyHandler(r.Group(""), stubProperties{err: domain.ErrForbidden})
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/properties?facets=true", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestFetchInBounds(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var q domain.GeoQuery
+	r := gin.New()
+	NewPropertyHandler(r.Group(""), stubProperties{geo: &q})
+	get := func(bbox string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/properties/in-bounds?bbox="+bbox, nil))
+		return w
+	}
+
+	w := get("-6.4,%20106.6,-6.1,107.0")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "[]", w.Body.String())
+	assert.Equal(t, &domain.BoundingBox{South: -6.4, West: 106.6, North: -6.1, East: 107.0}, q.Box)
+
+	for _, bbox := range []string{"", "-6.4,106.6,-6.1", "-6.4,106.6,-6.1,east", "-6.1,106.6,-6.4,107.0", "-8.8,105.1,-5.9,114.6"} {
+		assert.Equal(t, http.StatusBadRequest, get(bbox).Code, bbox)
+	}
+}
+
+func TestPublicListings(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	NewListingHandler(r.Group(""), stubProperties{properties: []domain.Property{
+		{ID: 9, Title: "Rumah", Status: domain.PropertyPublished, AgentID: 4, BranchID: 2},
+	}})
+
+	for _, path := range []string{"/tenants/2/listings", "/tenants/2/listings/9"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.Contains(t, w.Body.String(), `"title":"Rumah"`, path)
+		// Visitors do not learn who in the agency handles the listing
+		assert.NotContains(t, w.Body.String(), "agent_id", path)
+		assert.NotContains(t, w.Body.String(), "branch_id", path)
+	}
+}
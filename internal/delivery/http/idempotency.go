@@ -0,0 +1,129 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"nusatek-backend/internal/domain"
+)
+
+// IdempotencyKeyHeader lets clients retry a POST without repeating its effect
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+const (
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodySize bounds the request bodies buffered to fingerprint them
+	maxIdempotentBodySize = 1 << 20
+)
+
+// Idempotency replays the stored response when a request to one of routes,
+// given as "METHOD /route/pattern", is retried with the same Idempotency-Key
+// and payload. Keys are scoped to the tenant and client. Server errors are not
+// stored so the request can be retried, nor are responses marked
+// Cache-Control: no-store such as credentials. If the store is unavailable
+// requests proceed without protection.
+func Idempotency(repo domain.IdempotencyRepository, routes ...string) gin.HandlerFunc {
+	enabled := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		enabled[route] = true
+	}
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || !enabled[c.Request.Method+" "+c.FullPath()] {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			problem(c, http.StatusBadRequest, "Idempotency-Key must be at most "+strconv.Itoa(maxIdempotencyKeyLength)+" characters")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBodySize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			problem(c, http.StatusRequestEntityTooLarge, "Request body must be at most "+strconv.FormatInt(tooLarge.Limit, 10)+" bytes")
+			return
+		}
+		if err != nil {
+			problem(c, http.StatusBadRequest, "Failed to read request body")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		tenant, _ := domain.TenantFromContext(c.Request.Context())
+		scope := strconv.FormatInt(tenant, 10) + ":" + clientID(c) + ":" + key
+		sum := sha256.Sum256(append([]byte(c.Request.Method+" "+c.Request.URL.Path+"\n"), body...))
+		fingerprint := hex.EncodeToString(sum[:])
+
+		stored, err := repo.Begin(c.Request.Context(), scope, fingerprint)
+		switch {
+		case errors.Is(err, domain.ErrIdempotencyKeyReused):
+			problem(c, http.StatusUnprocessableEntity, err.Error())
+			return
+		case errors.Is(err, domain.ErrIdempotencyInProgress):
+			problem(c, http.StatusConflict, err.Error())
+			return
+		case err != nil:
+			log.Printf("idempotency: %v", err)
+			c.Next()
+			return
+		case stored != nil:
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(stored.Status, stored.ContentType, stored.Body)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError || noStore(recorder.Header()) {
+			err = repo.Release(c.Request.Context(), scope)
+		} else {
+			err = repo.Complete(c.Request.Context(), scope, fingerprint, domain.IdempotentResponse{
+				Status:      recorder.Status(),
+				ContentType: recorder.Header().Get("Content-Type"),
+				Body:        recorder.body.Bytes(),
+			})
+		}
+		if err != nil {
+			log.Printf("idempotency: %v", err)
+		}
+	}
+}
+
+// noStore reports whether a response must not be kept, like issued credentials
+func noStore(h http.Header) bool {
+	for _, directive := range strings.Split(h.Get("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), "no-store") {
+			return true
+		}
+	}
+	return false
+}
+
+// responseRecorder keeps a copy of the response body as it is written
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"nusatek-backend/internal/domain"
+)
+
+type memoryIdempotency struct {
+	fingerprints map[string]string
+	responses    map[string]domain.IdempotentResponse
+}
+
+func (m *memoryIdempotency) Begin(ctx context.Context, key string, fingerprint string) (*domain.IdempotentResponse, error) {
+	if fp, ok := m.fingerprints[key]; ok {
+		if fp != fingerprint {
+			return nil, domain.ErrIdempotencyKeyReused
+		}
+		resp, done := m.responses[key]
+		if !done {
+			return nil, domain.ErrIdempotencyInProgress
+		}
+		return &resp, nil
+	}
+	m.fingerprints[key] = fingerprint
+	return nil, nil
+}
+
+func (m *memoryIdempotency) Complete(ctx context.Context, key string, fingerprint string, resp domain.IdempotentResponse) error {
+	m.responses[key] = resp
+	return nil
+}
+
+func (m *memoryIdempotency) Release(ctx context.Context, key string) error {
+	delete(m.fingerprints, key)
+	return nil
+}
+
+func TestIdempotencyReplaysFirstResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := &memoryIdempotency{fingerprints: map[string]string{}, responses: map[string]domain.IdempotentResponse{}}
+
+	created := 0
+	r := gin.New()
+	r.POST("/properties", Idempotency(repo, "POST /properties"), func(c *gin.Context) {
+		created++
+		c.JSON(http.StatusCreated, gin.H{"id": created})
+	})
+
+	send := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/properties", strings.NewReader(body))
+		req.Header.Set(IdempotencyKeyHeader, key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	first := send("abc", `{"title":"Rumah"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+
+	retry := send("abc", `{"title":"Rumah"}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, 1, created)
+
+	reused := send("abc", `{"title":"Villa"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+
+	other := send("def", `{"title":"Villa"}`)
+	assert.Equal(t, http.StatusCreated, other.Code)
+	assert.Equal(t, 2, created)
+}
+
+func TestIdempotencyLeavesOtherRequestsAlone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := &memoryIdempotency{fingerprints: map[string]string{}, responses: map[string]domain.IdempotentResponse{}}
+
+	calls := 0
+	r := gin.New()
+	r.Use(Idempotency(repo, "POST /properties"))
+	r.POST("/properties", func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"id": calls})
+	})
+	r.POST("/api-keys", func(c *gin.Context) {
+		calls++
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusCreated, gin.H{"key": "nsk_secret"})
+	})
+	send := func(path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set(IdempotencyKeyHeader, key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Routes that were not listed run every time
+	send("/api-keys", "abc", `{}`)
+	send("/api-keys", "abc", `{}`)
+	assert.Equal(t, 2, calls)
+	assert.Empty(t, repo.fingerprints)
+
+	w := send("/properties", "big", `{"description":"`+strings.Repeat("x", maxIdempotentBodySize)+`"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotencyNeverStoresNoStore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := &memoryIdempotency{fingerprints: map[string]string{}, responses: map[string]domain.IdempotentResponse{}}
+
+	issued := 0
+	r := gin.New()
+	r.POST("/tokens", Idempotency(repo, "POST /tokens"), func(c *gin.Context) {
+		issued++
+		c.Header("Cache-Control", "private, no-store")
+		c.JSON(http.StatusCreated, gin.H{"token": issued})
+	})
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/tokens", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "abc")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+	}
+	assert.Equal(t, 2, issued)
+	assert.Empty(t, repo.responses)
+}
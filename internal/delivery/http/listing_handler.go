@@ -0,0 +1,125 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"nusatek-backend/internal/domain"
+)
+
+// ListingHandler serves published properties to anonymous visitors of an
+// agency's website. The agency is taken from the path.
+type ListingHandler struct {
+	PropertyUsecase domain.PropertyUsecase
+}
+
+func NewListingHandler(r *gin.RouterGroup, us domain.PropertyUsecase) {
+	handler := &ListingHandler{
+		PropertyUsecase: us,
+	}
+
+	listings := r.Group("/tenants/:tenant/listings", listingTenant)
+	listings.GET("", handler.Fetch)
+	listings.GET("/:id", handler.GetByID)
+}
+
+// publicListing is a property as shown to visitors. It leaves out who in the
+// agency handles the listing and how it moved through the pipeline.
+type publicListing struct {
+	ID          int64          `json:"id"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Address     domain.Address `json:"address"`
+	Price       domain.Money   `json:"price"`
+
+	PropertyType string  `json:"property_type"`
+	ListingType  string  `json:"listing_type"`
+	Bedrooms     int     `json:"bedrooms,omitempty"`
+	Bathrooms    int     `json:"bathrooms,omitempty"`
+	LandArea     float64 `json:"land_area,omitempty"`
+	BuildingArea float64 `json:"building_area,omitempty"`
+	Floors       int     `json:"floors,omitempty"`
+	Certificate  string  `json:"certificate,omitempty"`
+	Furnishing   string  `json:"furnishing,omitempty"`
+	YearBuilt    int     `json:"year_built,omitempty"`
+
+	Location  *domain.GeoPoint         `json:"location,omitempty"`
+	Media     []domain.PropertyMedia   `json:"media,omitempty"`
+	Amenities []domain.PropertyAmenity `json:"amenities,omitempty"`
+
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func toPublicListing(p domain.Property) publicListing {
+	return publicListing{
+		ID:           p.ID,
+		Title:        p.Title,
+		Description:  p.Description,
+		Address:      p.Address,
+		Price:        p.Price,
+		PropertyType: p.PropertyType,
+		ListingType:  p.ListingType,
+		Bedrooms:     p.Bedrooms,
+		Bathrooms:    p.Bathrooms,
+		LandArea:     p.LandArea,
+		BuildingArea: p.BuildingArea,
+		Floors:       p.Floors,
+		Certificate:  p.Certificate,
+		Furnishing:   p.Furnishing,
+		YearBuilt:    p.YearBuilt,
+		Location:     p.Location,
+		Media:        p.Media,
+		Amenities:    p.Amenities,
+		PublishedAt:  p.PublishedAt,
+		UpdatedAt:    p.UpdatedAt,
+	}
+}
+
+// listingTenant scopes the request to the tenant in the path
+func listingTenant(c *gin.Context) {
+	tenant, err := strconv.ParseInt(c.Param("tenant"), 10, 64)
+	if err != nil || tenant <= 0 {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Agency not found"})
+		return
+	}
+	c.Request = c.Request.WithContext(domain.ContextWithTenant(c.Request.Context(), tenant))
+	c.Next()
+}
+
+func (h *ListingHandler) Fetch(c *gin.Context) {
+	filter, ok := propertyFilter(c)
+	if !ok {
+		return
+	}
+
+	properties, err := h.PropertyUsecase.FetchPublished(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	listings := make([]publicListing, 0, len(properties))
+	for _, p := range properties {
+		listings = append(listings, toPublicListing(p))
+	}
+	c.JSON(http.StatusOK, listings)
+}
+
+func (h *ListingHandler) GetByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	property, err := h.PropertyUsecase.GetPublished(c.Request.Context(), int64(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, toPublicListing(property))
+}
@@ -0,0 +1,210 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"nusatek-backend/internal/domain"
+)
+
+// maxUploadFiles bounds the photos sent in one upload request
+const maxUploadFiles = 10
+
+type MediaHandler struct {
+	MediaUsecase domain.MediaUsecase
+}
+
+func NewMediaHandler(r *gin.RouterGroup, us domain.MediaUsecase) {
+	handler := &MediaHandler{
+		MediaUsecase: us,
+	}
+
+	r.GET("/properties/:id/media", handler.Fetch)
+	r.POST("/properties/:id/media", handler.Upload)
+	r.PUT("/properties/:id/media/order", handler.Reorder)
+	r.POST("/properties/:id/media/:media/cover", handler.SetCover)
+	r.DELETE("/properties/:id/media/:media", handler.Delete)
+}
+
+// NewMediaFileHandler serves the stored photos under r, whose path is the
+// blob store's base URL. Callers may be anonymous, so r must not require auth.
+func NewMediaFileHandler(r *gin.RouterGroup, us domain.MediaUsecase) {
+	handler := &MediaHandler{
+		MediaUsecase: us,
+	}
+
+	r.GET("/*key", handler.Serve)
+	r.HEAD("/*key", handler.Serve)
+}
+
+func (h *MediaHandler) Fetch(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	media, err := h.MediaUsecase.Fetch(c.Request.Context(), int64(id))
+	if err != nil {
+		respondMediaError(c, err)
+		return
+	}
+	if media == nil {
+		media = []domain.PropertyMedia{}
+	}
+
+	c.JSON(http.StatusOK, media)
+}
+
+// Upload stores the photos sent as multipart "file" parts, in order
+func (h *MediaHandler) Upload(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadFiles*domain.MaxMediaSize+1<<20)
+	form, err := c.MultipartForm()
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Upload is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Expected a multipart/form-data body"})
+		return
+	}
+	defer form.RemoveAll()
+
+	files := form.File["file"]
+	if len(files) == 0 || len(files) > maxUploadFiles {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Send between 1 and " + strconv.Itoa(maxUploadFiles) + " photos as \"file\" parts"})
+		return
+	}
+
+	uploaded := []domain.PropertyMedia{}
+	for _, fh := range files {
+		f, err := fh.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "uploaded": uploaded})
+			return
+		}
+		m, err := h.MediaUsecase.Upload(c.Request.Context(), int64(id), fh.Filename, f, fh.Size)
+		f.Close()
+		if err != nil {
+			if errors.Is(err, domain.ErrInvalidMedia) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fh.Filename + ": " + err.Error(), "uploaded": uploaded})
+				return
+			}
+			respondMediaError(c, err)
+			return
+		}
+		uploaded = append(uploaded, m)
+	}
+
+	c.JSON(http.StatusCreated, uploaded)
+}
+
+// Reorder sets the display order from {"ids": [...]}, which lists every photo
+func (h *MediaHandler) Reorder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var body struct {
+		IDs []int64 `json:"ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	media, err := h.MediaUsecase.Reorder(c.Request.Context(), int64(id), body.IDs)
+	if err != nil {
+		respondMediaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, media)
+}
+
+func (h *MediaHandler) SetCover(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	mediaID, err := strconv.Atoi(c.Param("media"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid media ID"})
+		return
+	}
+
+	media, err := h.MediaUsecase.SetCover(c.Request.Context(), int64(id), int64(mediaID))
+	if err != nil {
+		respondMediaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, media)
+}
+
+func (h *MediaHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	mediaID, err := strconv.Atoi(c.Param("media"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid media ID"})
+		return
+	}
+
+	if err := h.MediaUsecase.Delete(c.Request.Context(), int64(id), int64(mediaID)); err != nil {
+		respondMediaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Deleted successfully"})
+}
+
+// Serve writes a photo or one of its variants. Photos the caller may not see
+// are reported as missing, so their keys reveal nothing.
+func (h *MediaHandler) Serve(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	r, contentType, err := h.MediaUsecase.Open(c.Request.Context(), key)
+	if err != nil {
+		respondMediaError(c, err)
+		return
+	}
+	defer r.Close()
+
+	c.DataFromReader(http.StatusOK, -1, contentType, r, map[string]string{
+		// Drafts are not public, so shared caches must not keep any photo
+		"Cache-Control":          "private, max-age=3600",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+func respondMediaError(c *gin.Context, err error) {
+	if respondForbidden(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, domain.ErrPropertyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Property not found"})
+	case errors.Is(err, domain.ErrMediaNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Media not found"})
+	case errors.Is(err, domain.ErrInvalidMedia):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,183 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"nusatek-backend/internal/domain"
+)
+
+// stubMedia answers with fixed results; other methods are unused
+type stubMedia struct {
+	domain.MediaUsecase
+	err      error
+	files    map[string]string
+	uploaded *[]string
+}
+
+func (s stubMedia) Fetch(ctx context.Context, propertyID int64) ([]domain.PropertyMedia, error) {
+	return nil, s.err
+}
+
+func (s stubMedia) Upload(ctx context.Context, propertyID int64, filename string, r io.Reader, size int64) (domain.PropertyMedia, error) {
+	if strings.HasSuffix(filename, ".pdf") {
+		return domain.PropertyMedia{}, domain.ErrInvalidMedia
+	}
+	*s.uploaded = append(*s.uploaded, filename)
+	return domain.PropertyMedia{ID: int64(len(*s.uploaded)), PropertyID: propertyID, Filename: filename}, nil
+}
+
+// Open serves files to signed in callers only, as for the photos of a draft
+func (s stubMedia) Open(ctx context.Context, key string) (io.ReadCloser, string, error) {
+	data, ok := s.files[key]
+	if _, signedIn := domain.PrincipalFromContext(ctx); !ok || !signedIn {
+		return nil, "", domain.ErrMediaNotFound
+	}
+	return io.NopCloser(strings.NewReader(data)), "image/png", nil
+}
+
+// stubAuth accepts the access token "valid" and the stream token "stream"
+type stubAuth struct {
+	domain.AuthUsecase
+}
+
+func (stubAuth) Authenticate(ctx context.Context, accessToken string) (domain.Principal, error) {
+	if accessToken != "valid" {
+		return domain.Principal{}, domain.ErrInvalidToken
+	}
+	return domain.Principal{Kind: domain.PrincipalUser, UserID: 4, TenantID: 2}, nil
+}
+
+func (stubAuth) AuthenticateStream(ctx context.Context, streamToken string) (domain.Principal, error) {
+	if streamToken != "stream" {
+		return domain.Principal{}, domain.ErrInvalidToken
+	}
+	return domain.Principal{Kind: domain.PrincipalUser, UserID: 4, TenantID: 2}, nil
+}
+
+// stubKeys accepts any well-formed API key
+type stubKeys struct {
+	domain.APIKeyUsecase
+}
+
+func (stubKeys) Authenticate(ctx context.Context, key string) (domain.Principal, error) {
+	return domain.Principal{Kind: domain.PrincipalAPIKey, APIKeyID: 5, TenantID: 2}, nil
+}
+
+func TestFetchMediaErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for err, code := range map[error]int{
+		nil:                        http.StatusOK,
+		domain.ErrPropertyNotFound: http.StatusNotFound,
+		domain.ErrForbidden:        http.StatusForbidden,
+		errors.New("db down"):      http.StatusInternalServerError,
+	} {
+		r := gin.New()
+		NewMediaHandler(r.Group(""), stubMedia{err: err})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/properties/9/media", nil))
+		assert.Equal(t, code, w.Code, err)
+		if err == nil {
+			assert.JSONEq(t, `[]`, w.Body.String())
+		}
+	}
+}
+
+func TestUploadMediaParts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	upload := func(contentType string, body io.Reader) (*httptest.ResponseRecorder, []string) {
+		var uploaded []string
+		r := gin.New()
+		NewMediaHandler(r.Group(""), stubMedia{uploaded: &uploaded})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/properties/9/media", body)
+		req.Header.Set("Content-Type", contentType)
+		r.ServeHTTP(w, req)
+		return w, uploaded
+	}
+	form := func(field string, filenames ...string) (string, io.Reader) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		for _, name := range filenames {
+			part, err := mw.CreateFormFile(field, name)
+			require.NoError(t, err)
+			part.Write([]byte("photo"))
+		}
+		require.NoError(t, mw.Close())
+		return mw.FormDataContentType(), &body
+	}
+
+	w, uploaded := upload(form("file", "depan.jpg", "dapur.png"))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, []string{"depan.jpg", "dapur.png"}, uploaded)
+	var media []domain.PropertyMedia
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &media))
+	assert.Len(t, media, 2)
+
+	// A rejected photo stops the upload and reports what was stored before it
+	w, uploaded = upload(form("file", "depan.jpg", "sertifikat.pdf", "dapur.png"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, []string{"depan.jpg"}, uploaded)
+	assert.Contains(t, w.Body.String(), "sertifikat.pdf")
+	assert.Contains(t, w.Body.String(), `"filename":"depan.jpg"`)
+
+	w, uploaded = upload(form("photo", "depan.jpg"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, uploaded)
+
+	tooMany := make([]string, maxUploadFiles+1)
+	for i := range tooMany {
+		tooMany[i] = "foto.jpg"
+	}
+	w, _ = upload(form("file", tooMany...))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, _ = upload("application/json", strings.NewReader(`{"file":"depan.jpg"}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestServeMedia(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	NewMediaFileHandler(r.Group("/media", OptionalAuthMiddleware(stubAuth{}, nil)), stubMedia{
+		files: map[string]string{"tenants/2/properties/9/a.png": "photo"},
+	})
+	get := func(path, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/media/tenants/2/properties/9/a.png", "valid")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "photo", w.Body.String())
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.True(t, strings.HasPrefix(w.Header().Get("Cache-Control"), "private"))
+
+	// Anonymous callers get through to the usecase, which hides the draft
+	assert.Equal(t, http.StatusNotFound, get("/media/tenants/2/properties/9/a.png", "").Code)
+	assert.Equal(t, http.StatusNotFound, get("/media/tenants/2/properties/9/b.png", "valid").Code)
+	// Credentials that are sent must be valid
+	assert.Equal(t, http.StatusUnauthorized, get("/media/tenants/2/properties/9/a.png", "expired").Code)
+}
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"nusatek-backend/internal/domain"
+)
+
+// APIKeyHeader carries a partner API key as an alternative to "Authorization: Bearer"
+const APIKeyHeader = "X-API-Key"
+
+// AuthMiddleware rejects requests without a valid "Authorization: Bearer" access
+// token or API key and stores the authenticated domain.Principal in the request
+// context. API keys are accepted in the X-API-Key header or as the bearer token.
+func AuthMiddleware(auth domain.AuthUsecase, keys domain.APIKeyUsecase) gin.HandlerFunc {
+	return authenticate(auth, keys, false, true)
+}
+
+// StreamAuthMiddleware is AuthMiddleware that also accepts ?access_token=,
+// because browsers cannot set headers on an EventSource. Only short-lived
+// stream tokens from POST /auth/stream-token are accepted there.
+func StreamAuthMiddleware(auth domain.AuthUsecase, keys domain.APIKeyUsecase) gin.HandlerFunc {
+	return authenticate(auth, keys, true, true)
+}
+
+// OptionalAuthMiddleware is AuthMiddleware for routes that anonymous callers
+// may also use. Requests without credentials pass on with no principal.
+func OptionalAuthMiddleware(auth domain.AuthUsecase, keys domain.APIKeyUsecase) gin.HandlerFunc {
+	return authenticate(auth, keys, false, false)
+}
+
+func authenticate(auth domain.AuthUsecase, keys domain.APIKeyUsecase, allowStreamToken, required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.GetHeader(APIKeyHeader)
+		if token == "" {
+			token = bearerToken(c.GetHeader("Authorization"))
+		}
+		streamToken := ""
+		if token == "" && allowStreamToken {
+			streamToken = c.Query("access_token")
+			token = streamToken
+		}
+		if token == "" && !required {
+			c.Next()
+			return
+		}
+		if token == "" {
+			unauthorized(c, "missing bearer token or API key")
+			return
+		}
+
+		var (
+			principal domain.Principal
+			err       error
+		)
+		switch {
+		case streamToken != "":
+			principal, err = auth.AuthenticateStream(c.Request.Context(), streamToken)
+		case strings.HasPrefix(token, domain.APIKeyPrefix):
+			principal, err = keys.Authenticate(c.Request.Context(), token)
+		default:
+			principal, err = auth.Authenticate(c.Request.Context(), token)
+		}
+		if err != nil {
+			if errors.Is(err, domain.ErrInvalidToken) || errors.Is(err, domain.ErrInvalidAPIKey) {
+				unauthorized(c, err.Error())
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Request = c.Request.WithContext(domain.ContextWithPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+func bearerToken(header string) string {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+func unauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="api"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": message})
+}
+
+// clientID identifies the caller by API key, then user, then IP address
+func clientID(c *gin.Context) string {
+	if p, ok := domain.PrincipalFromContext(c.Request.Context()); ok {
+		if p.IsAPIKey() {
+			return "key:" + strconv.FormatInt(p.APIKeyID, 10)
+		}
+		return "user:" + strconv.FormatInt(p.UserID, 10)
+	}
+	return "ip:" + c.ClientIP()
+}
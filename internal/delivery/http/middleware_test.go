@@ -0,0 +1,39 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"nusatek-backend/internal/domain"
+)
+
+func TestStreamAuthQueryToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/stream", StreamAuthMiddleware(stubAuth{}, stubKeys{}), func(c *gin.Context) {
+		p, _ := domain.PrincipalFromContext(c.Request.Context())
+		c.JSON(http.StatusOK, gin.H{"user": p.UserID})
+	})
+	get := func(path string, header ...string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if len(header) == 2 {
+			req.Header.Set(header[0], header[1])
+		}
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, get("/stream?access_token=stream"))
+	// Long-lived credentials are refused in the URL, where they would be logged
+	assert.Equal(t, http.StatusUnauthorized, get("/stream?access_token=valid"))
+	assert.Equal(t, http.StatusUnauthorized, get("/stream?access_token="+domain.APIKeyPrefix+"secret"))
+	// Headers work as on every other route
+	assert.Equal(t, http.StatusOK, get("/stream", "Authorization", "Bearer valid"))
+	assert.Equal(t, http.StatusOK, get("/stream", APIKeyHeader, domain.APIKeyPrefix+"secret"))
+	assert.Equal(t, http.StatusUnauthorized, get("/stream"))
+}
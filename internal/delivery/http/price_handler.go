@@ -0,0 +1,112 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"nusatek-backend/internal/domain"
+)
+
+type PriceHistoryHandler struct {
+	PriceHistoryUsecase domain.PriceHistoryUsecase
+}
+
+func NewPriceHistoryHandler(r *gin.RouterGroup, us domain.PriceHistoryUsecase) {
+	handler := &PriceHistoryHandler{
+		PriceHistoryUsecase: us,
+	}
+
+	r.GET("/properties/:id/price-history", handler.Fetch)
+	r.GET("/analytics/price-changes", handler.Summary)
+	r.GET("/analytics/stale-listings", handler.FetchStale)
+}
+
+func (h *PriceHistoryHandler) Fetch(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	limit, offset := pageParams(c)
+
+	changes, err := h.PriceHistoryUsecase.Fetch(c.Request.Context(), int64(id), limit, offset)
+	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
+// Summary aggregates price changes over a period: /analytics/price-changes?period=720h&currency=IDR
+func (h *PriceHistoryHandler) Summary(c *gin.Context) {
+	period, ok := durationParam(c, "period", 30*24*time.Hour)
+	if !ok {
+		return
+	}
+
+	summary, err := h.PriceHistoryUsecase.Summary(c.Request.Context(), strings.ToUpper(c.Query("currency")), period)
+	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// FetchStale lists listings without a price reduction: /analytics/stale-listings?min_age=2160h
+func (h *PriceHistoryHandler) FetchStale(c *gin.Context) {
+	minAge, ok := durationParam(c, "min_age", 90*24*time.Hour)
+	if !ok {
+		return
+	}
+	limit, offset := pageParams(c)
+
+	listings, err := h.PriceHistoryUsecase.FetchStale(c.Request.Context(), minAge, limit, offset)
+	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, listings)
+}
+
+// pageParams reads limit (default 50, at most 500) and offset from the query
+func pageParams(c *gin.Context) (int, int) {
+	limit := 50
+	offset := 0
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 && l <= 500 {
+		limit = l
+	}
+	if o, err := strconv.Atoi(c.Query("offset")); err == nil && o > 0 {
+		offset = o
+	}
+	return limit, offset
+}
+
+// durationParam reads a positive Go duration such as "720h" from the query,
+// writing a 400 response and returning false when it is invalid
+func durationParam(c *gin.Context, name string, fallback time.Duration) (time.Duration, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return fallback, true
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be a positive duration such as 720h"})
+		return 0, false
+	}
+	return d, true
+}
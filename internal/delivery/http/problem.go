@@ -0,0 +1,39 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"nusatek-backend/internal/domain"
+)
+
+// Problem is an RFC 7807 problem details response
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// problem aborts the request with an application/problem+json response
+func problem(c *gin.Context, status int, detail string) {
+	c.Header("Content-Type", "application/problem+json")
+	c.AbortWithStatusJSON(status, Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: c.Request.URL.Path,
+	})
+}
+
+// respondForbidden writes a 403 problem response and returns true if err is a policy denial
+func respondForbidden(c *gin.Context, err error) bool {
+	if !errors.Is(err, domain.ErrForbidden) {
+		return false
+	}
+	problem(c, http.StatusForbidden, "You do not have permission to perform this action")
+	return true
+}
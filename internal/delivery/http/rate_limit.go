@@ -0,0 +1,54 @@
+package http
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"nusatek-backend/internal/domain"
+)
+
+// RateLimit limits each client to def requests per window on every route,
+// or to the entry of routes keyed by "METHOD /route/pattern". Clients are
+// identified by API key, then user, then IP address, so it must run after
+// the auth middleware on authenticated groups. If Redis is unavailable
+// requests are let through.
+func RateLimit(limiter domain.RateLimiter, def domain.RateLimit, routes map[string]domain.RateLimit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+		limit, ok := routes[route]
+		bucket := route
+		if !ok {
+			// Routes without their own limit share one budget per client
+			limit = def
+			bucket = "default"
+		}
+		if limit.Requests <= 0 {
+			c.Next()
+			return
+		}
+
+		res, err := limiter.Allow(c.Request.Context(), bucket+":"+clientID(c), limit)
+		if err != nil {
+			log.Printf("rate limit: %v", err)
+			c.Next()
+			return
+		}
+
+		reset := strconv.Itoa(int(math.Ceil(res.Reset.Seconds())))
+		c.Header("RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("RateLimit-Reset", reset)
+		c.Header("RateLimit-Policy", strconv.Itoa(limit.Requests)+";w="+strconv.Itoa(int(limit.Window/time.Second)))
+
+		if !res.Allowed {
+			c.Header("Retry-After", reset)
+			problem(c, http.StatusTooManyRequests, "Rate limit exceeded, retry in "+reset+" seconds")
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"nusatek-backend/internal/domain"
+)
+
+// countingLimiter allows limit.Requests per key and never resets
+type countingLimiter map[string]int
+
+func (l countingLimiter) Allow(ctx context.Context, key string, limit domain.RateLimit) (domain.RateLimitResult, error) {
+	l[key]++
+	remaining := limit.Requests - l[key]
+	if remaining < 0 {
+		remaining = 0
+	}
+	return domain.RateLimitResult{Allowed: l[key] <= limit.Requests, Limit: limit.Requests, Remaining: remaining, Reset: time.Minute}, nil
+}
+
+func TestRateLimitForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, tc := range []struct {
+		name    string
+		proxies []string
+		want    []int
+	}{
+		// A forged X-Forwarded-For does not buy a fresh budget
+		{"no trusted proxies", nil, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}},
+		{"behind a trusted proxy", []string{"192.0.2.0/24"}, []int{http.StatusOK, http.StatusOK, http.StatusOK}},
+	} {
+		limiter := countingLimiter{}
+		r := gin.New()
+		assert.NoError(t, r.SetTrustedProxies(tc.proxies))
+		r.Use(RateLimit(limiter, domain.RateLimit{Requests: 2, Window: time.Minute}, nil))
+		r.GET("/properties", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		for i, ip := range []string{"203.0.113.1", "203.0.113.2", "203.0.113.3"} {
+			req := httptest.NewRequest(http.MethodGet, "/properties", nil)
+			req.RemoteAddr = "192.0.2.10:51000"
+			req.Header.Set("X-Forwarded-For", ip)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tc.want[i], w.Code, "%s: request %d", tc.name, i)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"nusatek-backend/internal/domain"
+)
+
+// RegionHandler serves the administrative regions used in property addresses.
+// It is public so address forms can look regions up before signing in.
+type RegionHandler struct {
+	RegionUsecase domain.RegionUsecase
+}
+
+func NewRegionHandler(r *gin.RouterGroup, us domain.RegionUsecase) {
+	handler := &RegionHandler{
+		RegionUsecase: us,
+	}
+
+	r.GET("/regions", handler.Fetch)
+	r.GET("/regions/:code", handler.GetByCode)
+}
+
+// Fetch lists the regions under ?parent= (the provinces without it),
+// optionally matching ?q= in their name
+func (h *RegionHandler) Fetch(c *gin.Context) {
+	parent := c.Query("parent")
+	if parent != "" && domain.RegionLevel(parent) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent region code"})
+		return
+	}
+
+	regions, err := h.RegionUsecase.Fetch(c.Request.Context(), parent, c.Query("q"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if regions == nil {
+		regions = []domain.Region{}
+	}
+
+	c.JSON(http.StatusOK, regions)
+}
+
+func (h *RegionHandler) GetByCode(c *gin.Context) {
+	region, err := h.RegionUsecase.GetByCode(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		if errors.Is(err, domain.ErrRegionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Region not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, region)
+}
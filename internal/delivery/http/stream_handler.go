@@ -0,0 +1,83 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"nusatek-backend/internal/domain"
+)
+
+// streamHeartbeat keeps idle connections open through proxies
+const streamHeartbeat = 15 * time.Second
+
+type StreamHandler struct {
+	StreamUsecase domain.EventStreamUsecase
+}
+
+func NewStreamHandler(r *gin.RouterGroup, us domain.EventStreamUsecase) {
+	handler := &StreamHandler{
+		StreamUsecase: us,
+	}
+
+	r.GET("/stream", handler.Stream)
+}
+
+// Stream emits property and customer changes as Server-Sent Events.
+// ?types=property,customer limits the entity kinds; Last-Event-ID resumes a stream.
+func (h *StreamHandler) Stream(c *gin.Context) {
+	var kinds []string
+	if t := c.Query("types"); t != "" {
+		kinds = strings.Split(t, ",")
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	events, err := h.StreamUsecase.Subscribe(c.Request.Context(), lastEventID, kinds)
+	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidEventID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case se, ok := <-events:
+			if !ok {
+				return false
+			}
+			data, err := json.Marshal(se.Event)
+			if err != nil {
+				return false
+			}
+			_, err = io.WriteString(w, "id: "+se.ID+"\nevent: "+se.Event.Type+"\ndata: "+string(data)+"\n\n")
+			return err == nil
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"nusatek-backend/internal/domain"
+)
+
+// stubStream fails every subscription with err
+type stubStream struct {
+	err error
+}
+
+func (s stubStream) Subscribe(ctx context.Context, lastEventID string, kinds []string) (<-chan domain.StreamEvent, error) {
+	return nil, s.err
+}
+
+func TestStreamErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for err, code := range map[error]int{
+		domain.ErrInvalidEventID: http.StatusBadRequest,
+		domain.ErrForbidden:      http.StatusForbidden,
+		errors.New("redis down"): http.StatusServiceUnavailable,
+	} {
+		r := gin.New()
+		NewStreamHandler(r.Group(""), stubStream{err: err})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/stream", nil)
+		req.Header.Set("Last-Event-ID", "not-an-id")
+		r.ServeHTTP(w, req)
+		assert.Equal(t, code, w.Code, err.Error())
+	}
+}
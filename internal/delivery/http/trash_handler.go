@@ -0,0 +1,36 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"nusatek-backend/internal/domain"
+)
+
+type TrashHandler struct {
+	TrashUsecase domain.TrashUsecase
+}
+
+func NewTrashHandler(r *gin.RouterGroup, us domain.TrashUsecase) {
+	handler := &TrashHandler{
+		TrashUsecase: us,
+	}
+
+	r.GET("/trash", handler.Fetch)
+}
+
+// Fetch lists deleted properties and customers, most recently deleted first
+func (h *TrashHandler) Fetch(c *gin.Context) {
+	limit, offset := pageParams(c)
+
+	trash, err := h.TrashUsecase.Fetch(c.Request.Context(), limit, offset)
+	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, trash)
+}
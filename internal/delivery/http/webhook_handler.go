@@ -0,0 +1,197 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"nusatek-backend/internal/domain"
+)
+
+type WebhookHandler struct {
+	WebhookUsecase domain.WebhookUsecase
+}
+
+type webhookRequest struct {
+	URL        string   `json:"url" binding:"required"`
+	EventTypes []string `json:"event_types" binding:"required"`
+	Secret     string   `json:"secret"`
+	Active     *bool    `json:"active"`
+}
+
+func NewWebhookHandler(r *gin.RouterGroup, us domain.WebhookUsecase) {
+	handler := &WebhookHandler{
+		WebhookUsecase: us,
+	}
+
+	r.GET("/webhooks", handler.Fetch)
+	r.POST("/webhooks", handler.Store)
+	r.GET("/webhooks/:id", handler.GetByID)
+	r.PUT("/webhooks/:id", handler.Update)
+	r.DELETE("/webhooks/:id", handler.Delete)
+	r.GET("/webhooks/:id/deliveries", handler.FetchDeliveries)
+	r.POST("/webhooks/:id/deliveries/:deliveryId/redeliver", handler.Redeliver)
+}
+
+func (h *WebhookHandler) Fetch(c *gin.Context) {
+	webhooks, err := h.WebhookUsecase.Fetch(c.Request.Context())
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+
+	// Secrets are only shown when a webhook is created
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	c.JSON(http.StatusOK, webhooks)
+}
+
+func (h *WebhookHandler) GetByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	w, err := h.WebhookUsecase.GetByID(c.Request.Context(), int64(id))
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+
+	w.Secret = ""
+	c.JSON(http.StatusOK, w)
+}
+
+func (h *WebhookHandler) Store(c *gin.Context) {
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	w := req.toWebhook()
+	if err := h.WebhookUsecase.Store(c.Request.Context(), &w); err != nil {
+		webhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, w)
+}
+
+func (h *WebhookHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	w := req.toWebhook()
+	w.ID = int64(id)
+	if err := h.WebhookUsecase.Update(c.Request.Context(), &w); err != nil {
+		webhookError(c, err)
+		return
+	}
+
+	w.Secret = ""
+	c.JSON(http.StatusOK, w)
+}
+
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := h.WebhookUsecase.Delete(c.Request.Context(), int64(id)); err != nil {
+		webhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Deleted successfully"})
+}
+
+func (h *WebhookHandler) FetchDeliveries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	limit := 20
+	offset := 0
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil {
+		limit = l
+	}
+	if o, err := strconv.Atoi(c.Query("offset")); err == nil {
+		offset = o
+	}
+
+	deliveries, err := h.WebhookUsecase.FetchDeliveries(c.Request.Context(), int64(id), limit, offset)
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	deliveryID, err := strconv.Atoi(c.Param("deliveryId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	d, err := h.WebhookUsecase.Redeliver(c.Request.Context(), int64(id), int64(deliveryID))
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, d)
+}
+
+func (r webhookRequest) toWebhook() domain.Webhook {
+	active := true
+	if r.Active != nil {
+		active = *r.Active
+	}
+	return domain.Webhook{
+		URL:        r.URL,
+		EventTypes: r.EventTypes,
+		Secret:     r.Secret,
+		Active:     active,
+	}
+}
+
+func webhookError(c *gin.Context, err error) {
+	if respondForbidden(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, domain.ErrWebhookNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrInvalidWebhook):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrWebhookDisabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
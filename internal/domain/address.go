@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidAddress = errors.New("invalid address")
+
+// Address is a structured Indonesian address. Region names are filled in
+// from the regions dataset using the most specific code given.
+type Address struct {
+	Street       string `json:"street"`
+	RT           string `json:"rt,omitempty"`
+	RW           string `json:"rw,omitempty"`
+	VillageCode  string `json:"village_code,omitempty"`
+	Village      string `json:"village,omitempty"` // kelurahan/desa
+	DistrictCode string `json:"district_code,omitempty"`
+	District     string `json:"district,omitempty"` // kecamatan
+	RegencyCode  string `json:"regency_code,omitempty"`
+	Regency      string `json:"regency,omitempty"` // kabupaten/kota
+	ProvinceCode string `json:"province_code,omitempty"`
+	Province     string `json:"province,omitempty"`
+	PostalCode   string `json:"postal_code,omitempty"`
+}
+
+// UnmarshalJSON also accepts a plain string, which is taken as the street
+func (a *Address) UnmarshalJSON(data []byte) error {
+	var street string
+	if err := json.Unmarshal(data, &street); err == nil {
+		*a = Address{Street: street}
+		return nil
+	}
+	type address Address
+	return json.Unmarshal(data, (*address)(a))
+}
+
+// Codes returns the region codes of the address from province down to village
+func (a Address) Codes() []string {
+	var codes []string
+	for _, code := range []string{a.ProvinceCode, a.RegencyCode, a.DistrictCode, a.VillageCode} {
+		if code != "" {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// Normalize checks the format of the address and pads RT/RW to three digits.
+// Region codes are checked against the dataset separately.
+func (a *Address) Normalize() error {
+	a.Street = strings.TrimSpace(a.Street)
+	for _, f := range []struct {
+		name  string
+		value *string
+	}{{"rt", &a.RT}, {"rw", &a.RW}} {
+		v := strings.TrimSpace(*f.value)
+		if v == "" {
+			continue
+		}
+		if len(v) > 3 || strings.Trim(v, "0123456789") != "" {
+			return fmt.Errorf("%w: %s must be up to 3 digits", ErrInvalidAddress, f.name)
+		}
+		*f.value = strings.Repeat("0", 3-len(v)) + v
+	}
+	if a.PostalCode != "" && (len(a.PostalCode) != 5 || strings.Trim(a.PostalCode, "0123456789") != "") {
+		return fmt.Errorf("%w: postal_code must be 5 digits", ErrInvalidAddress)
+	}
+
+	levels := []struct {
+		code  string
+		level int
+	}{{a.ProvinceCode, RegionProvince}, {a.RegencyCode, RegionRegency}, {a.DistrictCode, RegionDistrict}, {a.VillageCode, RegionVillage}}
+	for _, l := range levels {
+		if l.code != "" && RegionLevel(l.code) != l.level {
+			return fmt.Errorf("%w: %q is not a level %d region code", ErrInvalidAddress, l.code, l.level)
+		}
+	}
+	return nil
+}
+
+// MostSpecificCode returns the lowest region code given, which determines the others
+func (a Address) MostSpecificCode() string {
+	codes := a.Codes()
+	if len(codes) == 0 {
+		return ""
+	}
+	return codes[len(codes)-1]
+}
+
+// SetRegions fills every level of the address from the chain of regions
+// ending at its most specific code
+func (a *Address) SetRegions(regions []Region) {
+	a.ProvinceCode, a.Province = "", ""
+	a.RegencyCode, a.Regency = "", ""
+	a.DistrictCode, a.District = "", ""
+	a.VillageCode, a.Village = "", ""
+	for _, r := range regions {
+		switch r.Level {
+		case RegionProvince:
+			a.ProvinceCode, a.Province = r.Code, r.Name
+		case RegionRegency:
+			a.RegencyCode, a.Regency = r.Code, r.Name
+		case RegionDistrict:
+			a.DistrictCode, a.District = r.Code, r.Name
+		case RegionVillage:
+			a.VillageCode, a.Village = r.Code, r.Name
+		}
+	}
+}
+
+// String formats the address on one line, e.g. for geocoding
+func (a Address) String() string {
+	var parts []string
+	street := a.Street
+	if a.RT != "" {
+		street = strings.TrimSpace(street + " RT " + a.RT)
+	}
+	if a.RW != "" {
+		street = strings.TrimSpace(street + " RW " + a.RW)
+	}
+	for _, p := range []string{street, a.Village, a.District, a.Regency, a.Province} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	line := strings.Join(parts, ", ")
+	if a.PostalCode != "" {
+		line = strings.TrimSpace(line + " " + a.PostalCode)
+	}
+	return line
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddressJSON(t *testing.T) {
+	var p struct {
+		Address Address `json:"address"`
+	}
+
+	// Addresses stored before they were structured are plain strings
+	assert.NoError(t, json.Unmarshal([]byte(`{"address": "Jl. Braga 10, Bandung"}`), &p))
+	assert.Equal(t, Address{Street: "Jl. Braga 10, Bandung"}, p.Address)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"address": {"street": "Jl. Braga 10", "district_code": "32.73.08"}}`), &p))
+	assert.Equal(t, Address{Street: "Jl. Braga 10", DistrictCode: "32.73.08"}, p.Address)
+}
+
+func TestAddressNormalize(t *testing.T) {
+	a := Address{Street: " Jl. Braga 10 ", RT: "1", RW: "12", PostalCode: "40111", DistrictCode: "32.73.08"}
+	assert.NoError(t, a.Normalize())
+	assert.Equal(t, "Jl. Braga 10", a.Street)
+	assert.Equal(t, "001", a.RT)
+	assert.Equal(t, "012", a.RW)
+
+	assert.ErrorIs(t, (&Address{RT: "1234"}).Normalize(), ErrInvalidAddress)
+	assert.ErrorIs(t, (&Address{PostalCode: "4011"}).Normalize(), ErrInvalidAddress)
+	assert.ErrorIs(t, (&Address{RegencyCode: "32.73.08"}).Normalize(), ErrInvalidAddress)
+}
+
+func TestRegionLevel(t *testing.T) {
+	assert.Equal(t, RegionProvince, RegionLevel("32"))
+	assert.Equal(t, RegionVillage, RegionLevel("32.73.08.1001"))
+	assert.Equal(t, 0, RegionLevel("32.73.08.101"))
+	assert.Equal(t, 0, RegionLevel("3273"))
+	assert.Equal(t, "32.73", ParentRegionCode("32.73.08"))
+	assert.Equal(t, "", ParentRegionCode("32"))
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrAmenityNotFound = errors.New("amenity not found")
+	ErrAmenityExists   = errors.New("an amenity with this code already exists")
+	ErrInvalidAmenity  = errors.New("invalid amenity")
+)
+
+// Amenity categories
+const (
+	AmenityFacility = "facility" // pool, carport, garden
+	AmenitySecurity = "security" // CCTV, one gate system
+	AmenityAccess   = "access"   // near a toll gate, station or school
+	AmenityUtility  = "utility"  // PLN electricity, PDAM water
+)
+
+var AmenityCategories = []string{AmenityFacility, AmenitySecurity, AmenityAccess, AmenityUtility}
+
+// Amenity is an entry in the tenant's catalogue of amenities. Amenities with
+// a Unit are measured, like the PLN connection in VA.
+type Amenity struct {
+	ID        int64     `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Category  string    `json:"category"`
+	Unit      string    `json:"unit,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate checks an amenity; codes are lowercase letters, digits and underscores
+func (a *Amenity) Validate() error {
+	a.Code = strings.TrimSpace(a.Code)
+	a.Name = strings.TrimSpace(a.Name)
+	if !ValidAmenityCode(a.Code) {
+		return fmt.Errorf("%w: code must be 1 to 50 lowercase letters, digits or underscores", ErrInvalidAmenity)
+	}
+	if a.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidAmenity)
+	}
+	if !oneOf(a.Category, AmenityCategories) {
+		return fmt.Errorf("%w: category must be one of %v", ErrInvalidAmenity, AmenityCategories)
+	}
+	return nil
+}
+
+func ValidAmenityCode(code string) bool {
+	if code == "" || len(code) > 50 {
+		return false
+	}
+	for _, r := range code {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// PropertyAmenity is an amenity of a property, with its Value for amenities
+// measured in a unit. Only the code and value are taken on writes.
+type PropertyAmenity struct {
+	AmenityID int64   `json:"-"`
+	Code      string  `json:"code"`
+	Name      string  `json:"name,omitempty"`
+	Category  string  `json:"category,omitempty"`
+	Unit      string  `json:"unit,omitempty"`
+	Value     float64 `json:"value,omitempty"`
+}
+
+// UnmarshalJSON also accepts a plain string, which is taken as the code
+func (a *PropertyAmenity) UnmarshalJSON(data []byte) error {
+	var code string
+	if err := json.Unmarshal(data, &code); err == nil {
+		*a = PropertyAmenity{Code: code}
+		return nil
+	}
+	type amenity PropertyAmenity
+	return json.Unmarshal(data, (*amenity)(a))
+}
+
+type AmenityRepository interface {
+	// Fetch returns the catalogue by category and name
+	Fetch(ctx context.Context) ([]Amenity, error)
+	GetByID(ctx context.Context, id int64) (Amenity, error)
+	// FetchByCodes returns the amenities with the given codes; unknown codes are left out
+	FetchByCodes(ctx context.Context, codes []string) ([]Amenity, error)
+	// Store and Update return ErrAmenityExists if the code is taken
+	Store(ctx context.Context, a *Amenity) error
+	Update(ctx context.Context, a *Amenity) error
+	// Delete also removes the amenity from every property
+	Delete(ctx context.Context, id int64) error
+
+	// FetchPropertyIDs returns the properties that have the amenity
+	FetchPropertyIDs(ctx context.Context, amenityID int64) ([]int64, error)
+	// FetchByProperties returns the amenities of several properties by code
+	FetchByProperties(ctx context.Context, propertyIDs []int64) (map[int64][]PropertyAmenity, error)
+	// SetForProperty replaces the amenities of a property
+	SetForProperty(ctx context.Context, propertyID int64, amenities []PropertyAmenity) error
+}
+
+type AmenityUsecase interface {
+	Fetch(ctx context.Context) ([]Amenity, error)
+	Store(ctx context.Context, a *Amenity) error
+	Update(ctx context.Context, a *Amenity) error
+	Delete(ctx context.Context, id int64) error
+}
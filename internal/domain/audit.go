@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Audited actions
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditStatus  = "status"
+	AuditMedia   = "media"
+	AuditMerge   = "merge"
+)
+
+// AuditEntry records one mutation: who made it, in which request, and the
+// entity before and after. Entries are append-only.
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	TenantID  int64           `json:"tenant_id"`
+	ActorID   int64           `json:"actor_id,omitempty"`
+	APIKeyID  int64           `json:"api_key_id,omitempty"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityID  int64           `json:"entity_id"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditFilter selects audit entries; zero fields match everything
+type AuditFilter struct {
+	Entity   string
+	EntityID int64
+	Limit    int
+	Offset   int
+}
+
+type AuditRepository interface {
+	Store(ctx context.Context, e *AuditEntry) error
+	// Fetch returns matching entries of the tenant in ctx, newest first
+	Fetch(ctx context.Context, f AuditFilter) ([]AuditEntry, error)
+}
+
+type AuditUsecase interface {
+	Fetch(ctx context.Context, f AuditFilter) ([]AuditEntry, error)
+}
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying the id of the request being served
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request id stored in ctx, or ""
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrForbidden = errors.New("forbidden")
+
+// Roles
+const (
+	RoleAgent   = "agent"
+	RoleManager = "manager"
+	RoleAdmin   = "admin"
+	// RolePlatformAdmin operates the platform: an admin of its own agency who
+	// may also manage what all agencies share. Agencies cannot grant it.
+	RolePlatformAdmin = "platform_admin"
+)
+
+// Permissions checked by the usecases
+const (
+	PermPropertiesRead    = "properties:read"
+	PermPropertiesCreate  = "properties:create"
+	PermPropertiesUpdate  = "properties:update"
+	PermPropertiesDelete  = "properties:delete"
+	PermCustomersRead     = "customers:read"
+	PermCustomersCreate   = "customers:create"
+	PermCustomersUpdate   = "customers:update"
+	PermCustomersDelete   = "customers:delete"
+	PermUsersManage       = "users:manage"
+	PermWebhooksManage    = "webhooks:manage"
+	PermDeadLettersManage = "platform:dead_letters" // every tenant's failed events
+	PermAPIKeysManage     = "api_keys:manage"
+	PermAuditRead         = "audit:read"
+	PermAmenitiesManage   = "amenities:manage"
+)
+
+// Grant scopes, from narrowest to widest
+const (
+	ScopeOwn    = "own"
+	ScopeBranch = "branch"
+	ScopeAny    = "any"
+)
+
+// Grant gives a role a permission within a scope
+type Grant struct {
+	Permission string `json:"permission"`
+	Scope      string `json:"scope"`
+}
+
+// Resource describes who a record belongs to, for scoped grants.
+// The zero Resource stands for a collection or a new record.
+type Resource struct {
+	OwnerID  int64
+	BranchID int64
+}
+
+type PermissionRepository interface {
+	FetchGrants(ctx context.Context, role string) ([]Grant, error)
+}
+
+// Authorizer is the policy layer invoked by the usecases
+type Authorizer interface {
+	// Authorize returns ErrForbidden unless the principal in ctx holds permission for resource
+	Authorize(ctx context.Context, permission string, resource Resource) error
+}
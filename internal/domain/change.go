@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Change kinds and operations reported by the change feed
+const (
+	ChangeKindProperty = "property"
+	ChangeKindCustomer = "customer"
+
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+var ErrInvalidChangeToken = errors.New("invalid change token")
+
+// Change is a single created, updated or deleted record in the change feed
+type Change struct {
+	Kind      string    `json:"kind"`
+	Op        string    `json:"op"`
+	ID        int64     `json:"id"`
+	ChangedAt time.Time `json:"changed_at"`
+	// Seq orders changes by the transaction that made them
+	Seq      uint64    `json:"-"`
+	Property *Property `json:"property,omitempty"`
+	Customer *Customer `json:"customer,omitempty"`
+}
+
+// ChangeCursor is the position of a change in feed order (Seq, Kind, ID)
+type ChangeCursor struct {
+	Seq  uint64
+	Kind string
+	ID   int64
+}
+
+// ChangeFeed is one page of the change feed
+type ChangeFeed struct {
+	Changes   []Change `json:"changes"`
+	NextToken string   `json:"next_token"`
+	HasMore   bool     `json:"has_more"`
+}
+
+type ChangeRepository interface {
+	// FetchSince returns changes strictly after cursor, oldest first. Changes
+	// of transactions that may still commit are held back, so none can later
+	// appear behind a cursor that was already returned.
+	FetchSince(ctx context.Context, cursor ChangeCursor, kinds []string, limit int) ([]Change, error)
+}
+
+type ChangeUsecase interface {
+	// FetchSince returns changes after the position encoded in token.
+	// An empty token starts from the beginning.
+	FetchSince(ctx context.Context, token string, kinds []string, limit int) (ChangeFeed, error)
+}
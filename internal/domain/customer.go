@@ -2,25 +2,42 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+var (
+	ErrCustomerNotFound = errors.New("customer not found")
+	// ErrCustomerEmailTaken is returned when another customer of the agency has the email
+	ErrCustomerEmailTaken = errors.New("another customer has this email")
+)
+
 type Customer struct {
-	ID        int64     `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Phone     string    `json:"phone"`
-	Status    string    `json:"status"` // Active, Inactive
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Email     string     `json:"email"`
+	Phone     string     `json:"phone"`
+	Status    string     `json:"status"` // Active, Inactive
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
+// CustomerRepository hides deleted customers from every read but the *Trashed ones
 type CustomerRepository interface {
 	Fetch(ctx context.Context, limit int, offset int) ([]Customer, error)
+	// GetByID, Update and Delete return ErrCustomerNotFound if there is no such customer.
+	// Store, Update and Restore return ErrCustomerEmailTaken for an email in use.
 	GetByID(ctx context.Context, id int64) (Customer, error)
+	// Store and Update set the timestamps of c from the stored row
 	Store(ctx context.Context, c *Customer) error
 	Update(ctx context.Context, c *Customer) error
 	Delete(ctx context.Context, id int64) error
+	FetchTrashed(ctx context.Context, limit int, offset int) ([]Customer, error)
+	GetTrashed(ctx context.Context, id int64) (Customer, error)
+	Restore(ctx context.Context, id int64) error
+	// Purge permanently removes customers of every tenant deleted before cutoff
+	Purge(ctx context.Context, before time.Time) (int64, error)
 }
 
 type CustomerUsecase interface {
@@ -29,4 +46,5 @@ type CustomerUsecase interface {
 	Store(ctx context.Context, c *Customer) error
 	Update(ctx context.Context, c *Customer) error
 	Delete(ctx context.Context, id int64) error
+	Restore(ctx context.Context, id int64) error
 }
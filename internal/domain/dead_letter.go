@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// DeadLetter is a message that a consumer failed to process
+type DeadLetter struct {
+	MessageID  string          `json:"message_id"`
+	Exchange   string          `json:"exchange"`
+	RoutingKey string          `json:"routing_key"`
+	Queue      string          `json:"queue"`
+	Reason     string          `json:"reason"`
+	Attempts   int64           `json:"attempts"`
+	FailedAt   time.Time       `json:"failed_at"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// DeadLetterUsecase defines operator actions on the dead-letter queue
+type DeadLetterUsecase interface {
+	Fetch(ctx context.Context, limit int) ([]DeadLetter, error)
+	Replay(ctx context.Context, ids []string) (int, error)
+	ReplayAll(ctx context.Context) (int, error)
+}
@@ -0,0 +1,178 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"unicode"
+)
+
+var ErrInvalidMerge = errors.New("invalid merge")
+
+// DuplicateThreshold is the lowest score reported as a possible duplicate
+const DuplicateThreshold = 0.6
+
+// Why two properties look like the same one
+const (
+	MatchAddress    = "address"
+	MatchLocation   = "location"
+	MatchAttributes = "attributes"
+	MatchPhotos     = "photos"
+	MatchPrice      = "price"
+)
+
+// DuplicateMatch is a property that may be the same as another one
+type DuplicateMatch struct {
+	Property Property `json:"property"`
+	// Score runs from 0 (unrelated) to 1 (certainly the same)
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// CompareProperties scores how likely a and b are the same property listed
+// twice. Photos and the exact spot weigh most, as agents type addresses in
+// many ways; conflicting room counts or areas, as between units of one
+// apartment block, count against a match.
+func CompareProperties(a Property, b Property) (float64, []string) {
+	var score float64
+	var reasons []string
+	match := func(reason string, weight float64) {
+		score += weight
+		reasons = append(reasons, reason)
+	}
+
+	if sameAddress(a.Address, b.Address) {
+		match(MatchAddress, 0.3)
+	}
+	if a.Location != nil && b.Location != nil {
+		switch d := DistanceKm(*a.Location, *b.Location); {
+		case d <= 0.05:
+			match(MatchLocation, 0.25)
+		case d <= 0.15:
+			match(MatchLocation, 0.15)
+		}
+	}
+	if similarPhotos(a.Media, b.Media) {
+		match(MatchPhotos, 0.35)
+	}
+	switch sameAttributes(a, b) {
+	case 1:
+		match(MatchAttributes, 0.2)
+	case -1:
+		score -= 0.2
+	}
+	if a.Price.Currency == b.Price.Currency && a.Price.Amount > 0 && b.Price.Amount > 0 &&
+		within(float64(a.Price.Amount), float64(b.Price.Amount), 0.1) {
+		match(MatchPrice, 0.1)
+	}
+	return math.Max(0, math.Min(1, score)), reasons
+}
+
+// sameAddress compares the normalised streets of two addresses in the same
+// village, or district when either has no village
+func sameAddress(a Address, b Address) bool {
+	street := NormalizeStreet(a.Street)
+	if street == "" || street != NormalizeStreet(b.Street) {
+		return false
+	}
+	if a.RT != "" && b.RT != "" && (a.RT != b.RT || a.RW != b.RW) {
+		return false
+	}
+	if a.VillageCode != "" && b.VillageCode != "" {
+		return a.VillageCode == b.VillageCode
+	}
+	return a.DistrictCode == b.DistrictCode
+}
+
+// streetWords spells out the abbreviations common in Indonesian addresses
+var streetWords = map[string]string{
+	"jl":       "jalan",
+	"jln":      "jalan",
+	"gg":       "gang",
+	"no":       "nomor",
+	"blk":      "blok",
+	"kav":      "kavling",
+	"perum":    "perumahan",
+	"komp":     "kompleks",
+	"komplek":  "kompleks",
+	"kompleks": "kompleks",
+	"ds":       "desa",
+	"kel":      "kelurahan",
+	"kec":      "kecamatan",
+}
+
+// NormalizeStreet lowercases a street address, drops punctuation and spells
+// out abbreviations, so that "Jl. Melati No.5" and "jalan melati nomor 5" are equal
+func NormalizeStreet(s string) string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, f := range fields {
+		if w, ok := streetWords[f]; ok {
+			fields[i] = w
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// SimilarPhotoDistance is the largest number of bits two perceptual hashes
+// may differ in for their photos to count as copies of each other
+const SimilarPhotoDistance = 6
+
+// similarPhotos reports whether any photo of one property is a copy of a
+// photo of the other
+func similarPhotos(a []PropertyMedia, b []PropertyMedia) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x.Hash != 0 && y.Hash != 0 && x.Hash.Distance(y.Hash) <= SimilarPhotoDistance {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sameAttributes compares the type, room counts and areas known for both
+// properties: 1 when at least two agree and none conflict, -1 on a conflict
+// and 0 when there is too little to tell
+func sameAttributes(a Property, b Property) int {
+	if a.PropertyType != b.PropertyType {
+		return -1
+	}
+	agree := 0
+	for _, f := range [][2]float64{
+		{float64(a.Bedrooms), float64(b.Bedrooms)},
+		{float64(a.Bathrooms), float64(b.Bathrooms)},
+		{a.LandArea, b.LandArea},
+		{a.BuildingArea, b.BuildingArea},
+	} {
+		if f[0] == 0 || f[1] == 0 {
+			continue
+		}
+		// Areas are measured a little differently from one agent to the next
+		if !within(f[0], f[1], 0.05) {
+			return -1
+		}
+		agree++
+	}
+	if agree >= 2 {
+		return 1
+	}
+	return 0
+}
+
+// within reports whether x and y differ by at most the fraction tolerance of the larger
+func within(x float64, y float64, tolerance float64) bool {
+	return math.Abs(x-y) <= tolerance*math.Max(x, y)
+}
+
+type DuplicateUsecase interface {
+	// FetchDuplicates returns the properties that may be the same as the
+	// property id, most likely first
+	FetchDuplicates(ctx context.Context, id int64) ([]DuplicateMatch, error)
+	// Merge folds duplicateID into id: its photos, price history and
+	// revisions move over, details missing from id are copied from it and
+	// it is deleted
+	Merge(ctx context.Context, id int64, duplicateID int64) (Property, error)
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeStreet(t *testing.T) {
+	assert.Equal(t, "jalan melati nomor 5", NormalizeStreet("Jl. Melati No.5"))
+	assert.Equal(t, NormalizeStreet("Perum. Griya Asri, Blk C-2"), NormalizeStreet("perumahan griya asri blok c 2"))
+	assert.Equal(t, "", NormalizeStreet(" ,. "))
+}
+
+func TestCompareProperties(t *testing.T) {
+	house := Property{
+		Address:      Address{Street: "Jl. Melati No. 5", VillageCode: "3171071001"},
+		Price:        NewMoney(150000000000, "IDR"),
+		PropertyType: "rumah", ListingType: "sale",
+		Bedrooms: 3, Bathrooms: 2, LandArea: 120, BuildingArea: 90,
+		Location: &GeoPoint{Lat: -6.2000, Lng: 106.8000},
+		Media:    []PropertyMedia{{Hash: 0xa5a5a5a5a5a5a5a5}},
+	}
+
+	// Listed again by another agent: address typed differently, areas
+	// measured a little differently and a recompressed photo
+	again := house
+	again.Address = Address{Street: "jalan melati nomor 5", VillageCode: "3171071001"}
+	again.LandArea, again.Price = 118, NewMoney(145000000000, "IDR")
+	again.Location = &GeoPoint{Lat: -6.2002, Lng: 106.8001}
+	again.Media = []PropertyMedia{{Hash: 0xa5a5a5a5a5a5a5a7}}
+	score, reasons := CompareProperties(house, again)
+	assert.Equal(t, 1.0, score)
+	assert.Equal(t, []string{MatchAddress, MatchLocation, MatchPhotos, MatchAttributes, MatchPrice}, reasons)
+
+	// The neighbour on the same street: close by but a different house
+	neighbour := house
+	neighbour.Address.Street = "Jl. Melati No. 7"
+	neighbour.Bedrooms = 4
+	neighbour.Location = &GeoPoint{Lat: -6.2003, Lng: 106.8002}
+	neighbour.Media = []PropertyMedia{{Hash: 0x5a5a5a5a5a5a5a5a}}
+	score, _ = CompareProperties(house, neighbour)
+	assert.Less(t, score, DuplicateThreshold)
+
+	// Unprocessed photos prove nothing
+	unprocessed := neighbour
+	unprocessed.Bedrooms = 3
+	unprocessed.Media = []PropertyMedia{{}}
+	house.Media = []PropertyMedia{{}}
+	score, reasons = CompareProperties(house, unprocessed)
+	assert.NotContains(t, reasons, MatchPhotos)
+	assert.InDelta(t, 0.55, score, 1e-9)
+}
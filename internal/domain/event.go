@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidEventID is returned when a client resumes a stream from an id
+// that the stream never sent
+var ErrInvalidEventID = errors.New("invalid last event id")
+
+// Event types published to the property_events and customer_events queues
+const (
+	EventPropertyCreated       = "property_created"
+	EventPropertyUpdated       = "property_updated"
+	EventPropertyDeleted       = "property_deleted"
+	EventPropertyRestored      = "property_restored"
+	EventPropertyStatusChanged = "property_status_changed"
+	EventPropertyMediaUploaded = "property_media_uploaded"
+	EventPropertyMerged        = "property_merged"
+	EventCustomerCreated       = "customer_created"
+	EventCustomerUpdated       = "customer_updated"
+	EventCustomerDeleted       = "customer_deleted"
+	EventCustomerRestored      = "customer_restored"
+)
+
+// Event is the envelope of every message published to RabbitMQ
+type Event struct {
+	EventID    string          `json:"event_id"`
+	Type       string          `json:"event"`
+	EntityID   int64           `json:"id"`
+	TenantID   int64           `json:"tenant_id,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data,omitempty"`
+}
+
+// Tenant returns the agency e belongs to; events published before
+// multi-tenancy belong to the default tenant.
+func (e Event) Tenant() int64 {
+	if e.TenantID == 0 {
+		return DefaultTenantID
+	}
+	return e.TenantID
+}
+
+// InboxRepository records which events a consumer has already processed
+type InboxRepository interface {
+	// Claim reserves eventID for consumer. It returns false when the event was
+	// already processed or is being processed by another worker.
+	Claim(ctx context.Context, consumer string, eventID string) (bool, error)
+	MarkProcessed(ctx context.Context, consumer string, eventID string) error
+	Release(ctx context.Context, consumer string, eventID string) error
+}
+
+// StreamEvent is an Event together with its position in the change stream
+type StreamEvent struct {
+	ID    string `json:"id"`
+	Event Event  `json:"event"`
+}
+
+// Kind returns the entity kind of the event type, e.g. "property" for property_created
+func (e Event) Kind() string {
+	if i := strings.IndexByte(e.Type, '_'); i > 0 {
+		return e.Type[:i]
+	}
+	return e.Type
+}
+
+// EventStreamRepository keeps a bounded, ordered history of events and fans
+// new ones out to every API instance.
+type EventStreamRepository interface {
+	Append(ctx context.Context, evt Event) (StreamEvent, error)
+	// Since returns events recorded after lastID, oldest first
+	Since(ctx context.Context, lastID string, limit int) ([]StreamEvent, error)
+	// Subscribe delivers newly appended events until ctx is cancelled
+	Subscribe(ctx context.Context) (<-chan StreamEvent, error)
+}
+
+// EventStreamUsecase streams live changes to clients
+type EventStreamUsecase interface {
+	// Subscribe replays events after lastEventID and then follows live events.
+	// Only events whose Kind is in kinds are delivered; empty kinds means all.
+	// It fails with ErrInvalidEventID unless lastEventID is empty or a stream id.
+	Subscribe(ctx context.Context, lastEventID string, kinds []string) (<-chan StreamEvent, error)
+}
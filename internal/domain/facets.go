@@ -0,0 +1,69 @@
+package domain
+
+// FacetCount counts the properties with one value of a facet
+type FacetCount struct {
+	Value string `json:"value"`
+	Name  string `json:"name,omitempty"`
+	Count int64  `json:"count"`
+}
+
+// PriceBucket counts the properties of a listing type priced from Min up to
+// but excluding Max; the first bucket has no Min and the last no Max
+type PriceBucket struct {
+	ListingType string `json:"listing_type"`
+	Min         *Money `json:"min,omitempty"`
+	Max         *Money `json:"max,omitempty"`
+	Count       int64  `json:"count"`
+}
+
+// PropertyFacets summarise the properties matching a filter. Every facet
+// counts as if its own filter were left out, so that the counts show what
+// choosing another value would return; amenities are counted within the
+// amenities already chosen, as a property must have all of them.
+type PropertyFacets struct {
+	Total         int64         `json:"total"`
+	PropertyTypes []FacetCount  `json:"property_types"`
+	Amenities     []FacetCount  `json:"amenities"`
+	Prices        []PriceBucket `json:"prices"`
+}
+
+// PriceBucketBounds returns the boundaries between the price buckets for a
+// listing type, in the default currency. Rents are yearly, as usual in Indonesia.
+func PriceBucketBounds(listingType string) []Money {
+	const juta, miliar = 1_000_000, 1_000_000_000
+	rupiah := []int64{500 * juta, miliar, 2 * miliar, 5 * miliar, 10 * miliar}
+	if listingType == ListingRent {
+		rupiah = []int64{25 * juta, 50 * juta, 100 * juta, 250 * juta}
+	}
+	bounds := make([]Money, len(rupiah))
+	for i, r := range rupiah {
+		bounds[i] = NewMoney(r*100, DefaultCurrency)
+	}
+	return bounds
+}
+
+// PriceBuckets returns the empty price buckets for a listing type, or for
+// every listing type when it is empty, as sale prices and yearly rents can't
+// share buckets
+func PriceBuckets(listingType string) []PriceBucket {
+	types := ListingTypes
+	if listingType != "" {
+		types = []string{listingType}
+	}
+
+	var buckets []PriceBucket
+	for _, t := range types {
+		bounds := PriceBucketBounds(t)
+		for i := 0; i <= len(bounds); i++ {
+			b := PriceBucket{ListingType: t}
+			if i > 0 {
+				b.Min = &bounds[i-1]
+			}
+			if i < len(bounds) {
+				b.Max = &bounds[i]
+			}
+			buckets = append(buckets, b)
+		}
+	}
+	return buckets
+}
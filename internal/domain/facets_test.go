@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriceBuckets(t *testing.T) {
+	sale := PriceBuckets(ListingSale)
+	if assert.Len(t, sale, 6) {
+		assert.Nil(t, sale[0].Min)
+		assert.Equal(t, NewMoney(500_000_000_00, "IDR"), *sale[0].Max)
+		assert.Equal(t, NewMoney(500_000_000_00, "IDR"), *sale[1].Min)
+		assert.Equal(t, NewMoney(10_000_000_000_00, "IDR"), *sale[5].Min)
+		assert.Nil(t, sale[5].Max)
+	}
+	for _, b := range sale {
+		assert.Equal(t, ListingSale, b.ListingType)
+	}
+
+	rent := PriceBuckets(ListingRent)
+	if assert.Len(t, rent, 5) {
+		assert.Equal(t, NewMoney(25_000_000_00, "IDR"), *rent[0].Max)
+	}
+
+	// Without a listing type sale prices and rents are bucketed separately
+	all := PriceBuckets("")
+	assert.Equal(t, append(sale, rent...), all)
+}
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var ErrInvalidGeoQuery = errors.New("invalid geo query")
+
+const (
+	// MaxSearchRadiusKm bounds radius searches so they stay index-friendly
+	MaxSearchRadiusKm = 200
+	// MaxBoxDegrees bounds the height and width of a bounding box, about as
+	// much as a radius search covers
+	MaxBoxDegrees = 4
+	// MaxGeoResults bounds the properties returned by one geo search
+	MaxGeoResults = 100
+)
+
+// GeoPoint is a WGS 84 coordinate in decimal degrees
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+func (g GeoPoint) Valid() bool {
+	return g.Lat >= -90 && g.Lat <= 90 && g.Lng >= -180 && g.Lng <= 180
+}
+
+// BoundingBox is a map viewport. West may be greater than East when the box
+// crosses the antimeridian.
+type BoundingBox struct {
+	South float64 `json:"south"`
+	West  float64 `json:"west"`
+	North float64 `json:"north"`
+	East  float64 `json:"east"`
+}
+
+// Width is the span of the box in degrees of longitude
+func (b BoundingBox) Width() float64 {
+	if b.West > b.East {
+		return b.East + 360 - b.West
+	}
+	return b.East - b.West
+}
+
+// Center is the midpoint of the box, used to rank properties inside it
+func (b BoundingBox) Center() GeoPoint {
+	east := b.East
+	if b.West > east {
+		east += 360
+	}
+	lng := (b.West + east) / 2
+	if lng > 180 {
+		lng -= 360
+	}
+	return GeoPoint{Lat: (b.South + b.North) / 2, Lng: lng}
+}
+
+// GeoQuery selects properties either within RadiusKm of Center or inside Box
+type GeoQuery struct {
+	Center   GeoPoint
+	RadiusKm float64
+	Box      *BoundingBox
+}
+
+func (q GeoQuery) Validate() error {
+	if q.Box != nil {
+		b := *q.Box
+		if !(GeoPoint{b.South, b.West}).Valid() || !(GeoPoint{b.North, b.East}).Valid() || b.South > b.North {
+			return fmt.Errorf("%w: bounding box must be south,west,north,east in degrees", ErrInvalidGeoQuery)
+		}
+		if b.North-b.South > MaxBoxDegrees || b.Width() > MaxBoxDegrees {
+			return fmt.Errorf("%w: bounding box can span at most %d degrees each way", ErrInvalidGeoQuery, MaxBoxDegrees)
+		}
+		return nil
+	}
+	if !q.Center.Valid() {
+		return fmt.Errorf("%w: lat must be within ±90 and lng within ±180", ErrInvalidGeoQuery)
+	}
+	if q.RadiusKm <= 0 || q.RadiusKm > MaxSearchRadiusKm {
+		return fmt.Errorf("%w: radius_km must be above 0 and at most %d", ErrInvalidGeoQuery, MaxSearchRadiusKm)
+	}
+	return nil
+}
+
+// NearbyProperty is a geo search result. DistanceKm is measured from the
+// search point, or from the centre of the bounding box.
+type NearbyProperty struct {
+	Property
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// DistanceKm is the great-circle distance between a and b on a spherical Earth
+func DistanceKm(a GeoPoint, b GeoPoint) float64 {
+	const earthRadiusKm = 6371.0
+	rad := math.Pi / 180
+	dLat := (b.Lat - a.Lat) * rad
+	dLng := (b.Lng - a.Lng) * rad
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(a.Lat*rad)*math.Cos(b.Lat*rad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
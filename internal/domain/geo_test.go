@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistanceKm(t *testing.T) {
+	monas := GeoPoint{Lat: -6.1754, Lng: 106.8272}
+	gedungSate := GeoPoint{Lat: -6.9025, Lng: 107.6188}
+	assert.InDelta(t, 119, DistanceKm(monas, gedungSate), 2)
+	assert.Equal(t, 0.0, DistanceKm(monas, monas))
+}
+
+func TestGeoQueryValidate(t *testing.T) {
+	assert.NoError(t, GeoQuery{Center: GeoPoint{Lat: -8.65, Lng: 115.21}, RadiusKm: 5}.Validate())
+	assert.ErrorIs(t, GeoQuery{Center: GeoPoint{Lat: -91, Lng: 115.21}, RadiusKm: 5}.Validate(), ErrInvalidGeoQuery)
+	assert.ErrorIs(t, GeoQuery{Center: GeoPoint{Lat: -8.65, Lng: 115.21}}.Validate(), ErrInvalidGeoQuery)
+	assert.ErrorIs(t, GeoQuery{Center: GeoPoint{Lat: -8.65, Lng: 115.21}, RadiusKm: 500}.Validate(), ErrInvalidGeoQuery)
+
+	box := &BoundingBox{South: -6.4, West: 106.6, North: -6.1, East: 107.0}
+	assert.NoError(t, GeoQuery{Box: box}.Validate())
+	assert.ErrorIs(t, GeoQuery{Box: &BoundingBox{South: -6.1, West: 106.6, North: -6.4, East: 107.0}}.Validate(), ErrInvalidGeoQuery)
+	// The whole of Java is too large a box
+	assert.ErrorIs(t, GeoQuery{Box: &BoundingBox{South: -8.8, West: 105.1, North: -5.9, East: 114.6}}.Validate(), ErrInvalidGeoQuery)
+	assert.NoError(t, GeoQuery{Box: &BoundingBox{South: -1, West: 179, North: 1, East: -179}}.Validate())
+	assert.ErrorIs(t, GeoQuery{Box: &BoundingBox{South: -1, West: 170, North: 1, East: -170}}.Validate(), ErrInvalidGeoQuery)
+}
+
+func TestBoundingBoxCenter(t *testing.T) {
+	assert.Equal(t, GeoPoint{Lat: -6.25, Lng: 106.8}, BoundingBox{South: -6.4, West: 106.6, North: -6.1, East: 107.0}.Center())
+	// Across the antimeridian
+	assert.Equal(t, GeoPoint{Lat: 0, Lng: -175}, BoundingBox{South: -1, West: 170, North: 1, East: -160}.Center())
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNoGeocodeMatch = errors.New("address could not be geocoded")
+
+// Geocode records how a property's location was derived from its address.
+// Properties whose location was entered by hand have none.
+type Geocode struct {
+	// Confidence ranges from 0 (country) to 1 (exact building)
+	Confidence float64   `json:"confidence"`
+	Provider   string    `json:"provider"`
+	Address    string    `json:"address"` // the address as geocoded
+	GeocodedAt time.Time `json:"geocoded_at"`
+}
+
+type GeocodeResult struct {
+	Location   GeoPoint
+	Confidence float64
+	Provider   string
+}
+
+// Geocoder turns an address into coordinates. It returns ErrNoGeocodeMatch
+// when it has no answer; other errors are worth retrying.
+type Geocoder interface {
+	Geocode(ctx context.Context, a Address) (GeocodeResult, error)
+}
+
+// GeocodeUsecase keeps geocoded locations in step with property addresses.
+// It runs in the worker on behalf of the system, without authorization.
+type GeocodeUsecase interface {
+	// GeocodeProperty locates the property in the tenant of ctx if its address
+	// changed since it was last geocoded and its location was not set by hand
+	GeocodeProperty(ctx context.Context, id int64) error
+}
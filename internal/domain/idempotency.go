@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrIdempotencyKeyReused  = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// IdempotentResponse is the first response to a request, replayed for retries
+type IdempotentResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// IdempotencyRepository remembers responses by Idempotency-Key. fingerprint
+// identifies the request payload so a key cannot be reused for another request.
+type IdempotencyRepository interface {
+	// Begin reserves key for a new request and returns nil. If key already
+	// completed with the same fingerprint it returns the stored response.
+	Begin(ctx context.Context, key string, fingerprint string) (*IdempotentResponse, error)
+	Complete(ctx context.Context, key string, fingerprint string, resp IdempotentResponse) error
+	// Release forgets a reservation so the request can be retried
+	Release(ctx context.Context, key string) error
+}
@@ -0,0 +1,216 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMediaNotFound = errors.New("media not found")
+	ErrInvalidMedia  = errors.New("invalid media")
+)
+
+const (
+	// MaxMediaSize is the largest photo accepted for upload
+	MaxMediaSize = 10 << 20
+	// MaxMediaPerProperty limits the photos of a single listing
+	MaxMediaPerProperty = 40
+)
+
+// MediaContentTypes are the accepted photo formats, by sniffed content type
+var MediaContentTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// Processing states of uploaded media. Photos are served as uploaded until
+// the worker has stripped their metadata and resized them.
+const (
+	MediaPending   = "pending"
+	MediaProcessed = "processed"
+	MediaFailed    = "failed"
+)
+
+// PropertyMedia is a photo of a property. Photos are shown by Position and
+// exactly one photo of a property with any is its cover.
+type PropertyMedia struct {
+	ID          int64                   `json:"id"`
+	PropertyID  int64                   `json:"property_id"`
+	Key         string                  `json:"-"` // in the blob store
+	URL         string                  `json:"url"`
+	Filename    string                  `json:"filename,omitempty"`
+	ContentType string                  `json:"content_type"`
+	Size        int64                   `json:"size"`
+	Position    int                     `json:"position"`
+	IsCover     bool                    `json:"is_cover"`
+	Status      string                  `json:"status"`
+	Width       int                     `json:"width,omitempty"`
+	Height      int                     `json:"height,omitempty"`
+	Hash        PerceptualHash          `json:"phash,omitempty"`
+	Variants    map[string]MediaVariant `json:"variants,omitempty"`
+	CreatedAt   time.Time               `json:"created_at"`
+}
+
+// MediaVariant is a resized JPEG copy of a photo
+type MediaVariant struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// MediaKey is where a photo of a property is kept in the blob store
+func MediaKey(tenantID, propertyID int64, name string) string {
+	return fmt.Sprintf("tenants/%d/properties/%d/%s", tenantID, propertyID, name)
+}
+
+// ParseMediaKey returns the tenant and property a blob key made by MediaKey
+// or VariantKey was made for. A merge moves photos to another property of the
+// tenant without changing their keys.
+func ParseMediaKey(key string) (tenantID, propertyID int64, ok bool) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 5 || parts[0] != "tenants" || parts[2] != "properties" || parts[4] == "" {
+		return 0, 0, false
+	}
+	tenantID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || tenantID <= 0 {
+		return 0, 0, false
+	}
+	propertyID, err = strconv.ParseInt(parts[3], 10, 64)
+	if err != nil || propertyID <= 0 {
+		return 0, 0, false
+	}
+	return tenantID, propertyID, true
+}
+
+// VariantKey is where the named variant of m is kept in the blob store
+func (m PropertyMedia) VariantKey(name string) string {
+	base := m.Key
+	if i := strings.LastIndexByte(base, '.'); i > strings.LastIndexByte(base, '/') {
+		base = base[:i]
+	}
+	return base + "_" + name + ".jpg"
+}
+
+// PerceptualHash fingerprints what a photo looks like, so that rescaled or
+// recompressed copies of it hash to nearly the same bits. Zero means unknown.
+type PerceptualHash uint64
+
+// Distance is the number of differing bits; copies of one photo are usually within 10
+func (h PerceptualHash) Distance(o PerceptualHash) int {
+	return bits.OnesCount64(uint64(h ^ o))
+}
+
+func (h PerceptualHash) String() string {
+	return fmt.Sprintf("%016x", uint64(h))
+}
+
+// MarshalJSON writes the hash as 16 hex digits, as JSON numbers lose precision above 2^53
+func (h PerceptualHash) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.String())
+}
+
+func (h *PerceptualHash) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return fmt.Errorf("perceptual hash: %w", err)
+	}
+	*h = PerceptualHash(v)
+	return nil
+}
+
+// BlobStore keeps uploaded files under a key and serves them at a public URL
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+type MediaRepository interface {
+	// Fetch returns the media of a property in display order
+	Fetch(ctx context.Context, propertyID int64) ([]PropertyMedia, error)
+	// FetchByProperties returns the media of several properties in display order
+	FetchByProperties(ctx context.Context, propertyIDs []int64) (map[int64][]PropertyMedia, error)
+	// Get returns ErrMediaNotFound if the property has no such media
+	Get(ctx context.Context, propertyID int64, id int64) (PropertyMedia, error)
+	// Store appends m after the property's other media, as the cover if it is the first
+	Store(ctx context.Context, m *PropertyMedia) error
+	// Delete removes m, passing the cover on to the next photo if m was it
+	Delete(ctx context.Context, propertyID int64, id int64) error
+	// Reorder sets the display order; ids must list every media of the property
+	Reorder(ctx context.Context, propertyID int64, ids []int64) error
+	SetCover(ctx context.Context, propertyID int64, id int64) error
+	// UpdateProcessed saves the outcome of processing m: its status, size,
+	// dimensions, hash and variants
+	UpdateProcessed(ctx context.Context, m PropertyMedia) error
+	// FetchByKey returns the media that key may be the blob of: the photo
+	// stored under it and, as a variant's key is its photo's without the
+	// extension followed by _name.jpg, photos with that stem
+	FetchByKey(ctx context.Context, key string) ([]PropertyMedia, error)
+	// FetchPurgeable returns the media of properties of every tenant deleted
+	// before cutoff, which PropertyRepository.Purge removes along with them
+	FetchPurgeable(ctx context.Context, before time.Time) ([]PropertyMedia, error)
+}
+
+type MediaUsecase interface {
+	Fetch(ctx context.Context, propertyID int64) ([]PropertyMedia, error)
+	// Upload validates and stores a photo read from r, which holds size bytes
+	Upload(ctx context.Context, propertyID int64, filename string, r io.Reader, size int64) (PropertyMedia, error)
+	Delete(ctx context.Context, propertyID int64, id int64) error
+	Reorder(ctx context.Context, propertyID int64, ids []int64) ([]PropertyMedia, error)
+	SetCover(ctx context.Context, propertyID int64, id int64) ([]PropertyMedia, error)
+	// Open returns a stored photo or variant and its content type. Photos of
+	// published properties are public; those of other properties are only
+	// shown to callers of the tenant who may read properties. Keys of unknown
+	// photos, and photos the caller may not see, fail with ErrMediaNotFound.
+	Open(ctx context.Context, key string) (io.ReadCloser, string, error)
+}
+
+// ImageProcessor prepares uploaded photos for display
+type ImageProcessor interface {
+	// StripMetadata returns data without the metadata that may reveal where
+	// and with what it was taken. It fails with ErrInvalidMedia when data is
+	// not a well-formed file of the given content type.
+	StripMetadata(data []byte, contentType string) ([]byte, error)
+	// Process fails with ErrInvalidMedia when data cannot be read as an image
+	// of the given content type
+	Process(data []byte, contentType string) (ProcessedImage, error)
+}
+
+// ProcessedImage is an uploaded photo ready to be shown
+type ProcessedImage struct {
+	// Original is the uploaded file without the metadata that may reveal
+	// where and with what it was taken
+	Original []byte
+	// Width and Height are zero and Variants empty for formats that can
+	// only be cleaned, not decoded
+	Width    int
+	Height   int
+	Hash     PerceptualHash
+	Variants []ImageVariant
+}
+
+// ImageVariant is an encoded JPEG copy of a photo resized to fit a box
+type ImageVariant struct {
+	Name   string
+	Data   []byte
+	Width  int
+	Height int
+}
+
+// MediaProcessingUsecase runs in the worker after every upload
+type MediaProcessingUsecase interface {
+	Process(ctx context.Context, propertyID int64, id int64) error
+}
@@ -0,0 +1,213 @@
+package domain
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used when an amount is given without a currency
+const DefaultCurrency = "IDR"
+
+// Currencies that can be stored. All of them have two minor digits (ISO 4217),
+// matching the NUMERIC(15, 2) price columns.
+var supportedCurrencies = map[string]bool{"IDR": true, "USD": true, "SGD": true, "EUR": true, "AUD": true, "MYR": true}
+
+var (
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	ErrCurrencyMismatch    = errors.New("currency mismatch")
+	ErrInvalidAmount       = errors.New("invalid amount: expected a decimal with at most 2 decimal places")
+)
+
+// Money is an exact amount in minor units (hundredths) of an ISO 4217
+// currency. Do arithmetic on Money rather than converting to float64.
+//
+// It encodes to JSON as {"amount": "1500000000.00", "currency": "IDR"} with
+// the amount as a string so clients do not round it through a double. A plain
+// JSON number or decimal string is also accepted and taken to be in IDR.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// NewMoney returns minor units of currency, e.g. NewMoney(150000, "IDR") is Rp1,500.00
+func NewMoney(minor int64, currency string) Money {
+	return Money{Amount: minor, Currency: currency}
+}
+
+// ParseMoney parses a decimal such as "1500000000.50" in currency
+func ParseMoney(amount string, currency string) (Money, error) {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	currency = strings.ToUpper(currency)
+	if !supportedCurrencies[currency] {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnsupportedCurrency, currency)
+	}
+	minor, err := parseMinor(amount, false)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{Amount: m.Amount - o.Amount, Currency: m.Currency}, nil
+}
+
+// Cmp returns -1, 0 or +1 as m is less than, equal to or greater than o
+func (m Money) Cmp(o Money) (int, error) {
+	if m.Currency != o.Currency {
+		return 0, ErrCurrencyMismatch
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Decimal formats the amount without currency, e.g. "1500000000.00"
+func (m Money) Decimal() string {
+	sign := ""
+	abs := uint64(m.Amount)
+	if m.Amount < 0 {
+		sign = "-"
+		abs = uint64(-m.Amount)
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, abs/100, abs%100)
+}
+
+func (m Money) String() string {
+	return m.Currency + " " + m.Decimal()
+}
+
+type moneyJSON struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	currency := m.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{m.Decimal(), currency})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*m = Money{}
+		return nil
+	}
+
+	currency := ""
+	if len(data) > 0 && data[0] == '{' {
+		var obj moneyJSON
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return err
+		}
+		data, currency = bytes.TrimSpace(obj.Amount), obj.Currency
+	}
+
+	amount := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &amount); err != nil {
+			return err
+		}
+	}
+	parsed, err := ParseMoney(amount, currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan reads a NUMERIC amount. The currency lives in its own column and is
+// scanned separately into Currency.
+func (m *Money) Scan(src interface{}) error {
+	var text string
+	switch v := src.(type) {
+	case nil:
+		m.Amount = 0
+		return nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+
+	// Aggregates such as AVG return more than two decimals and are rounded
+	minor, err := parseMinor(text, true)
+	if err != nil {
+		return err
+	}
+	m.Amount = minor
+	return nil
+}
+
+// Value stores the amount as a decimal string for a NUMERIC column
+func (m Money) Value() (driver.Value, error) {
+	return m.Decimal(), nil
+}
+
+// parseMinor converts a decimal to hundredths without going through float64.
+// Extra decimal places are an error unless round is set, in which case they
+// are rounded half away from zero.
+func parseMinor(s string, round bool) (int64, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return 0, ErrInvalidAmount
+	}
+	r.Mul(r, big.NewRat(100, 1))
+	if !r.IsInt() {
+		if !round {
+			return 0, ErrInvalidAmount
+		}
+		// Add or subtract one half before truncating towards zero
+		half := big.NewRat(1, 2)
+		if r.Sign() < 0 {
+			half.Neg(half)
+		}
+		r.Add(r, half)
+		r.SetInt(new(big.Int).Quo(r.Num(), r.Denom()))
+	}
+	n := r.Num()
+	if !n.IsInt64() || n.Int64() == math.MinInt64 {
+		return 0, ErrInvalidAmount
+	}
+	return n.Int64(), nil
+}
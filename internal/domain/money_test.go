@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoneyJSON(t *testing.T) {
+	var p struct {
+		Price Money `json:"price"`
+	}
+
+	// Beyond float64's 15-16 significant digits
+	assert.NoError(t, json.Unmarshal([]byte(`{"price": 12345678901234.57}`), &p))
+	assert.Equal(t, Money{Amount: 1234567890123457, Currency: "IDR"}, p.Price)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"price": {"amount": "-2500.5", "currency": "usd"}}`), &p))
+	assert.Equal(t, Money{Amount: -250050, Currency: "USD"}, p.Price)
+
+	out, err := json.Marshal(p)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"price": {"amount": "-2500.50", "currency": "USD"}}`, string(out))
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"price": "10.001"}`), &p), ErrInvalidAmount)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"price": {"amount": "10", "currency": "XYZ"}}`), &p), ErrUnsupportedCurrency)
+}
+
+func TestMoneyScan(t *testing.T) {
+	var m Money
+	assert.NoError(t, m.Scan([]byte("1500000000.00")))
+	assert.Equal(t, int64(150000000000), m.Amount)
+
+	// AVG() results are rounded half away from zero
+	assert.NoError(t, m.Scan([]byte("-12.345")))
+	assert.Equal(t, int64(-1235), m.Amount)
+
+	v, err := NewMoney(5, "IDR").Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "0.05", v)
+}
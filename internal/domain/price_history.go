@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// PriceChange records one change of a property's asking price. OldPrice is
+// nil for the price the property was listed at.
+type PriceChange struct {
+	ID         int64     `json:"id"`
+	PropertyID int64     `json:"property_id"`
+	OldPrice   *Money    `json:"old_price"`
+	NewPrice   Money     `json:"new_price"`
+	ActorID    int64     `json:"actor_id,omitempty"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+// PriceChangeSummary aggregates the price changes of a tenant in one
+// currency over a period
+type PriceChangeSummary struct {
+	Since            time.Time `json:"since"`
+	Changes          int64     `json:"changes"`
+	Reductions       int64     `json:"reductions"`
+	Increases        int64     `json:"increases"`
+	AverageChange    Money     `json:"average_change"`
+	AverageChangePct float64   `json:"average_change_pct"`
+}
+
+// StaleListing is a property whose price has not been reduced for a while.
+// The age counts from the listing date when the price was never reduced.
+type StaleListing struct {
+	PropertyID         int64      `json:"property_id"`
+	Title              string     `json:"title"`
+	Price              Money      `json:"price"`
+	ListedAt           time.Time  `json:"listed_at"`
+	LastReductionAt    *time.Time `json:"last_reduction_at"`
+	DaysSinceReduction int        `json:"days_since_reduction"`
+}
+
+type PriceHistoryRepository interface {
+	Store(ctx context.Context, c *PriceChange) error
+	// Fetch returns the price changes of a property, newest first
+	Fetch(ctx context.Context, propertyID int64, limit int, offset int) ([]PriceChange, error)
+	Summary(ctx context.Context, currency string, since time.Time) (PriceChangeSummary, error)
+	// FetchStale returns listings not reduced since before, longest unchanged first
+	FetchStale(ctx context.Context, before time.Time, limit int, offset int) ([]StaleListing, error)
+}
+
+type PriceHistoryUsecase interface {
+	Fetch(ctx context.Context, propertyID int64, limit int, offset int) ([]PriceChange, error)
+	Summary(ctx context.Context, currency string, period time.Duration) (PriceChangeSummary, error)
+	FetchStale(ctx context.Context, minAge time.Duration, limit int, offset int) ([]StaleListing, error)
+}
@@ -2,27 +2,125 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+var ErrPropertyNotFound = errors.New("property not found")
+
 // Property represents a real estate property
 type Property struct {
-	ID          int64     `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Address     string    `json:"address"`
-	Price       float64   `json:"price"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int64   `json:"id"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Address     Address `json:"address"`
+	Price       Money   `json:"price"`
+
+	PropertyType string  `json:"property_type"` // rumah, apartemen, ruko, tanah
+	ListingType  string  `json:"listing_type"`  // sale, rent
+	Bedrooms     int     `json:"bedrooms,omitempty"`
+	Bathrooms    int     `json:"bathrooms,omitempty"`
+	LandArea     float64 `json:"land_area,omitempty"`     // LT, square metres
+	BuildingArea float64 `json:"building_area,omitempty"` // LB, square metres
+	Floors       int     `json:"floors,omitempty"`
+	Certificate  string  `json:"certificate,omitempty"` // SHM, HGB, HP, Strata, Girik
+	Furnishing   string  `json:"furnishing,omitempty"`
+	YearBuilt    int     `json:"year_built,omitempty"`
+
+	Location *GeoPoint `json:"location,omitempty"`
+	Geocode  *Geocode  `json:"geocode,omitempty"`
+
+	// Media is managed through MediaUsecase and only filled on reads
+	Media []PropertyMedia `json:"media,omitempty"`
+	// Amenities are left as they are by updates that omit them
+	Amenities []PropertyAmenity `json:"amenities,omitempty"`
+
+	AgentID   int64      `json:"agent_id"`
+	BranchID  int64      `json:"branch_id,omitempty"`
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// MergedInto is the property a deleted duplicate was merged into
+	MergedInto int64 `json:"merged_into,omitempty"`
+
+	// When the property last entered each status
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	ReservedAt  *time.Time `json:"reserved_at,omitempty"`
+	SoldAt      *time.Time `json:"sold_at,omitempty"`
+	RentedAt    *time.Time `json:"rented_at,omitempty"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+}
+
+// PropertyFilter selects properties; zero fields match everything. Ranges
+// are inclusive.
+type PropertyFilter struct {
+	Statuses      []string
+	PropertyTypes []string
+	ListingType   string
+	Certificates  []string
+	Furnishings   []string
+	// Region codes; a property matches a region and every region inside it
+	ProvinceCode string
+	RegencyCode  string
+	DistrictCode string
+	VillageCode  string
+	// Amenity codes; a property matches if it has all of them
+	Amenities []string
+
+	MinBedrooms     int
+	MaxBedrooms     int
+	MinBathrooms    int
+	MinFloors       int
+	MaxFloors       int
+	MinLandArea     float64
+	MaxLandArea     float64
+	MinBuildingArea float64
+	MaxBuildingArea float64
+	MinYearBuilt    int
+	MaxYearBuilt    int
+	// Price bounds only match properties priced in the same currency
+	MinPrice *Money
+	MaxPrice *Money
+
+	Limit  int
+	Offset int
 }
 
-// PropertyRepository defines the interface for database operations
+// PropertyRepository defines the interface for database operations.
+// Deleted properties stay in the trash, hidden from every read but the *Trashed ones.
 type PropertyRepository interface {
-	Fetch(ctx context.Context, limit int, offset int) ([]Property, error)
+	Fetch(ctx context.Context, f PropertyFilter) ([]Property, error)
+	// FetchNearby returns the properties matching f and q, nearest first
+	FetchNearby(ctx context.Context, f PropertyFilter, q GeoQuery) ([]NearbyProperty, error)
+	// GetByID returns ErrPropertyNotFound if there is no such property
 	GetByID(ctx context.Context, id int64) (Property, error)
+	// Store and Update write p and replace it with the stored row, keeping its
+	// media and amenities. Update and Delete return ErrPropertyNotFound if
+	// there is no such property.
 	Store(ctx context.Context, p *Property) error
 	Update(ctx context.Context, p *Property) error
 	Delete(ctx context.Context, id int64) error
+	FetchTrashed(ctx context.Context, limit int, offset int) ([]Property, error)
+	GetTrashed(ctx context.Context, id int64) (Property, error)
+	Restore(ctx context.Context, id int64) error
+	// UpdateStatus moves a property from one status to another and stamps the
+	// time. It returns ErrInvalidTransition if the status is no longer from.
+	UpdateStatus(ctx context.Context, id int64, from string, to string) error
+	// UpdateGeocode stores a geocoded location. It returns ErrPropertyNotFound
+	// if the property was changed or deleted since updatedAt.
+	UpdateGeocode(ctx context.Context, id int64, updatedAt time.Time, loc GeoPoint, g Geocode) error
+	// FetchDuplicateCandidates returns up to limit other properties with the
+	// listing type of p that are close to it, in its village (or district
+	// without one) or share an identical photo with it
+	FetchDuplicateCandidates(ctx context.Context, p Property, limit int) ([]Property, error)
+	// Merge moves the photos, price history and revisions of duplicateID to id
+	// and deletes duplicateID. It returns ErrPropertyNotFound if either is gone.
+	Merge(ctx context.Context, id int64, duplicateID int64) error
+	// Facets counts the properties matching f, ignoring its limit and offset
+	Facets(ctx context.Context, f PropertyFilter) (PropertyFacets, error)
+	// Purge permanently removes properties of every tenant deleted before cutoff
+	Purge(ctx context.Context, before time.Time) (int64, error)
 }
 
 // PropertyCacheRepository defines the interface for caching operations
@@ -34,9 +132,21 @@ type PropertyCacheRepository interface {
 
 // PropertyUsecase defines the interface for business logic
 type PropertyUsecase interface {
-	Fetch(ctx context.Context, limit int, offset int) ([]Property, error)
+	Fetch(ctx context.Context, f PropertyFilter) ([]Property, error)
+	FetchNearby(ctx context.Context, f PropertyFilter, q GeoQuery) ([]NearbyProperty, error)
+	Facets(ctx context.Context, f PropertyFilter) (PropertyFacets, error)
 	GetByID(ctx context.Context, id int64) (Property, error)
 	Store(ctx context.Context, p *Property) error
 	Update(ctx context.Context, p *Property) error
 	Delete(ctx context.Context, id int64) error
+	Restore(ctx context.Context, id int64) error
+	FetchRevisions(ctx context.Context, id int64, limit int, offset int) ([]PropertyRevision, error)
+	DiffRevisions(ctx context.Context, id int64, from int, to int) (RevisionDiff, error)
+	// Rollback writes revision back as the current state, creating a new revision
+	Rollback(ctx context.Context, id int64, revision int) (Property, error)
+	Transition(ctx context.Context, id int64, status string) (Property, error)
+	// FetchPublished and GetPublished serve the public listing pages of the
+	// tenant in ctx without authorization
+	FetchPublished(ctx context.Context, f PropertyFilter) ([]Property, error)
+	GetPublished(ctx context.Context, id int64) (Property, error)
 }
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Property types
+const (
+	PropertyTypeHouse     = "rumah"
+	PropertyTypeApartment = "apartemen"
+	PropertyTypeShophouse = "ruko"
+	PropertyTypeLand      = "tanah"
+)
+
+// Listing types
+const (
+	ListingSale = "sale"
+	ListingRent = "rent"
+)
+
+// Land certificates
+const (
+	CertificateSHM    = "SHM"    // Sertifikat Hak Milik, freehold
+	CertificateHGB    = "HGB"    // Hak Guna Bangunan, right to build
+	CertificateHP     = "HP"     // Hak Pakai, right of use
+	CertificateStrata = "Strata" // SHM Sarusun, apartment units
+	CertificateGirik  = "Girik"  // Unregistered customary land
+)
+
+// Furnishing
+const (
+	Unfurnished   = "unfurnished"
+	SemiFurnished = "semi_furnished"
+	Furnished     = "furnished"
+)
+
+var ErrInvalidProperty = errors.New("invalid property")
+
+var (
+	PropertyTypes = []string{PropertyTypeHouse, PropertyTypeApartment, PropertyTypeShophouse, PropertyTypeLand}
+	ListingTypes  = []string{ListingSale, ListingRent}
+	Certificates  = []string{CertificateSHM, CertificateHGB, CertificateHP, CertificateStrata, CertificateGirik}
+	Furnishings   = []string{Unfurnished, SemiFurnished, Furnished}
+)
+
+// Validate checks the attributes of a property. Zero values mean unknown and
+// are accepted, except for the property and listing type.
+func (p *Property) Validate() error {
+	if p.Title == "" {
+		return fmt.Errorf("%w: title is required", ErrInvalidProperty)
+	}
+	if !oneOf(p.PropertyType, PropertyTypes) {
+		return fmt.Errorf("%w: property_type must be one of %v", ErrInvalidProperty, PropertyTypes)
+	}
+	if !oneOf(p.ListingType, ListingTypes) {
+		return fmt.Errorf("%w: listing_type must be one of %v", ErrInvalidProperty, ListingTypes)
+	}
+	if p.Certificate != "" && !oneOf(p.Certificate, Certificates) {
+		return fmt.Errorf("%w: certificate must be one of %v", ErrInvalidProperty, Certificates)
+	}
+	if p.Furnishing != "" && !oneOf(p.Furnishing, Furnishings) {
+		return fmt.Errorf("%w: furnishing must be one of %v", ErrInvalidProperty, Furnishings)
+	}
+	if p.Price.Amount < 0 {
+		return fmt.Errorf("%w: price cannot be negative", ErrInvalidProperty)
+	}
+	if p.Bedrooms < 0 || p.Bathrooms < 0 || p.Floors < 0 || p.LandArea < 0 || p.BuildingArea < 0 {
+		return fmt.Errorf("%w: rooms, floors and areas cannot be negative", ErrInvalidProperty)
+	}
+	if p.YearBuilt != 0 && (p.YearBuilt < 1800 || p.YearBuilt > time.Now().Year()+5) {
+		return fmt.Errorf("%w: year_built %d is out of range", ErrInvalidProperty, p.YearBuilt)
+	}
+	if p.Location != nil && !p.Location.Valid() {
+		return fmt.Errorf("%w: location must have lat within ±90 and lng within ±180", ErrInvalidProperty)
+	}
+	if p.PropertyType == PropertyTypeLand && (p.BuildingArea > 0 || p.Bedrooms > 0) {
+		return fmt.Errorf("%w: land has no building area or bedrooms", ErrInvalidProperty)
+	}
+	return nil
+}
+
+func oneOf(value string, allowed []string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
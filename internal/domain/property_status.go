@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Listing lifecycle: draft → published → reserved → sold/rented → archived
+const (
+	PropertyDraft     = "draft"
+	PropertyPublished = "published"
+	PropertyReserved  = "reserved"
+	PropertySold      = "sold"
+	PropertyRented    = "rented"
+	PropertyArchived  = "archived"
+)
+
+var (
+	ErrInvalidStatus     = errors.New("unknown property status")
+	ErrInvalidTransition = errors.New("property status cannot change this way")
+)
+
+// propertyTransitions lists the statuses each status may move to
+var propertyTransitions = map[string][]string{
+	PropertyDraft:     {PropertyPublished, PropertyArchived},
+	PropertyPublished: {PropertyDraft, PropertyReserved, PropertySold, PropertyRented, PropertyArchived},
+	PropertyReserved:  {PropertyPublished, PropertySold, PropertyRented, PropertyArchived},
+	PropertySold:      {PropertyArchived},
+	// A rental can be listed again when the lease ends
+	PropertyRented:   {PropertyPublished, PropertyArchived},
+	PropertyArchived: {PropertyDraft},
+}
+
+// ValidPropertyStatus reports whether status is part of the lifecycle
+func ValidPropertyStatus(status string) bool {
+	_, ok := propertyTransitions[status]
+	return ok
+}
+
+// CanTransition reports whether a property may move from one status to another
+func CanTransition(from string, to string) bool {
+	for _, next := range propertyTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// PropertyStatusChange is the payload of EventPropertyStatusChanged
+type PropertyStatusChange struct {
+	PropertyID int64     `json:"property_id"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
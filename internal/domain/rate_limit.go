@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimit allows Requests per Window; zero Requests means unlimited
+type RateLimit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// RateLimitResult is the outcome of counting one request against a limit
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until another request will be allowed
+	Reset time.Duration
+}
+
+type RateLimiter interface {
+	// Allow counts a request for key against limit and reports whether it may proceed
+	Allow(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+}
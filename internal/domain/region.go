@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var ErrRegionNotFound = errors.New("region not found")
+
+// Administrative levels, identified by the number of segments in a
+// Kemendagri region code: 31 → 31.71 → 31.71.01 → 31.71.01.1001
+const (
+	RegionProvince = 1 // provinsi
+	RegionRegency  = 2 // kabupaten/kota
+	RegionDistrict = 3 // kecamatan
+	RegionVillage  = 4 // kelurahan/desa
+)
+
+// Region is an entry of the administrative regions reference dataset
+type Region struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	Level      int    `json:"level"`
+	ParentCode string `json:"parent_code,omitempty"`
+}
+
+// RegionLevel returns the level of a region code, or 0 if it is malformed
+func RegionLevel(code string) int {
+	if code == "" {
+		return 0
+	}
+	segments := strings.Split(code, ".")
+	if len(segments) > RegionVillage {
+		return 0
+	}
+	for i, s := range segments {
+		want := 2
+		if i == RegionVillage-1 {
+			want = 4
+		}
+		if len(s) != want || strings.Trim(s, "0123456789") != "" {
+			return 0
+		}
+	}
+	return len(segments)
+}
+
+// ParentRegionCode returns the code of the region containing code, or "" for a province
+func ParentRegionCode(code string) string {
+	if i := strings.LastIndex(code, "."); i >= 0 {
+		return code[:i]
+	}
+	return ""
+}
+
+type RegionRepository interface {
+	// Fetch returns the regions directly under parent, or the provinces when
+	// parent is empty, optionally matching part of their name
+	Fetch(ctx context.Context, parent string, name string) ([]Region, error)
+	// GetByCodes returns the regions that exist among codes
+	GetByCodes(ctx context.Context, codes []string) ([]Region, error)
+}
+
+type RegionUsecase interface {
+	Fetch(ctx context.Context, parent string, name string) ([]Region, error)
+	GetByCode(ctx context.Context, code string) (Region, error)
+}
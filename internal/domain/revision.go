@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// PropertyRevision is a snapshot of a property after one of its writes.
+// Revisions are numbered from 1 per property.
+type PropertyRevision struct {
+	ID         int64    `json:"id"`
+	PropertyID int64    `json:"property_id"`
+	Revision   int      `json:"revision"`
+	Property   Property `json:"property"`
+	AuthorID   int64    `json:"author_id,omitempty"`
+	// RolledBackFrom is the revision this one restored, if any
+	RolledBackFrom int       `json:"rolled_back_from,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// FieldChange is one field that differs between two revisions
+type FieldChange struct {
+	Field string          `json:"field"`
+	From  json.RawMessage `json:"from"`
+	To    json.RawMessage `json:"to"`
+}
+
+type RevisionDiff struct {
+	PropertyID int64         `json:"property_id"`
+	From       int           `json:"from"`
+	To         int           `json:"to"`
+	Changes    []FieldChange `json:"changes"`
+}
+
+type PropertyRevisionRepository interface {
+	// Fetch returns the revisions of a property, newest first
+	Fetch(ctx context.Context, propertyID int64, limit int, offset int) ([]PropertyRevision, error)
+	Get(ctx context.Context, propertyID int64, revision int) (PropertyRevision, error)
+	// Store saves r as the next revision of r.PropertyID and sets its number
+	Store(ctx context.Context, r *PropertyRevision) error
+}
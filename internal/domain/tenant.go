@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+// DefaultTenantID is the agency that owns rows created before multi-tenancy
+const DefaultTenantID int64 = 1
+
+var ErrTenantRequired = errors.New("tenant is required")
+
+type tenantKey struct{}
+
+// ContextWithTenant returns a copy of ctx acting for tenantID. Background jobs
+// use it where there is no authenticated principal.
+func ContextWithTenant(ctx context.Context, tenantID int64) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant ctx acts for: the one set with
+// ContextWithTenant, otherwise the authenticated principal's.
+func TenantFromContext(ctx context.Context) (int64, bool) {
+	if id, ok := ctx.Value(tenantKey{}).(int64); ok && id != 0 {
+		return id, true
+	}
+	if p, ok := PrincipalFromContext(ctx); ok && p.TenantID != 0 {
+		return p.TenantID, true
+	}
+	return 0, false
+}
@@ -0,0 +1,11 @@
+package domain
+
+import "context"
+
+// Transactor runs fn in one database transaction. Repositories called with
+// the ctx given to fn take part in it, and a WithinTx nested in another
+// joins the outer transaction. The transaction commits if fn returns nil and
+// rolls back otherwise.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNotInTrash = errors.New("not found in trash")
+
+// Trash holds soft-deleted records that can still be restored
+type Trash struct {
+	Properties []Property `json:"properties"`
+	Customers  []Customer `json:"customers"`
+}
+
+type TrashUsecase interface {
+	// Fetch lists the trash of every kind the caller may read
+	Fetch(ctx context.Context, limit int, offset int) (Trash, error)
+	// Purge permanently removes records of every tenant deleted before cutoff
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
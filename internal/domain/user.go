@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrInvalidUser        = errors.New("invalid user")
+)
+
+// User is a person who can sign in to the API
+type User struct {
+	ID           int64     `json:"id"`
+	Email        string    `json:"email"`
+	Name         string    `json:"name"`
+	Role         string    `json:"role"`
+	TenantID     int64     `json:"tenant_id"`
+	BranchID     int64     `json:"branch_id,omitempty"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TokenPair is returned on login and refresh
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// StreamToken opens an event stream from a browser, which cannot send an
+// Authorization header on an EventSource and so puts it in the URL
+type StreamToken struct {
+	Token     string `json:"token"`
+	ExpiresIn int64  `json:"expires_in"`
+}
+
+// RefreshSession is what a refresh token resolves to. Every token issued by
+// rotating the same login shares a Family, so reuse of an old token can
+// revoke the whole chain.
+type RefreshSession struct {
+	UserID int64  `json:"user_id"`
+	Family string `json:"family"`
+}
+
+// Kinds of principal
+const (
+	PrincipalUser   = "user"
+	PrincipalAPIKey = "api_key"
+)
+
+// Principal is the authenticated caller of a request. An API key acts for an
+// integration rather than a person: it has Kind PrincipalAPIKey, no UserID
+// and is limited to the key's Scopes instead of a role.
+type Principal struct {
+	Kind      string
+	UserID    int64
+	Email     string
+	Role      string
+	TenantID  int64
+	BranchID  int64
+	TokenID   string
+	ExpiresAt time.Time
+	APIKeyID  int64
+	Scopes    []string
+}
+
+// IsAPIKey reports whether p authenticated with an API key
+func (p Principal) IsAPIKey() bool {
+	return p.Kind == PrincipalAPIKey
+}
+
+type principalKey struct{}
+
+// ContextWithPrincipal returns a copy of ctx carrying p
+func ContextWithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the authenticated caller stored in ctx
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+type UserRepository interface {
+	GetByID(ctx context.Context, id int64) (User, error)
+	GetByEmail(ctx context.Context, email string) (User, error)
+	Store(ctx context.Context, u *User) error
+	Count(ctx context.Context) (int64, error)
+}
+
+// TokenRepository stores refresh sessions and revoked access tokens
+type TokenRepository interface {
+	StoreRefresh(ctx context.Context, tokenHash string, s RefreshSession, ttl time.Duration) error
+	// ConsumeRefresh atomically removes and returns the session for tokenHash.
+	// The hash is remembered as used so a replayed token can be detected.
+	ConsumeRefresh(ctx context.Context, tokenHash string, usedTTL time.Duration) (RefreshSession, error)
+	// UsedRefresh returns the session of a refresh token that was already rotated
+	UsedRefresh(ctx context.Context, tokenHash string) (RefreshSession, error)
+	RevokeFamily(ctx context.Context, family string) error
+	FamilyRevoked(ctx context.Context, family string) (bool, error)
+	RevokeAccess(ctx context.Context, tokenID string, ttl time.Duration) error
+	AccessRevoked(ctx context.Context, tokenID string) (bool, error)
+}
+
+type AuthUsecase interface {
+	Login(ctx context.Context, email string, password string) (TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (TokenPair, error)
+	// Logout revokes the caller's access token and, if given, its refresh token
+	Logout(ctx context.Context, p Principal, refreshToken string) error
+	Authenticate(ctx context.Context, accessToken string) (Principal, error)
+	// StreamToken issues p a token that only opens event streams, for less
+	// than a minute. Tokens in URLs end up in logs and browser history, so
+	// neither access tokens nor API keys are accepted there.
+	StreamToken(ctx context.Context, p Principal) (StreamToken, error)
+	AuthenticateStream(ctx context.Context, streamToken string) (Principal, error)
+}
+
+type UserUsecase interface {
+	GetByID(ctx context.Context, id int64) (User, error)
+	Store(ctx context.Context, u *User, password string) error
+	// EnsureAdmin creates the first user, a platform admin, when the users
+	// table is empty
+	EnsureAdmin(ctx context.Context, email string, password string) error
+}
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Webhook delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+var (
+	ErrWebhookNotFound = errors.New("webhook not found")
+	ErrInvalidWebhook  = errors.New("invalid webhook")
+	ErrWebhookDisabled = errors.New("webhook is disabled")
+)
+
+// Webhook is a partner subscription to events delivered over HTTP
+type Webhook struct {
+	ID         int64     `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Secret     string    `json:"secret,omitempty"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery is one event sent (or to be sent) to a webhook
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	WebhookID      int64           `json:"webhook_id"`
+	TenantID       int64           `json:"-"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// Subscribes reports whether w should receive events of eventType
+func (w Webhook) Subscribes(eventType string) bool {
+	for _, t := range w.EventTypes {
+		if t == eventType || t == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookRepository interface {
+	Fetch(ctx context.Context) ([]Webhook, error)
+	GetByID(ctx context.Context, id int64) (Webhook, error)
+	Store(ctx context.Context, w *Webhook) error
+	Update(ctx context.Context, w *Webhook) error
+	Delete(ctx context.Context, id int64) error
+	FetchActive(ctx context.Context) ([]Webhook, error)
+
+	// StoreDelivery ignores deliveries that already exist for the same webhook and event
+	StoreDelivery(ctx context.Context, d *WebhookDelivery) error
+	FetchDeliveries(ctx context.Context, webhookID int64, limit int, offset int) ([]WebhookDelivery, error)
+	GetDelivery(ctx context.Context, webhookID int64, id int64) (WebhookDelivery, error)
+	// FetchDueDeliveries claims pending deliveries whose next attempt is due
+	// for lease, during which no other call returns them
+	FetchDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, d *WebhookDelivery) error
+}
+
+type WebhookUsecase interface {
+	Fetch(ctx context.Context) ([]Webhook, error)
+	GetByID(ctx context.Context, id int64) (Webhook, error)
+	Store(ctx context.Context, w *Webhook) error
+	Update(ctx context.Context, w *Webhook) error
+	Delete(ctx context.Context, id int64) error
+
+	FetchDeliveries(ctx context.Context, webhookID int64, limit int, offset int) ([]WebhookDelivery, error)
+	// Redeliver sends a delivery again now; it fails with ErrWebhookDisabled
+	// for webhooks that are not active
+	Redeliver(ctx context.Context, webhookID int64, deliveryID int64) (WebhookDelivery, error)
+
+	// Enqueue creates a pending delivery for every active webhook subscribed to evt
+	Enqueue(ctx context.Context, evt Event) error
+	// DispatchDue sends due deliveries and returns how many were attempted
+	DispatchDue(ctx context.Context, limit int) (int, error)
+}
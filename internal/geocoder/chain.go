@@ -0,0 +1,24 @@
+package geocoder
+
+import (
+	"context"
+	"errors"
+
+	"nusatek-backend/internal/domain"
+)
+
+// Chain asks each geocoder in turn until one finds a match. Errors other than
+// ErrNoGeocodeMatch stop the chain so the caller retries later rather than
+// settling for a less precise answer.
+type Chain []domain.Geocoder
+
+func (c Chain) Geocode(ctx context.Context, a domain.Address) (domain.GeocodeResult, error) {
+	for _, g := range c {
+		res, err := g.Geocode(ctx, a)
+		if errors.Is(err, domain.ErrNoGeocodeMatch) {
+			continue
+		}
+		return res, err
+	}
+	return domain.GeocodeResult{}, domain.ErrNoGeocodeMatch
+}
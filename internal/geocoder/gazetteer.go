@@ -0,0 +1,75 @@
+package geocoder
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"nusatek-backend/internal/domain"
+)
+
+// gazetteerConfidence is the confidence of a region centroid by region level.
+// A centroid says nothing about where in the region the property is.
+var gazetteerConfidence = map[int]float64{
+	domain.RegionProvince: 0.1,
+	domain.RegionRegency:  0.3,
+	domain.RegionDistrict: 0.5,
+	domain.RegionVillage:  0.7,
+}
+
+// Gazetteer geocodes offline from the centroids of administrative regions,
+// read from a CSV file of code,name,lat,lng rows with a header.
+type Gazetteer struct {
+	centroids map[string]domain.GeoPoint
+}
+
+func LoadGazetteer(path string) (*Gazetteer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewGazetteer(f)
+}
+
+func NewGazetteer(r io.Reader) (*Gazetteer, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 4
+	cr.Comment = '#'
+	if _, err := cr.Read(); err != nil {
+		return nil, fmt.Errorf("gazetteer: reading header: %w", err)
+	}
+
+	g := &Gazetteer{centroids: make(map[string]domain.GeoPoint)}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return g, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("gazetteer: %w", err)
+		}
+		lat, errLat := strconv.ParseFloat(rec[2], 64)
+		lng, errLng := strconv.ParseFloat(rec[3], 64)
+		p := domain.GeoPoint{Lat: lat, Lng: lng}
+		if domain.RegionLevel(rec[0]) == 0 || errLat != nil || errLng != nil || !p.Valid() {
+			line, _ := cr.FieldPos(0)
+			return nil, fmt.Errorf("gazetteer: line %d: invalid entry %v", line, rec)
+		}
+		g.centroids[rec[0]] = p
+	}
+}
+
+// Geocode returns the centroid of the most specific region of a that the
+// gazetteer knows, falling back to the regions containing it
+func (g *Gazetteer) Geocode(ctx context.Context, a domain.Address) (domain.GeocodeResult, error) {
+	for code := a.MostSpecificCode(); code != ""; code = domain.ParentRegionCode(code) {
+		if p, ok := g.centroids[code]; ok {
+			return domain.GeocodeResult{Location: p, Confidence: gazetteerConfidence[domain.RegionLevel(code)], Provider: "gazetteer"}, nil
+		}
+	}
+	return domain.GeocodeResult{}, domain.ErrNoGeocodeMatch
+}
@@ -0,0 +1,90 @@
+package geocoder
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"nusatek-backend/internal/domain"
+)
+
+const testGazetteer = `code,name,lat,lng
+32.73,Kota Bandung,-6.9147,107.6098
+32.73.01,Sukasari,-6.8721,107.5863
+`
+
+func TestGazetteer(t *testing.T) {
+	g, err := NewGazetteer(strings.NewReader(testGazetteer))
+	assert.NoError(t, err)
+
+	res, err := g.Geocode(context.Background(), domain.Address{RegencyCode: "32.73", DistrictCode: "32.73.01"})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.GeoPoint{Lat: -6.8721, Lng: 107.5863}, res.Location)
+	assert.Equal(t, 0.5, res.Confidence)
+
+	// Unknown villages fall back to the nearest known region containing them
+	res, err = g.Geocode(context.Background(), domain.Address{VillageCode: "32.73.02.1001"})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.GeoPoint{Lat: -6.9147, Lng: 107.6098}, res.Location)
+	assert.Equal(t, 0.3, res.Confidence)
+
+	_, err = g.Geocode(context.Background(), domain.Address{Street: "Jl. Braga 10"})
+	assert.ErrorIs(t, err, domain.ErrNoGeocodeMatch)
+
+	_, err = NewGazetteer(strings.NewReader("code,name,lat,lng\n32.73,Kota Bandung,north,107.6\n"))
+	assert.Error(t, err)
+}
+
+func TestDataGazetteerLoads(t *testing.T) {
+	_, err := LoadGazetteer("../../data/gazetteer.csv")
+	assert.NoError(t, err)
+}
+
+func TestNominatim(t *testing.T) {
+	var query, userAgent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query, userAgent = r.URL.Query().Get("q"), r.UserAgent()
+		switch {
+		case strings.Contains(query, "Nowhere"):
+			w.Write([]byte(`[]`))
+		case strings.Contains(query, "Down"):
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`[{"lat": "-6.9175", "lon": "107.6094", "place_rank": 30, "display_name": "Jalan Braga 10"}]`))
+		}
+	}))
+	defer srv.Close()
+
+	n := NewNominatim(srv.URL, "nusatek-test", srv.Client())
+	n.MinInterval = 0
+
+	res, err := n.Geocode(context.Background(), domain.Address{Street: "Jl. Braga 10", Regency: "Kota Bandung"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Jl. Braga 10, Kota Bandung", query)
+	assert.Equal(t, "nusatek-test", userAgent)
+	assert.Equal(t, domain.GeocodeResult{Location: domain.GeoPoint{Lat: -6.9175, Lng: 107.6094}, Confidence: 1, Provider: "nominatim"}, res)
+
+	_, err = n.Geocode(context.Background(), domain.Address{Street: "Nowhere"})
+	assert.ErrorIs(t, err, domain.ErrNoGeocodeMatch)
+
+	_, err = n.Geocode(context.Background(), domain.Address{Street: "Down"})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, domain.ErrNoGeocodeMatch)
+}
+
+func TestChain(t *testing.T) {
+	g, _ := NewGazetteer(strings.NewReader(testGazetteer))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+	n := NewNominatim(srv.URL, "nusatek-test", srv.Client())
+	n.MinInterval = 0
+
+	res, err := Chain{n, g}.Geocode(context.Background(), domain.Address{RegencyCode: "32.73", Regency: "Kota Bandung"})
+	assert.NoError(t, err)
+	assert.Equal(t, "gazetteer", res.Provider)
+}
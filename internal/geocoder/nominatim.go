@@ -0,0 +1,124 @@
+package geocoder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"nusatek-backend/internal/domain"
+)
+
+// Nominatim geocodes with an OpenStreetMap Nominatim compatible search API.
+// The public instance allows one request per second and requires an
+// identifying User-Agent; self-hosted instances can lower MinInterval.
+type Nominatim struct {
+	BaseURL     string
+	UserAgent   string
+	Client      *http.Client
+	MinInterval time.Duration
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+func NewNominatim(baseURL string, userAgent string, client *http.Client) *Nominatim {
+	return &Nominatim{
+		BaseURL:     strings.TrimRight(baseURL, "/"),
+		UserAgent:   userAgent,
+		Client:      client,
+		MinInterval: time.Second,
+	}
+}
+
+type nominatimPlace struct {
+	Lat       string `json:"lat"`
+	Lon       string `json:"lon"`
+	PlaceRank int    `json:"place_rank"`
+}
+
+func (n *Nominatim) Geocode(ctx context.Context, a domain.Address) (domain.GeocodeResult, error) {
+	q := url.Values{}
+	q.Set("q", a.String())
+	q.Set("countrycodes", "id")
+	q.Set("format", "jsonv2")
+	q.Set("limit", "1")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, n.BaseURL+"/search?"+q.Encode(), nil)
+	if err != nil {
+		return domain.GeocodeResult{}, err
+	}
+	req.Header.Set("User-Agent", n.UserAgent)
+	req.Header.Set("Accept-Language", "id")
+
+	if err := n.wait(ctx); err != nil {
+		return domain.GeocodeResult{}, err
+	}
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return domain.GeocodeResult{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return domain.GeocodeResult{}, fmt.Errorf("nominatim: unexpected status %d", resp.StatusCode)
+	}
+
+	var places []nominatimPlace
+	if err := json.NewDecoder(resp.Body).Decode(&places); err != nil {
+		return domain.GeocodeResult{}, fmt.Errorf("nominatim: %w", err)
+	}
+	if len(places) == 0 {
+		return domain.GeocodeResult{}, domain.ErrNoGeocodeMatch
+	}
+	lat, errLat := strconv.ParseFloat(places[0].Lat, 64)
+	lng, errLng := strconv.ParseFloat(places[0].Lon, 64)
+	if errLat != nil || errLng != nil {
+		return domain.GeocodeResult{}, fmt.Errorf("nominatim: invalid coordinates %q,%q", places[0].Lat, places[0].Lon)
+	}
+	return domain.GeocodeResult{
+		Location:   domain.GeoPoint{Lat: lat, Lng: lng},
+		Confidence: rankConfidence(places[0].PlaceRank),
+		Provider:   "nominatim",
+	}, nil
+}
+
+// wait spaces requests at least MinInterval apart
+func (n *Nominatim) wait(ctx context.Context) error {
+	n.mu.Lock()
+	next := n.last.Add(n.MinInterval)
+	now := time.Now()
+	if next.Before(now) {
+		next = now
+	}
+	n.last = next
+	n.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Until(next)):
+		return nil
+	}
+}
+
+// rankConfidence maps Nominatim's place_rank, which grows from country (4)
+// to house (30), onto a confidence
+func rankConfidence(rank int) float64 {
+	switch {
+	case rank >= 30:
+		return 1
+	case rank >= 26:
+		return 0.8 // street
+	case rank >= 19:
+		return 0.6 // village, neighbourhood
+	case rank >= 16:
+		return 0.4 // district, city
+	case rank >= 12:
+		return 0.3 // regency
+	}
+	return 0.1
+}
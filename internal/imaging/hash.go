@@ -0,0 +1,34 @@
+package imaging
+
+import (
+	"image"
+
+	"nusatek-backend/internal/domain"
+)
+
+// DifferenceHash computes the dHash of img: it is shrunk to 9×8 grey pixels
+// and every bit tells whether a pixel is brighter than its right neighbour.
+// Resizing, recompression and small colour changes keep most bits.
+func DifferenceHash(img image.Image) domain.PerceptualHash {
+	rgba, ok := img.(*image.RGBA)
+	if !ok || rgba.Rect.Min != (image.Point{}) {
+		rgba = flatten(img)
+	}
+	small := resize(rgba, 9, 8)
+
+	var h uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			h <<= 1
+			if luma(small, x, y) > luma(small, x+1, y) {
+				h |= 1
+			}
+		}
+	}
+	return domain.PerceptualHash(h)
+}
+
+func luma(img *image.RGBA, x, y int) int {
+	p := img.Pix[img.PixOffset(x, y):]
+	return 299*int(p[0]) + 587*int(p[1]) + 114*int(p[2])
+}
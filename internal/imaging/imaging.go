@@ -0,0 +1,90 @@
+// Package imaging cleans uploaded photos of their metadata and renders the
+// resized copies shown in listings.
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+
+	"nusatek-backend/internal/domain"
+)
+
+// MaxPixels bounds the size of photos that are decoded. A small file can
+// declare huge dimensions, and decoding allocates 4 bytes for every pixel.
+const MaxPixels = 50_000_000
+
+// Variant names a resized copy by the square it must fit in
+type Variant struct {
+	Name string
+	Box  int
+}
+
+// DefaultVariants are rendered for every photo, largest first
+var DefaultVariants = []Variant{
+	{Name: "full", Box: 1920},
+	{Name: "card", Box: 800},
+	{Name: "thumbnail", Box: 320},
+}
+
+type Processor struct {
+	variants []Variant
+	quality  int
+}
+
+// NewProcessor renders variants, which must be ordered largest first, as
+// JPEGs of the given quality
+func NewProcessor(variants []Variant, quality int) *Processor {
+	return &Processor{variants: variants, quality: quality}
+}
+
+func (p *Processor) StripMetadata(data []byte, contentType string) ([]byte, error) {
+	stripped, err := StripMetadata(data, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidMedia, err)
+	}
+	return stripped, nil
+}
+
+func (p *Processor) Process(data []byte, contentType string) (domain.ProcessedImage, error) {
+	original, err := p.StripMetadata(data, contentType)
+	if err != nil {
+		return domain.ProcessedImage{}, err
+	}
+	res := domain.ProcessedImage{Original: original}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return domain.ProcessedImage{}, fmt.Errorf("%w: %v", domain.ErrInvalidMedia, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > MaxPixels/cfg.Height {
+		return domain.ProcessedImage{}, fmt.Errorf("%w: %d×%d pixels is more than %d megapixels", domain.ErrInvalidMedia, cfg.Width, cfg.Height, MaxPixels/1_000_000)
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return domain.ProcessedImage{}, fmt.Errorf("%w: %v", domain.ErrInvalidMedia, err)
+	}
+	img := flatten(decoded)
+	if contentType == "image/jpeg" {
+		img = orient(img, JPEGOrientation(data))
+	}
+	res.Width, res.Height = img.Rect.Dx(), img.Rect.Dy()
+
+	// Every variant is shrunk from the previous, larger one, which is much
+	// cheaper than starting from a 12 megapixel original each time
+	for _, v := range p.variants {
+		w, h := fit(img.Rect.Dx(), img.Rect.Dy(), v.Box)
+		img = resize(img, w, h)
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: p.quality}); err != nil {
+			return domain.ProcessedImage{}, err
+		}
+		res.Variants = append(res.Variants, domain.ImageVariant{Name: v.Name, Data: buf.Bytes(), Width: w, Height: h})
+	}
+	res.Hash = DifferenceHash(img)
+	return res, nil
+}
@@ -0,0 +1,207 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"nusatek-backend/internal/domain"
+)
+
+// gradient is a w×h image getting brighter to the right, with a dark block
+// in its top left corner to tell its orientation
+func gradient(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8(40 + 200*x/w)
+			if x < w/4 && y < h/4 {
+				v = 0
+			}
+			img.Set(x, y, color.RGBA{v, v, v, 255})
+		}
+	}
+	return img
+}
+
+// withEXIF inserts an EXIF segment with an orientation and a GPS tag after the SOI marker
+func withEXIF(jpg []byte, orientation int) []byte {
+	tiff := []byte{'I', 'I', 42, 0, 8, 0, 0, 0, 2, 0}
+	entry := make([]byte, 12)
+	binary.LittleEndian.PutUint16(entry, tagOrientation)
+	binary.LittleEndian.PutUint16(entry[2:], 3)
+	binary.LittleEndian.PutUint32(entry[4:], 1)
+	binary.LittleEndian.PutUint16(entry[8:], uint16(orientation))
+	tiff = append(tiff, entry...)
+	gps := make([]byte, 12)
+	binary.LittleEndian.PutUint16(gps, 0x8825)
+	binary.LittleEndian.PutUint16(gps[2:], 4)
+	binary.LittleEndian.PutUint32(gps[4:], 1)
+	binary.LittleEndian.PutUint32(gps[8:], 30)
+	tiff = append(tiff, gps...)
+	tiff = append(tiff, 0, 0, 0, 0)
+	tiff = append(tiff, "GPS -6.2088 106.8456"...)
+
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(2+6+len(tiff)))
+	segment = append(segment, "Exif\x00\x00"...)
+	segment = append(segment, tiff...)
+
+	out := append([]byte{0xFF, 0xD8}, segment...)
+	return append(out, jpg[2:]...)
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}))
+	return buf.Bytes()
+}
+
+func TestProcessJPEG(t *testing.T) {
+	// Taken with the phone held upright: stored sideways, to be turned clockwise
+	photo := withEXIF(encodeJPEG(t, gradient(1200, 900)), 6)
+	require.Equal(t, 6, JPEGOrientation(photo))
+
+	p := NewProcessor([]Variant{{Name: "card", Box: 800}, {Name: "thumbnail", Box: 320}}, 82)
+	res, err := p.Process(photo, "image/jpeg")
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(res.Original), "GPS -6.2088")
+	assert.Equal(t, 6, JPEGOrientation(res.Original), "the original keeps its orientation")
+	_, err = jpeg.Decode(bytes.NewReader(res.Original))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 900, res.Width)
+	assert.Equal(t, 1200, res.Height)
+	require.Len(t, res.Variants, 2)
+	assert.Equal(t, "card", res.Variants[0].Name)
+	assert.Equal(t, 600, res.Variants[0].Width)
+	assert.Equal(t, 800, res.Variants[0].Height)
+	assert.Equal(t, 240, res.Variants[1].Width)
+	assert.Equal(t, 320, res.Variants[1].Height)
+
+	thumb, err := jpeg.Decode(bytes.NewReader(res.Variants[1].Data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 240, 320), thumb.Bounds())
+	// The dark corner ends up top right once turned upright
+	r, _, _, _ := thumb.At(235, 5).RGBA()
+	assert.Less(t, r>>8, uint32(30))
+	assert.Equal(t, 1, JPEGOrientation(res.Variants[1].Data), "variants have no EXIF")
+	assert.NotZero(t, res.Hash)
+}
+
+func TestProcessSmallPNG(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, gradient(200, 100)))
+
+	res, err := NewProcessor(DefaultVariants, 82).Process(buf.Bytes(), "image/png")
+	require.NoError(t, err)
+	require.Len(t, res.Variants, 3)
+	for _, v := range res.Variants {
+		// Never enlarged
+		assert.Equal(t, 200, v.Width, v.Name)
+		assert.Equal(t, 100, v.Height, v.Name)
+	}
+}
+
+func TestProcessInvalid(t *testing.T) {
+	_, err := NewProcessor(DefaultVariants, 82).Process([]byte("\xff\xd8\xff\xe0 truncated"), "image/jpeg")
+	assert.Error(t, err)
+}
+
+func TestStripJPEGTrailingData(t *testing.T) {
+	primary := encodeJPEG(t, gradient(64, 48))
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(2+len("Exif\x00\x00GPS -6.2088 106.8456")))
+	app1 = append(app1, "Exif\x00\x00GPS -6.2088 106.8456"...)
+	// Some cameras append a preview JPEG, with its own EXIF, after the photo
+	data := append(append(append([]byte{}, primary...), app1...), withEXIF(encodeJPEG(t, gradient(16, 12)), 1)...)
+
+	out, err := StripMetadata(data, "image/jpeg")
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "GPS")
+	assert.Equal(t, primary, out)
+
+	img, err := jpeg.Decode(bytes.NewReader(out))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 64, 48), img.Bounds())
+
+	_, err = StripMetadata(primary[:len(primary)-2], "image/jpeg")
+	assert.Error(t, err, "the image data must end")
+}
+
+func TestStripWebP(t *testing.T) {
+	chunk := func(fourcc string, data []byte) []byte {
+		c := append([]byte(fourcc), 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(c[4:], uint32(len(data)))
+		c = append(c, data...)
+		if len(data)%2 == 1 {
+			c = append(c, 0)
+		}
+		return c
+	}
+	body := []byte("WEBP")
+	body = append(body, chunk("VP8X", []byte{0x08 | 0x04 | 0x10, 0, 0, 0, 9, 0, 0, 9, 0, 0})...)
+	body = append(body, chunk("VP8L", []byte("pixels"))...)
+	body = append(body, chunk("EXIF", []byte("GPS -6.2088 106.8456"))...)
+	body = append(body, chunk("XMP ", []byte("<x:xmpmeta/>"))...)
+	webp := append([]byte("RIFF\x00\x00\x00\x00"), body...)
+	binary.LittleEndian.PutUint32(webp[4:], uint32(len(body)))
+
+	out, err := StripMetadata(webp, "image/webp")
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "GPS")
+	assert.NotContains(t, string(out), "xmpmeta")
+	assert.Contains(t, string(out), "pixels")
+	assert.Equal(t, byte(0x10), out[20], "only the alpha flag is left")
+	assert.Equal(t, uint32(len(out)-8), binary.LittleEndian.Uint32(out[4:]))
+}
+
+func TestProcessWebP(t *testing.T) {
+	// A 1×1 lossy WebP
+	webp, err := base64.StdEncoding.DecodeString("UklGRiIAAABXRUJQVlA4IBYAAAAwAQCdASoBAAEADsD+JaQAA3AAAAAA")
+	require.NoError(t, err)
+
+	res, err := NewProcessor(DefaultVariants, 82).Process(webp, "image/webp")
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Width)
+	assert.Equal(t, 1, res.Height)
+	require.Len(t, res.Variants, len(DefaultVariants))
+	_, err = jpeg.Decode(bytes.NewReader(res.Variants[0].Data))
+	assert.NoError(t, err)
+}
+
+func TestProcessTooManyPixels(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, gradient(1, 1)))
+	// The IHDR chunk claims 20000×20000 pixels
+	data := buf.Bytes()
+	binary.BigEndian.PutUint32(data[16:], 20000)
+	binary.BigEndian.PutUint32(data[20:], 20000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+	_, err := NewProcessor(DefaultVariants, 82).Process(data, "image/png")
+	assert.ErrorIs(t, err, domain.ErrInvalidMedia)
+	assert.Contains(t, err.Error(), "megapixels")
+}
+
+func TestDifferenceHash(t *testing.T) {
+	photo := gradient(800, 600)
+	smaller := resize(photo, 200, 150)
+	recompressed, err := jpeg.Decode(bytes.NewReader(encodeJPEG(t, smaller)))
+	require.NoError(t, err)
+
+	h := DifferenceHash(photo)
+	assert.LessOrEqual(t, h.Distance(DifferenceHash(recompressed)), 4)
+
+	other := orient(gradient(800, 600), 3)
+	assert.Greater(t, h.Distance(DifferenceHash(other)), 20)
+}
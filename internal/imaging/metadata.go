@@ -0,0 +1,261 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var errMalformed = errors.New("imaging: malformed file")
+
+const tagOrientation = 0x0112
+
+// StripMetadata removes EXIF, XMP and text metadata from a JPEG, PNG or WebP
+// file without re-encoding it. Phone photos carry GPS coordinates and camera
+// serials there. A JPEG keeps its EXIF orientation, rewritten as the only tag,
+// so that it is still shown upright.
+func StripMetadata(data []byte, contentType string) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	case "image/webp":
+		return stripWebP(data)
+	}
+	return nil, errors.New("imaging: unsupported format " + contentType)
+}
+
+// jpegSegments calls fn with every marker segment before the image data and
+// returns the offset of the start of scan marker
+func jpegSegments(data []byte, fn func(marker byte, segment []byte)) (int, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 0, errMalformed
+	}
+	i := 2
+	for {
+		if i+4 > len(data) || data[i] != 0xFF {
+			return 0, errMalformed
+		}
+		marker := data[i+1]
+		if marker == 0xFF {
+			// Fill byte before a marker
+			i++
+			continue
+		}
+		if marker == 0xDA {
+			return i, nil
+		}
+		n := int(binary.BigEndian.Uint16(data[i+2:]))
+		if n < 2 || i+2+n > len(data) {
+			return 0, errMalformed
+		}
+		fn(marker, data[i:i+2+n])
+		i += 2 + n
+	}
+}
+
+func isAPP1(marker byte, segment []byte, signature string) bool {
+	return marker == 0xE1 && bytes.HasPrefix(segment[4:], []byte(signature))
+}
+
+// isMetadata reports whether a segment holds comments, IPTC or application
+// data (EXIF, XMP, maker notes). Adobe colour transforms and ICC profiles are
+// needed to show the image.
+func isMetadata(marker byte) bool {
+	return marker == 0xFE || marker >= 0xE1 && marker <= 0xEF && marker != 0xE2 && marker != 0xEE
+}
+
+func stripJPEG(data []byte) ([]byte, error) {
+	orientation := JPEGOrientation(data)
+
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, 0xD8)
+	written := false
+	writeOrientation := func() {
+		if !written && orientation > 1 {
+			out = append(out, orientationSegment(orientation)...)
+		}
+		written = true
+	}
+	sos, err := jpegSegments(data, func(marker byte, segment []byte) {
+		switch {
+		case marker == 0xE0:
+			// JFIF must stay first
+		case isMetadata(marker):
+			return
+		default:
+			writeOrientation()
+		}
+		out = append(out, segment...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	writeOrientation()
+	return jpegScans(out, data, sos)
+}
+
+// jpegScans appends the scans starting at sos to out, up to and including the
+// end of image marker. Whatever follows it, such as another APP1 segment or a
+// second JPEG, is dropped: viewers ignore it but it can still carry metadata.
+func jpegScans(out, data []byte, sos int) ([]byte, error) {
+	for i := sos; ; {
+		if i+2 > len(data) || data[i] != 0xFF {
+			return nil, errMalformed
+		}
+		marker := data[i+1]
+		switch marker {
+		case 0xFF:
+			i++
+			continue
+		case 0xD9:
+			return append(out, data[i:i+2]...), nil
+		}
+		if i+4 > len(data) {
+			return nil, errMalformed
+		}
+		n := int(binary.BigEndian.Uint16(data[i+2:]))
+		if n < 2 || i+2+n > len(data) {
+			return nil, errMalformed
+		}
+		if !isMetadata(marker) {
+			out = append(out, data[i:i+2+n]...)
+		}
+		i += 2 + n
+		if marker != 0xDA {
+			continue
+		}
+		// Entropy-coded data runs to the next marker other than a stuffed
+		// zero byte or a restart marker
+		j := i
+		for ; j+1 < len(data); j++ {
+			if data[j] == 0xFF && data[j+1] != 0x00 && (data[j+1] < 0xD0 || data[j+1] > 0xD7) {
+				break
+			}
+		}
+		out = append(out, data[i:j]...)
+		i = j
+	}
+}
+
+// orientationSegment is an APP1 EXIF segment holding only an orientation tag
+func orientationSegment(orientation int) []byte {
+	tiff := []byte{
+		'M', 'M', 0, 42, 0, 0, 0, 8, // big endian, first IFD at 8
+		0, 1, // one entry
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, byte(orientation), 0, 0, // SHORT orientation
+		0, 0, 0, 0, // no next IFD
+	}
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(2+6+len(tiff)))
+	segment = append(segment, "Exif\x00\x00"...)
+	return append(segment, tiff...)
+}
+
+// JPEGOrientation returns the EXIF orientation of a JPEG, from 1 (upright) to
+// 8, or 1 when it has none
+func JPEGOrientation(data []byte) int {
+	orientation := 1
+	_, _ = jpegSegments(data, func(marker byte, segment []byte) {
+		if isAPP1(marker, segment, "Exif\x00\x00") {
+			if o := tiffOrientation(segment[10:]); o >= 1 && o <= 8 {
+				orientation = o
+			}
+		}
+	})
+	return orientation
+}
+
+// tiffOrientation reads the orientation tag from the first IFD of EXIF data
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		e := ifd + 2 + 12*i
+		if e+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[e:]) == tagOrientation && order.Uint16(tiff[e+2:]) == 3 {
+			return int(order.Uint16(tiff[e+8:]))
+		}
+	}
+	return 0
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// stripPNG drops the eXIf chunk and the text chunks, which hold XMP
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errMalformed
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	for i := len(pngSignature); i < len(data); {
+		if i+12 > len(data) {
+			return nil, errMalformed
+		}
+		n := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + n
+		if end > len(data) {
+			return nil, errMalformed
+		}
+		switch string(data[i+4 : i+8]) {
+		case "eXIf", "tEXt", "zTXt", "iTXt":
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	return out, nil
+}
+
+// stripWebP drops the EXIF and XMP chunks and clears their flags in the
+// extended header
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errMalformed
+	}
+	out := make([]byte, 12, len(data))
+	copy(out, data[:12])
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, errMalformed
+		}
+		n := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + n + n%2
+		if end > len(data) {
+			return nil, errMalformed
+		}
+		switch string(data[i : i+4]) {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte(nil), data[i:end]...)
+			if n > 0 {
+				chunk[8] &^= 0x08 | 0x04
+			}
+			out = append(out, chunk...)
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
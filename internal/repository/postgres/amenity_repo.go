@@ -0,0 +1,203 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+
+	"nusatek-backend/internal/domain"
+)
+
+type amenityRepository struct {
+	Conn *sql.DB
+}
+
+func NewAmenityRepository(Conn *sql.DB) domain.AmenityRepository {
+	return &amenityRepository{Conn}
+}
+
+const amenityColumns = `id, code, name, category, COALESCE(unit, ''), created_at`
+
+func scanAmenity(row rowScanner) (domain.Amenity, error) {
+	var a domain.Amenity
+	err := row.Scan(&a.ID, &a.Code, &a.Name, &a.Category, &a.Unit, &a.CreatedAt)
+	return a, err
+}
+
+func (m *amenityRepository) Fetch(ctx context.Context) ([]domain.Amenity, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.fetch(ctx, `SELECT `+amenityColumns+` FROM amenities WHERE tenant_id = $1 ORDER BY category, name`, tenant)
+}
+
+func (m *amenityRepository) FetchByCodes(ctx context.Context, codes []string) ([]domain.Amenity, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.fetch(ctx, `SELECT `+amenityColumns+` FROM amenities WHERE tenant_id = $1 AND code = ANY($2) ORDER BY code`, tenant, pq.Array(codes))
+}
+
+func (m *amenityRepository) fetch(ctx context.Context, query string, args ...interface{}) ([]domain.Amenity, error) {
+	rows, err := conn(ctx, m.Conn).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	amenities := []domain.Amenity{}
+	for rows.Next() {
+		a, err := scanAmenity(rows)
+		if err != nil {
+			return nil, err
+		}
+		amenities = append(amenities, a)
+	}
+	return amenities, rows.Err()
+}
+
+func (m *amenityRepository) GetByID(ctx context.Context, id int64) (domain.Amenity, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return domain.Amenity{}, err
+	}
+
+	query := `SELECT ` + amenityColumns + ` FROM amenities WHERE id = $1 AND tenant_id = $2`
+	a, err := scanAmenity(conn(ctx, m.Conn).QueryRowContext(ctx, query, id, tenant))
+	if err == sql.ErrNoRows {
+		return domain.Amenity{}, domain.ErrAmenityNotFound
+	}
+	return a, err
+}
+
+func (m *amenityRepository) Store(ctx context.Context, a *domain.Amenity) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO amenities (tenant_id, code, name, category, unit, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NOW()) RETURNING id, created_at`
+	err = conn(ctx, m.Conn).QueryRowContext(ctx, query, tenant, a.Code, a.Name, a.Category, a.Unit).Scan(&a.ID, &a.CreatedAt)
+	return amenityError(err)
+}
+
+func (m *amenityRepository) Update(ctx context.Context, a *domain.Amenity) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE amenities SET code = $1, name = $2, category = $3, unit = NULLIF($4, '')
+		WHERE id = $5 AND tenant_id = $6 RETURNING created_at`
+	err = conn(ctx, m.Conn).QueryRowContext(ctx, query, a.Code, a.Name, a.Category, a.Unit, a.ID, tenant).Scan(&a.CreatedAt)
+	if err == sql.ErrNoRows {
+		return domain.ErrAmenityNotFound
+	}
+	return amenityError(err)
+}
+
+// amenityError reports a taken code as ErrAmenityExists
+func amenityError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return domain.ErrAmenityExists
+	}
+	return err
+}
+
+func (m *amenityRepository) Delete(ctx context.Context, id int64) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := conn(ctx, m.Conn).ExecContext(ctx, `DELETE FROM amenities WHERE id = $1 AND tenant_id = $2`, id, tenant)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return domain.ErrAmenityNotFound
+	}
+	return nil
+}
+
+func (m *amenityRepository) FetchPropertyIDs(ctx context.Context, amenityID int64) ([]int64, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn(ctx, m.Conn).QueryContext(ctx, `SELECT property_id FROM property_amenities
+		WHERE amenity_id = $1 AND tenant_id = $2 ORDER BY property_id`, amenityID, tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (m *amenityRepository) FetchByProperties(ctx context.Context, propertyIDs []int64) (map[int64][]domain.PropertyAmenity, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT pa.property_id, a.id, a.code, a.name, a.category, COALESCE(a.unit, ''), COALESCE(pa.value, 0)
+		FROM property_amenities pa JOIN amenities a ON a.id = pa.amenity_id
+		WHERE pa.property_id = ANY($1) AND pa.tenant_id = $2 ORDER BY pa.property_id, a.code`
+	rows, err := conn(ctx, m.Conn).QueryContext(ctx, query, pq.Array(propertyIDs), tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	amenities := make(map[int64][]domain.PropertyAmenity)
+	for rows.Next() {
+		var propertyID int64
+		var a domain.PropertyAmenity
+		if err := rows.Scan(&propertyID, &a.AmenityID, &a.Code, &a.Name, &a.Category, &a.Unit, &a.Value); err != nil {
+			return nil, err
+		}
+		amenities[propertyID] = append(amenities[propertyID], a)
+	}
+	return amenities, rows.Err()
+}
+
+func (m *amenityRepository) SetForProperty(ctx context.Context, propertyID int64, amenities []domain.PropertyAmenity) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]int64, len(amenities))
+	values := make([]float64, len(amenities))
+	for i, a := range amenities {
+		ids[i], values[i] = a.AmenityID, a.Value
+	}
+
+	return withinTx(ctx, m.Conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM property_amenities WHERE property_id = $1 AND tenant_id = $2`, propertyID, tenant); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO property_amenities (tenant_id, property_id, amenity_id, value)
+			SELECT $1, $2, a.id, NULLIF(v.value, 0)
+			FROM unnest($3::bigint[], $4::numeric[]) AS v(amenity_id, value)
+			JOIN amenities a ON a.id = v.amenity_id AND a.tenant_id = $1`,
+			tenant, propertyID, pq.Array(ids), pq.Array(values))
+		return err
+	})
+}
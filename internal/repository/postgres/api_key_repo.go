@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"nusatek-backend/internal/domain"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type apiKeyRepository struct {
+	Conn *sql.DB
+}
+
+func NewAPIKeyRepository(Conn *sql.DB) domain.APIKeyRepository {
+	return &apiKeyRepository{Conn}
+}
+
+const apiKeyColumns = `id, name, prefix, key_hash, scopes, tenant_id, COALESCE(created_by, 0), last_used_at, expires_at, revoked_at, created_at, updated_at`
+
+func (m *apiKeyRepository) Fetch(ctx context.Context) ([]domain.APIKey, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE tenant_id = $1 ORDER BY id`
+	rows, err := m.Conn.QueryContext(ctx, query, tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []domain.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (m *apiKeyRepository) GetByID(ctx context.Context, id int64) (domain.APIKey, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = $1 AND tenant_id = $2`
+	return m.get(ctx, query, id, tenant)
+}
+
+// GetByHash is not tenant scoped: the key itself identifies the tenant
+func (m *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
+	return m.get(ctx, query, keyHash)
+}
+
+func (m *apiKeyRepository) get(ctx context.Context, query string, args ...interface{}) (domain.APIKey, error) {
+	k, err := scanAPIKey(m.Conn.QueryRowContext(ctx, query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return k, domain.ErrAPIKeyNotFound
+	}
+	return k, err
+}
+
+func (m *apiKeyRepository) Store(ctx context.Context, k *domain.APIKey) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+	k.TenantID = tenant
+
+	query := `INSERT INTO api_keys (tenant_id, name, prefix, key_hash, scopes, created_by, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), $7, NOW(), NOW()) RETURNING id, created_at, updated_at`
+	return m.Conn.QueryRowContext(ctx, query, k.TenantID, k.Name, k.Prefix, k.KeyHash, pq.Array(k.Scopes), k.CreatedBy, k.ExpiresAt).Scan(&k.ID, &k.CreatedAt, &k.UpdatedAt)
+}
+
+func (m *apiKeyRepository) Rotate(ctx context.Context, k *domain.APIKey) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE api_keys SET prefix=$1, key_hash=$2, last_used_at=NULL, updated_at=NOW() WHERE id=$3 AND tenant_id=$4 AND revoked_at IS NULL RETURNING updated_at`
+	err = m.Conn.QueryRowContext(ctx, query, k.Prefix, k.KeyHash, k.ID, tenant).Scan(&k.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrAPIKeyNotFound
+	}
+	k.LastUsedAt = nil
+	return err
+}
+
+func (m *apiKeyRepository) Revoke(ctx context.Context, id int64) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE api_keys SET revoked_at=NOW(), updated_at=NOW() WHERE id=$1 AND tenant_id=$2 AND revoked_at IS NULL`
+	res, err := m.Conn.ExecContext(ctx, query, id, tenant)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (m *apiKeyRepository) TouchLastUsed(ctx context.Context, id int64, at time.Time) error {
+	// Skip the write when the key was already marked recently, so busy keys
+	// do not turn every request into an UPDATE
+	query := `UPDATE api_keys SET last_used_at=$2 WHERE id=$1 AND (last_used_at IS NULL OR last_used_at < $2 - INTERVAL '1 minute')`
+	_, err := m.Conn.ExecContext(ctx, query, id, at)
+	return err
+}
+
+func scanAPIKey(row rowScanner) (domain.APIKey, error) {
+	var k domain.APIKey
+	err := row.Scan(&k.ID, &k.Name, &k.Prefix, &k.KeyHash, pq.Array(&k.Scopes), &k.TenantID, &k.CreatedBy, &k.LastUsedAt, &k.ExpiresAt, &k.RevokedAt, &k.CreatedAt, &k.UpdatedAt)
+	return k, err
+}
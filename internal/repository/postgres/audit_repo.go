@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"nusatek-backend/internal/domain"
+	"strconv"
+)
+
+type auditRepository struct {
+	Conn *sql.DB
+}
+
+func NewAuditRepository(Conn *sql.DB) domain.AuditRepository {
+	return &auditRepository{Conn}
+}
+
+func (m *auditRepository) Store(ctx context.Context, e *domain.AuditEntry) error {
+	query := `INSERT INTO audit_log (tenant_id, actor_id, api_key_id, action, entity, entity_id, before, after, request_id, created_at)
+		VALUES ($1, NULLIF($2, 0), NULLIF($3, 0), $4, $5, $6, $7, $8, NULLIF($9, ''), NOW()) RETURNING id, created_at`
+	return conn(ctx, m.Conn).QueryRowContext(ctx, query, e.TenantID, e.ActorID, e.APIKeyID, e.Action, e.Entity, e.EntityID,
+		nullJSON(e.Before), nullJSON(e.After), e.RequestID).Scan(&e.ID, &e.CreatedAt)
+}
+
+func (m *auditRepository) Fetch(ctx context.Context, f domain.AuditFilter) ([]domain.AuditEntry, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT id, tenant_id, COALESCE(actor_id, 0), COALESCE(api_key_id, 0), action, entity, entity_id, before, after, COALESCE(request_id, ''), created_at
+		FROM audit_log WHERE tenant_id = $1`
+	args := []interface{}{tenant}
+	if f.Entity != "" {
+		args = append(args, f.Entity)
+		query += ` AND entity = $` + strconv.Itoa(len(args))
+	}
+	if f.EntityID != 0 {
+		args = append(args, f.EntityID)
+		query += ` AND entity_id = $` + strconv.Itoa(len(args))
+	}
+	args = append(args, f.Limit, f.Offset)
+	query += ` ORDER BY created_at DESC, id DESC LIMIT $` + strconv.Itoa(len(args)-1) + ` OFFSET $` + strconv.Itoa(len(args))
+
+	rows, err := conn(ctx, m.Conn).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []domain.AuditEntry
+	for rows.Next() {
+		var (
+			e             domain.AuditEntry
+			before, after []byte
+		)
+		if err := rows.Scan(&e.ID, &e.TenantID, &e.ActorID, &e.APIKeyID, &e.Action, &e.Entity, &e.EntityID, &before, &after, &e.RequestID, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Before = before
+		e.After = after
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// nullJSON stores an empty document as SQL NULL
+func nullJSON(doc []byte) interface{} {
+	if len(doc) == 0 {
+		return nil
+	}
+	return doc
+}
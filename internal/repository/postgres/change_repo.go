@@ -0,0 +1,145 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"nusatek-backend/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type changeRepository struct {
+	Conn *sql.DB
+}
+
+func NewChangeRepository(Conn *sql.DB) domain.ChangeRepository {
+	return &changeRepository{Conn}
+}
+
+func (m *changeRepository) FetchSince(ctx context.Context, cursor domain.ChangeCursor, kinds []string, limit int) ([]domain.Change, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Changes are ordered by the id of the transaction that made them, which
+	// schema.sql keeps in change_xid. Every transaction with an id below the
+	// snapshot's xmin has finished, so changes of transactions that have not
+	// committed yet are held back until they can be placed after all others.
+	query := `SELECT kind, id, op, changed_at, change_xid::text FROM (
+			SELECT 'property' AS kind, id, CASE WHEN created_at = updated_at THEN 'created' ELSE 'updated' END AS op, updated_at AS changed_at, change_xid FROM properties WHERE tenant_id = $6 AND deleted_at IS NULL
+			UNION ALL
+			SELECT 'customer', id, CASE WHEN created_at = updated_at THEN 'created' ELSE 'updated' END, updated_at, change_xid FROM customers WHERE tenant_id = $6 AND deleted_at IS NULL
+			UNION ALL
+			SELECT entity, entity_id, 'deleted', deleted_at, change_xid FROM tombstones WHERE tenant_id = $6
+		) c
+		WHERE (change_xid, kind, id) > ($1::xid8, $2, $3)
+			AND change_xid < pg_snapshot_xmin(pg_current_snapshot())
+			AND kind = ANY($4)
+		ORDER BY change_xid, kind, id
+		LIMIT $5`
+	rows, err := m.Conn.QueryContext(ctx, query, strconv.FormatUint(cursor.Seq, 10), cursor.Kind, cursor.ID, pq.Array(kinds), limit, tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		changes     []domain.Change
+		propertyIDs []int64
+		customerIDs []int64
+	)
+	for rows.Next() {
+		var ch domain.Change
+		if err := rows.Scan(&ch.Kind, &ch.ID, &ch.Op, &ch.ChangedAt, &ch.Seq); err != nil {
+			return nil, err
+		}
+		if ch.Op != domain.ChangeDeleted {
+			switch ch.Kind {
+			case domain.ChangeKindProperty:
+				propertyIDs = append(propertyIDs, ch.ID)
+			case domain.ChangeKindCustomer:
+				customerIDs = append(customerIDs, ch.ID)
+			}
+		}
+		changes = append(changes, ch)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	properties, err := m.fetchProperties(ctx, tenant, propertyIDs)
+	if err != nil {
+		return nil, err
+	}
+	customers, err := m.fetchCustomers(ctx, tenant, customerIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range changes {
+		ch := &changes[i]
+		if ch.Op == domain.ChangeDeleted {
+			continue
+		}
+		switch ch.Kind {
+		case domain.ChangeKindProperty:
+			if p, ok := properties[ch.ID]; ok {
+				ch.Property = &p
+			}
+		case domain.ChangeKindCustomer:
+			if c, ok := customers[ch.ID]; ok {
+				ch.Customer = &c
+			}
+		}
+	}
+	return changes, nil
+}
+
+func (m *changeRepository) fetchProperties(ctx context.Context, tenant int64, ids []int64) (map[int64]domain.Property, error) {
+	result := make(map[int64]domain.Property, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	query := `SELECT ` + propertyColumns + ` FROM properties WHERE id = ANY($1) AND tenant_id = $2 AND deleted_at IS NULL`
+	rows, err := m.Conn.QueryContext(ctx, query, pq.Array(ids), tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		p, err := scanProperty(rows)
+		if err != nil {
+			return nil, err
+		}
+		result[p.ID] = p
+	}
+	return result, rows.Err()
+}
+
+func (m *changeRepository) fetchCustomers(ctx context.Context, tenant int64, ids []int64) (map[int64]domain.Customer, error) {
+	result := make(map[int64]domain.Customer, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	query := `SELECT id, name, email, phone, status, created_at, updated_at FROM customers WHERE id = ANY($1) AND tenant_id = $2 AND deleted_at IS NULL`
+	rows, err := m.Conn.QueryContext(ctx, query, pq.Array(ids), tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c domain.Customer
+		if err := rows.Scan(&c.ID, &c.Name, &c.Email, &c.Phone, &c.Status, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		result[c.ID] = c
+	}
+	return result, rows.Err()
+}
@@ -3,9 +3,15 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+
 	"nusatek-backend/internal/domain"
 )
 
+// customerRepository scopes every query to the tenant in ctx
 type customerRepository struct {
 	Conn *sql.DB
 }
@@ -15,8 +21,13 @@ func NewCustomerRepository(Conn *sql.DB) domain.CustomerRepository {
 }
 
 func (m *customerRepository) Fetch(ctx context.Context, limit int, offset int) ([]domain.Customer, error) {
-	query := `SELECT id, name, email, phone, status, created_at, updated_at FROM customers LIMIT $1 OFFSET $2`
-	rows, err := m.Conn.QueryContext(ctx, query, limit, offset)
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT id, name, email, phone, status, created_at, updated_at FROM customers WHERE tenant_id = $1 AND deleted_at IS NULL LIMIT $2 OFFSET $3`
+	rows, err := conn(ctx, m.Conn).QueryContext(ctx, query, tenant, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -34,27 +45,140 @@ func (m *customerRepository) Fetch(ctx context.Context, limit int, offset int) (
 }
 
 func (m *customerRepository) GetByID(ctx context.Context, id int64) (domain.Customer, error) {
-	query := `SELECT id, name, email, phone, status, created_at, updated_at FROM customers WHERE id = $1`
-	row := m.Conn.QueryRowContext(ctx, query, id)
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return domain.Customer{}, err
+	}
+
+	query := `SELECT id, name, email, phone, status, created_at, updated_at FROM customers WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL`
+	row := conn(ctx, m.Conn).QueryRowContext(ctx, query, id, tenant)
 
 	var c domain.Customer
-	err := row.Scan(&c.ID, &c.Name, &c.Email, &c.Phone, &c.Status, &c.CreatedAt, &c.UpdatedAt)
+	err = row.Scan(&c.ID, &c.Name, &c.Email, &c.Phone, &c.Status, &c.CreatedAt, &c.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return domain.Customer{}, domain.ErrCustomerNotFound
+	}
 	return c, err
 }
 
 func (m *customerRepository) Store(ctx context.Context, c *domain.Customer) error {
-	query := `INSERT INTO customers (name, email, phone, status, created_at, updated_at) VALUES ($1, $2, $3, $4, NOW(), NOW()) RETURNING id`
-	return m.Conn.QueryRowContext(ctx, query, c.Name, c.Email, c.Phone, c.Status).Scan(&c.ID)
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO customers (tenant_id, name, email, phone, status, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		RETURNING id, created_at, updated_at`
+	err = conn(ctx, m.Conn).QueryRowContext(ctx, query, tenant, c.Name, c.Email, c.Phone, c.Status).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+	return customerError(err)
 }
 
-func (m *customerRepository) Update(ctx context.Context, c *domain.Customer) error {
-	query := `UPDATE customers SET name=$1, email=$2, phone=$3, status=$4, updated_at=NOW() WHERE id=$5`
-	_, err := m.Conn.ExecContext(ctx, query, c.Name, c.Email, c.Phone, c.Status, c.ID)
+// customerError reports a taken email as ErrCustomerEmailTaken
+func customerError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return domain.ErrCustomerEmailTaken
+	}
 	return err
 }
 
+func (m *customerRepository) Update(ctx context.Context, c *domain.Customer) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE customers SET name=$1, email=$2, phone=$3, status=$4, updated_at=NOW() WHERE id=$5 AND tenant_id=$6 AND deleted_at IS NULL
+		RETURNING created_at, updated_at`
+	err = conn(ctx, m.Conn).QueryRowContext(ctx, query, c.Name, c.Email, c.Phone, c.Status, c.ID, tenant).Scan(&c.CreatedAt, &c.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return domain.ErrCustomerNotFound
+	}
+	return customerError(err)
+}
+
 func (m *customerRepository) Delete(ctx context.Context, id int64) error {
-	query := `DELETE FROM customers WHERE id = $1`
-	_, err := m.Conn.ExecContext(ctx, query, id)
-	return err
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Move the row to the trash and leave a tombstone behind for the change feed
+	query := `WITH deleted AS (UPDATE customers SET deleted_at = NOW() WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL RETURNING id, tenant_id)
+		INSERT INTO tombstones (tenant_id, entity, entity_id, deleted_at) SELECT tenant_id, 'customer', id, NOW() FROM deleted`
+	res, err := conn(ctx, m.Conn).ExecContext(ctx, query, id, tenant)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return domain.ErrCustomerNotFound
+	}
+	return nil
+}
+
+func (m *customerRepository) FetchTrashed(ctx context.Context, limit int, offset int) ([]domain.Customer, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT id, name, email, phone, status, created_at, updated_at, deleted_at FROM customers WHERE tenant_id = $1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC, id LIMIT $2 OFFSET $3`
+	rows, err := conn(ctx, m.Conn).QueryContext(ctx, query, tenant, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var customers []domain.Customer
+	for rows.Next() {
+		var c domain.Customer
+		if err := rows.Scan(&c.ID, &c.Name, &c.Email, &c.Phone, &c.Status, &c.CreatedAt, &c.UpdatedAt, &c.DeletedAt); err != nil {
+			return nil, err
+		}
+		customers = append(customers, c)
+	}
+	return customers, rows.Err()
+}
+
+func (m *customerRepository) GetTrashed(ctx context.Context, id int64) (domain.Customer, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return domain.Customer{}, err
+	}
+
+	query := `SELECT id, name, email, phone, status, created_at, updated_at, deleted_at FROM customers WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NOT NULL`
+	var c domain.Customer
+	err = conn(ctx, m.Conn).QueryRowContext(ctx, query, id, tenant).Scan(&c.ID, &c.Name, &c.Email, &c.Phone, &c.Status, &c.CreatedAt, &c.UpdatedAt, &c.DeletedAt)
+	if err == sql.ErrNoRows {
+		return domain.Customer{}, domain.ErrNotInTrash
+	}
+	return c, err
+}
+
+// Restore takes id out of the trash. updated_at moves forward so change feed
+// clients that saw the tombstone pick the row up again.
+func (m *customerRepository) Restore(ctx context.Context, id int64) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE customers SET deleted_at = NULL, updated_at = NOW() WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NOT NULL`
+	res, err := conn(ctx, m.Conn).ExecContext(ctx, query, id, tenant)
+	if err != nil {
+		return customerError(err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return domain.ErrNotInTrash
+	}
+	return nil
+}
+
+// Purge runs from the worker and is not tenant scoped
+func (m *customerRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	res, err := conn(ctx, m.Conn).ExecContext(ctx, `DELETE FROM customers WHERE deleted_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
 }
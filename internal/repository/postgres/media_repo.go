@@ -0,0 +1,242 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"nusatek-backend/internal/domain"
+)
+
+type mediaRepository struct {
+	Conn *sql.DB
+}
+
+func NewMediaRepository(Conn *sql.DB) domain.MediaRepository {
+	return &mediaRepository{Conn}
+}
+
+const mediaColumns = `id, property_id, storage_key, url, COALESCE(filename, ''), content_type, size, position, is_cover,
+	status, COALESCE(width, 0), COALESCE(height, 0), COALESCE(phash, 0), variants, created_at`
+
+func scanMedia(row rowScanner) (domain.PropertyMedia, error) {
+	var m domain.PropertyMedia
+	var hash int64
+	var variants []byte
+	err := row.Scan(&m.ID, &m.PropertyID, &m.Key, &m.URL, &m.Filename, &m.ContentType, &m.Size, &m.Position, &m.IsCover,
+		&m.Status, &m.Width, &m.Height, &hash, &variants, &m.CreatedAt)
+	if err != nil {
+		return m, err
+	}
+	// The hash is stored as a signed BIGINT with the same bits
+	m.Hash = domain.PerceptualHash(hash)
+	if len(variants) > 0 {
+		err = json.Unmarshal(variants, &m.Variants)
+	}
+	return m, err
+}
+
+func (m *mediaRepository) Fetch(ctx context.Context, propertyID int64) ([]domain.PropertyMedia, error) {
+	media, err := m.FetchByProperties(ctx, []int64{propertyID})
+	if err != nil {
+		return nil, err
+	}
+	return media[propertyID], nil
+}
+
+func (m *mediaRepository) FetchByProperties(ctx context.Context, propertyIDs []int64) (map[int64][]domain.PropertyMedia, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + mediaColumns + ` FROM property_media
+		WHERE property_id = ANY($1) AND tenant_id = $2 ORDER BY property_id, position, id`
+	rows, err := conn(ctx, m.Conn).QueryContext(ctx, query, pq.Array(propertyIDs), tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	media := make(map[int64][]domain.PropertyMedia)
+	for rows.Next() {
+		md, err := scanMedia(rows)
+		if err != nil {
+			return nil, err
+		}
+		media[md.PropertyID] = append(media[md.PropertyID], md)
+	}
+	return media, rows.Err()
+}
+
+func (m *mediaRepository) FetchByKey(ctx context.Context, key string) ([]domain.PropertyMedia, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stem := key
+	if i := strings.LastIndexByte(key, '_'); i > strings.LastIndexByte(key, '/') {
+		stem = key[:i]
+	}
+	query := `SELECT ` + mediaColumns + ` FROM property_media
+		WHERE tenant_id = $1 AND (storage_key = $2 OR regexp_replace(storage_key, '\.[^./]*$', '') = $3) ORDER BY id`
+	rows, err := conn(ctx, m.Conn).QueryContext(ctx, query, tenant, key, stem)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var media []domain.PropertyMedia
+	for rows.Next() {
+		md, err := scanMedia(rows)
+		if err != nil {
+			return nil, err
+		}
+		media = append(media, md)
+	}
+	return media, rows.Err()
+}
+
+// FetchPurgeable runs from the worker and is not tenant scoped
+func (m *mediaRepository) FetchPurgeable(ctx context.Context, before time.Time) ([]domain.PropertyMedia, error) {
+	query := `SELECT ` + mediaColumns + ` FROM property_media
+		WHERE property_id IN (SELECT id FROM properties WHERE deleted_at < $1) ORDER BY id`
+	rows, err := conn(ctx, m.Conn).QueryContext(ctx, query, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var media []domain.PropertyMedia
+	for rows.Next() {
+		md, err := scanMedia(rows)
+		if err != nil {
+			return nil, err
+		}
+		media = append(media, md)
+	}
+	return media, rows.Err()
+}
+
+func (m *mediaRepository) Get(ctx context.Context, propertyID int64, id int64) (domain.PropertyMedia, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return domain.PropertyMedia{}, err
+	}
+
+	query := `SELECT ` + mediaColumns + ` FROM property_media WHERE id = $1 AND property_id = $2 AND tenant_id = $3`
+	md, err := scanMedia(conn(ctx, m.Conn).QueryRowContext(ctx, query, id, propertyID, tenant))
+	if err == sql.ErrNoRows {
+		return domain.PropertyMedia{}, domain.ErrMediaNotFound
+	}
+	return md, err
+}
+
+func (m *mediaRepository) Store(ctx context.Context, md *domain.PropertyMedia) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO property_media (tenant_id, property_id, storage_key, url, filename, content_type, size, status, position, is_cover, created_at)
+		SELECT $1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, COALESCE(MAX(position), 0) + 1, COUNT(*) FILTER (WHERE is_cover) = 0, NOW()
+		FROM property_media WHERE property_id = $2
+		RETURNING id, position, is_cover, created_at`
+	// Concurrent first uploads can both claim the cover; the unique index
+	// rejects all but one and the others try again as ordinary photos
+	return retryUnique(ctx, m.Conn, 4, func(q dbtx) error {
+		return q.QueryRowContext(ctx, query, tenant, md.PropertyID, md.Key, md.URL, md.Filename, md.ContentType, md.Size, md.Status).
+			Scan(&md.ID, &md.Position, &md.IsCover, &md.CreatedAt)
+	})
+}
+
+func (m *mediaRepository) Delete(ctx context.Context, propertyID int64, id int64) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	return withinTx(ctx, m.Conn, func(tx *sql.Tx) error {
+		var wasCover bool
+		err := tx.QueryRowContext(ctx, `DELETE FROM property_media WHERE id = $1 AND property_id = $2 AND tenant_id = $3 RETURNING is_cover`,
+			id, propertyID, tenant).Scan(&wasCover)
+		if err == sql.ErrNoRows {
+			return domain.ErrMediaNotFound
+		}
+		if err != nil || !wasCover {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE property_media SET is_cover = TRUE
+			WHERE id = (SELECT id FROM property_media WHERE property_id = $1 AND tenant_id = $2 ORDER BY position, id LIMIT 1)`,
+			propertyID, tenant)
+		return err
+	})
+}
+
+func (m *mediaRepository) Reorder(ctx context.Context, propertyID int64, ids []int64) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE property_media m SET position = o.n
+		FROM unnest($1::bigint[]) WITH ORDINALITY AS o(id, n)
+		WHERE m.id = o.id AND m.property_id = $2 AND m.tenant_id = $3`
+	_, err = conn(ctx, m.Conn).ExecContext(ctx, query, pq.Array(ids), propertyID, tenant)
+	return err
+}
+
+func (m *mediaRepository) SetCover(ctx context.Context, propertyID int64, id int64) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	return withinTx(ctx, m.Conn, func(tx *sql.Tx) error {
+		// The old cover is cleared first as at most one may be set at any time
+		if _, err := tx.ExecContext(ctx, `UPDATE property_media SET is_cover = FALSE WHERE property_id = $1 AND tenant_id = $2 AND is_cover`,
+			propertyID, tenant); err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, `UPDATE property_media SET is_cover = TRUE WHERE id = $1 AND property_id = $2 AND tenant_id = $3`,
+			id, propertyID, tenant)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return domain.ErrMediaNotFound
+		}
+		return nil
+	})
+}
+
+func (m *mediaRepository) UpdateProcessed(ctx context.Context, md domain.PropertyMedia) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	var variants []byte
+	if len(md.Variants) > 0 {
+		if variants, err = json.Marshal(md.Variants); err != nil {
+			return err
+		}
+	}
+	query := `UPDATE property_media SET status = $1, size = $2, width = NULLIF($3, 0), height = NULLIF($4, 0),
+		phash = NULLIF($5, 0), variants = $6, processed_at = NOW()
+		WHERE id = $7 AND property_id = $8 AND tenant_id = $9`
+	res, err := conn(ctx, m.Conn).ExecContext(ctx, query, md.Status, md.Size, md.Width, md.Height, int64(md.Hash), nullJSON(variants),
+		md.ID, md.PropertyID, tenant)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return domain.ErrMediaNotFound
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"nusatek-backend/internal/domain"
+)
+
+type permissionRepository struct {
+	Conn *sql.DB
+}
+
+func NewPermissionRepository(Conn *sql.DB) domain.PermissionRepository {
+	return &permissionRepository{Conn}
+}
+
+func (m *permissionRepository) FetchGrants(ctx context.Context, role string) ([]domain.Grant, error) {
+	query := `SELECT permission, scope FROM role_permissions WHERE role = $1`
+	rows, err := m.Conn.QueryContext(ctx, query, role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var grants []domain.Grant
+	for rows.Next() {
+		var g domain.Grant
+		if err := rows.Scan(&g.Permission, &g.Scope); err != nil {
+			return nil, err
+		}
+		grants = append(grants, g)
+	}
+	return grants, rows.Err()
+}
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"nusatek-backend/internal/domain"
+)
+
+type priceHistoryRepository struct {
+	Conn *sql.DB
+}
+
+func NewPriceHistoryRepository(Conn *sql.DB) domain.PriceHistoryRepository {
+	return &priceHistoryRepository{Conn}
+}
+
+func (m *priceHistoryRepository) Store(ctx context.Context, c *domain.PriceChange) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	// A change of currency is recorded without an old price as the two do not compare
+	var old interface{}
+	if c.OldPrice != nil && c.OldPrice.Currency == c.NewPrice.Currency {
+		old = *c.OldPrice
+	}
+	query := `INSERT INTO price_history (tenant_id, property_id, old_price, new_price, currency, actor_id, changed_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), NOW()) RETURNING id, changed_at`
+	return conn(ctx, m.Conn).QueryRowContext(ctx, query, tenant, c.PropertyID, old, c.NewPrice, c.NewPrice.Currency, c.ActorID).Scan(&c.ID, &c.ChangedAt)
+}
+
+func (m *priceHistoryRepository) Fetch(ctx context.Context, propertyID int64, limit int, offset int) ([]domain.PriceChange, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT id, property_id, old_price, new_price, currency, COALESCE(actor_id, 0), changed_at FROM price_history
+		WHERE property_id = $1 AND tenant_id = $2 ORDER BY changed_at DESC, id DESC LIMIT $3 OFFSET $4`
+	rows, err := conn(ctx, m.Conn).QueryContext(ctx, query, propertyID, tenant, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []domain.PriceChange
+	for rows.Next() {
+		var c domain.PriceChange
+		if err := rows.Scan(&c.ID, &c.PropertyID, &c.OldPrice, &c.NewPrice, &c.NewPrice.Currency, &c.ActorID, &c.ChangedAt); err != nil {
+			return nil, err
+		}
+		if c.OldPrice != nil {
+			c.OldPrice.Currency = c.NewPrice.Currency
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+func (m *priceHistoryRepository) Summary(ctx context.Context, currency string, since time.Time) (domain.PriceChangeSummary, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return domain.PriceChangeSummary{}, err
+	}
+
+	// Initial listing prices have no old price and are not changes
+	query := `SELECT COUNT(*),
+			COUNT(*) FILTER (WHERE new_price < old_price),
+			COUNT(*) FILTER (WHERE new_price > old_price),
+			COALESCE(AVG(new_price - old_price), 0),
+			COALESCE(AVG((new_price - old_price) / NULLIF(old_price, 0) * 100), 0)
+		FROM price_history WHERE tenant_id = $1 AND changed_at >= $2 AND currency = $3 AND old_price IS NOT NULL`
+	s := domain.PriceChangeSummary{Since: since, AverageChange: domain.NewMoney(0, currency)}
+	err = conn(ctx, m.Conn).QueryRowContext(ctx, query, tenant, since, currency).Scan(&s.Changes, &s.Reductions, &s.Increases, &s.AverageChange, &s.AverageChangePct)
+	return s, err
+}
+
+func (m *priceHistoryRepository) FetchStale(ctx context.Context, before time.Time, limit int, offset int) ([]domain.StaleListing, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT p.id, p.title, p.price, p.currency, p.created_at, r.last_reduction_at
+		FROM properties p
+		LEFT JOIN LATERAL (
+			SELECT MAX(changed_at) AS last_reduction_at FROM price_history h WHERE h.property_id = p.id AND h.new_price < h.old_price
+		) r ON true
+		WHERE p.tenant_id = $1 AND p.deleted_at IS NULL AND COALESCE(r.last_reduction_at, p.created_at) < $2
+		ORDER BY COALESCE(r.last_reduction_at, p.created_at), p.id
+		LIMIT $3 OFFSET $4`
+	rows, err := conn(ctx, m.Conn).QueryContext(ctx, query, tenant, before, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var listings []domain.StaleListing
+	for rows.Next() {
+		var l domain.StaleListing
+		if err := rows.Scan(&l.PropertyID, &l.Title, &l.Price, &l.Price.Currency, &l.ListedAt, &l.LastReductionAt); err != nil {
+			return nil, err
+		}
+		listings = append(listings, l)
+	}
+	return listings, rows.Err()
+}
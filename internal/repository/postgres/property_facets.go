@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+
+	"nusatek-backend/internal/domain"
+)
+
+func (m *propertyRepository) Facets(ctx context.Context, f domain.PropertyFilter) (domain.PropertyFacets, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return domain.PropertyFacets{}, err
+	}
+
+	var facets domain.PropertyFacets
+	cond := propertyConditions(tenant, f)
+	if err := m.Conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM properties p`+cond.where(), cond.args...).Scan(&facets.Total); err != nil {
+		return domain.PropertyFacets{}, err
+	}
+
+	byType := f
+	byType.PropertyTypes = nil
+	cond = propertyConditions(tenant, byType)
+	facets.PropertyTypes, err = m.facetCounts(ctx, `SELECT COALESCE(p.property_type, ''), '', COUNT(*) FROM properties p`+cond.where()+
+		` GROUP BY 1 ORDER BY COUNT(*) DESC, 1`, cond.args)
+	if err != nil {
+		return domain.PropertyFacets{}, err
+	}
+
+	cond = propertyConditions(tenant, f)
+	facets.Amenities, err = m.facetCounts(ctx, `SELECT a.code, a.name, COUNT(*)
+		FROM properties p JOIN property_amenities pa ON pa.property_id = p.id JOIN amenities a ON a.id = pa.amenity_id`+cond.where()+
+		` GROUP BY a.code, a.name ORDER BY COUNT(*) DESC, a.code`, cond.args)
+	if err != nil {
+		return domain.PropertyFacets{}, err
+	}
+
+	facets.Prices, err = m.priceBuckets(ctx, tenant, f)
+	if err != nil {
+		return domain.PropertyFacets{}, err
+	}
+	return facets, nil
+}
+
+func (m *propertyRepository) facetCounts(ctx context.Context, query string, args []interface{}) ([]domain.FacetCount, error) {
+	rows, err := m.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []domain.FacetCount{}
+	for rows.Next() {
+		var fc domain.FacetCount
+		if err := rows.Scan(&fc.Value, &fc.Name, &fc.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, fc)
+	}
+	return counts, rows.Err()
+}
+
+// priceBuckets counts the properties priced in the default currency in each
+// bucket with one pass over the matching rows. Without a listing type filter
+// every listing type is counted in its own buckets.
+func (m *propertyRepository) priceBuckets(ctx context.Context, tenant int64, f domain.PropertyFilter) ([]domain.PriceBucket, error) {
+	f.MinPrice, f.MaxPrice = nil, nil
+	cond := propertyConditions(tenant, f)
+	cond.add("p.currency = %s", domain.DefaultCurrency)
+
+	buckets := domain.PriceBuckets(f.ListingType)
+	counts := make([]string, len(buckets))
+	dest := make([]interface{}, len(buckets))
+	for i, b := range buckets {
+		clauses := []string{"p.listing_type = " + cond.next(b.ListingType)}
+		if b.Min != nil {
+			clauses = append(clauses, "p.price >= "+cond.next(*b.Min))
+		}
+		if b.Max != nil {
+			clauses = append(clauses, "p.price < "+cond.next(*b.Max))
+		}
+		counts[i] = "COUNT(*) FILTER (WHERE " + strings.Join(clauses, " AND ") + ")"
+		dest[i] = &buckets[i].Count
+	}
+
+	query := `SELECT ` + strings.Join(counts, ", ") + ` FROM properties p` + cond.where()
+	if err := m.Conn.QueryRowContext(ctx, query, cond.args...).Scan(dest...); err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"nusatek-backend/internal/domain"
+)
+
+// conditions collects SQL predicates and their arguments
+type conditions struct {
+	clauses []string
+	args    []interface{}
+}
+
+// add appends a predicate written with %s in place of its placeholder
+func (c *conditions) add(predicate string, arg interface{}) {
+	c.args = append(c.args, arg)
+	c.clauses = append(c.clauses, strings.Replace(predicate, "%s", "$"+strconv.Itoa(len(c.args)), 1))
+}
+
+func (c *conditions) where() string {
+	return " WHERE " + strings.Join(c.clauses, " AND ")
+}
+
+// next returns the placeholder for an argument appended after the conditions
+func (c *conditions) next(arg interface{}) string {
+	c.args = append(c.args, arg)
+	return "$" + strconv.Itoa(len(c.args))
+}
+
+// propertyConditions selects the live properties of tenant matching f. The
+// properties table must be aliased as p.
+func propertyConditions(tenant int64, f domain.PropertyFilter) *conditions {
+	c := &conditions{}
+	c.add("p.tenant_id = %s", tenant)
+	c.clauses = append(c.clauses, "p.deleted_at IS NULL")
+
+	if len(f.Statuses) > 0 {
+		c.add("p.status = ANY(%s)", pq.Array(f.Statuses))
+	}
+	if len(f.PropertyTypes) > 0 {
+		c.add("p.property_type = ANY(%s)", pq.Array(f.PropertyTypes))
+	}
+	if f.ListingType != "" {
+		c.add("p.listing_type = %s", f.ListingType)
+	}
+	if len(f.Certificates) > 0 {
+		c.add("p.certificate = ANY(%s)", pq.Array(f.Certificates))
+	}
+	if len(f.Furnishings) > 0 {
+		c.add("p.furnishing = ANY(%s)", pq.Array(f.Furnishings))
+	}
+	regions := []struct {
+		column string
+		code   string
+	}{
+		{"p.province_code", f.ProvinceCode},
+		{"p.regency_code", f.RegencyCode},
+		{"p.district_code", f.DistrictCode},
+		{"p.village_code", f.VillageCode},
+	}
+	for _, r := range regions {
+		if r.code != "" {
+			c.add(r.column+" = %s", r.code)
+		}
+	}
+
+	intRanges := []struct {
+		column string
+		op     string
+		value  int
+	}{
+		{"p.bedrooms", ">=", f.MinBedrooms},
+		{"p.bedrooms", "<=", f.MaxBedrooms},
+		{"p.bathrooms", ">=", f.MinBathrooms},
+		{"p.floors", ">=", f.MinFloors},
+		{"p.floors", "<=", f.MaxFloors},
+		{"p.year_built", ">=", f.MinYearBuilt},
+		{"p.year_built", "<=", f.MaxYearBuilt},
+	}
+	for _, r := range intRanges {
+		if r.value > 0 {
+			c.add(r.column+" "+r.op+" %s", r.value)
+		}
+	}
+
+	areaRanges := []struct {
+		column string
+		op     string
+		value  float64
+	}{
+		{"p.land_area", ">=", f.MinLandArea},
+		{"p.land_area", "<=", f.MaxLandArea},
+		{"p.building_area", ">=", f.MinBuildingArea},
+		{"p.building_area", "<=", f.MaxBuildingArea},
+	}
+	for _, r := range areaRanges {
+		if r.value > 0 {
+			c.add(r.column+" "+r.op+" %s", r.value)
+		}
+	}
+
+	if codes := distinct(f.Amenities); len(codes) > 0 {
+		// The property must have every amenity listed
+		c.add(`(SELECT COUNT(*) FROM property_amenities pa JOIN amenities a ON a.id = pa.amenity_id
+			WHERE pa.property_id = p.id AND a.code = ANY(%s)) = `+strconv.Itoa(len(codes)), pq.Array(codes))
+	}
+
+	if f.MinPrice != nil {
+		c.add("p.currency = %s", f.MinPrice.Currency)
+		c.add("p.price >= %s", *f.MinPrice)
+	}
+	if f.MaxPrice != nil {
+		c.add("p.currency = %s", f.MaxPrice.Currency)
+		c.add("p.price <= %s", *f.MaxPrice)
+	}
+	return c
+}
+
+func distinct(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var out []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -3,9 +3,13 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"strings"
+	"time"
+
 	"nusatek-backend/internal/domain"
 )
 
+// propertyRepository scopes every query to the tenant in ctx
 type propertyRepository struct {
 	Conn *sql.DB
 }
@@ -14,9 +18,65 @@ func NewPropertyRepository(Conn *sql.DB) domain.PropertyRepository {
 	return &propertyRepository{Conn}
 }
 
-func (m *propertyRepository) Fetch(ctx context.Context, limit int, offset int) ([]domain.Property, error) {
-	query := `SELECT id, title, description, address, price, created_at, updated_at FROM properties LIMIT $1 OFFSET $2`
-	rows, err := m.Conn.QueryContext(ctx, query, limit, offset)
+const propertyColumns = `id, title, description, COALESCE(address, ''), COALESCE(rt, ''), COALESCE(rw, ''),
+	COALESCE(village_code, ''), COALESCE(village, ''), COALESCE(district_code, ''), COALESCE(district, ''),
+	COALESCE(regency_code, ''), COALESCE(regency, ''), COALESCE(province_code, ''), COALESCE(province, ''),
+	COALESCE(postal_code, ''), price, currency,
+	COALESCE(property_type, ''), COALESCE(listing_type, ''), COALESCE(bedrooms, 0), COALESCE(bathrooms, 0),
+	COALESCE(land_area, 0), COALESCE(building_area, 0), COALESCE(floors, 0), COALESCE(certificate, ''),
+	COALESCE(furnishing, ''), COALESCE(year_built, 0), latitude, longitude,
+	geocode_confidence, COALESCE(geocode_provider, ''), COALESCE(geocoded_address, ''), geocoded_at,
+	COALESCE(agent_id, 0), COALESCE(branch_id, 0), status,
+	created_at, updated_at, deleted_at, published_at, reserved_at, sold_at, rented_at, archived_at, COALESCE(merged_into, 0)`
+
+// scanProperty reads propertyColumns followed by any extra columns selected
+func scanProperty(row rowScanner, extra ...interface{}) (domain.Property, error) {
+	var p domain.Property
+	var lat, lng, confidence sql.NullFloat64
+	var geocodedAt sql.NullTime
+	var g domain.Geocode
+	a := &p.Address
+	dest := []interface{}{&p.ID, &p.Title, &p.Description, &a.Street, &a.RT, &a.RW,
+		&a.VillageCode, &a.Village, &a.DistrictCode, &a.District,
+		&a.RegencyCode, &a.Regency, &a.ProvinceCode, &a.Province,
+		&a.PostalCode, &p.Price, &p.Price.Currency,
+		&p.PropertyType, &p.ListingType, &p.Bedrooms, &p.Bathrooms,
+		&p.LandArea, &p.BuildingArea, &p.Floors, &p.Certificate,
+		&p.Furnishing, &p.YearBuilt, &lat, &lng,
+		&confidence, &g.Provider, &g.Address, &geocodedAt,
+		&p.AgentID, &p.BranchID, &p.Status,
+		&p.CreatedAt, &p.UpdatedAt, &p.DeletedAt, &p.PublishedAt, &p.ReservedAt, &p.SoldAt, &p.RentedAt, &p.ArchivedAt, &p.MergedInto}
+	err := row.Scan(append(dest, extra...)...)
+	if lat.Valid && lng.Valid {
+		p.Location = &domain.GeoPoint{Lat: lat.Float64, Lng: lng.Float64}
+	}
+	if geocodedAt.Valid {
+		g.Confidence, g.GeocodedAt = confidence.Float64, geocodedAt.Time
+		p.Geocode = &g
+	}
+	return p, err
+}
+
+// statusColumns holds the column stamped when a property enters each status
+var statusColumns = map[string]string{
+	domain.PropertyPublished: "published_at",
+	domain.PropertyReserved:  "reserved_at",
+	domain.PropertySold:      "sold_at",
+	domain.PropertyRented:    "rented_at",
+	domain.PropertyArchived:  "archived_at",
+}
+
+func (m *propertyRepository) Fetch(ctx context.Context, f domain.PropertyFilter) ([]domain.Property, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cond := propertyConditions(tenant, f)
+	query := `SELECT ` + propertyColumns + ` FROM properties p` + cond.where() +
+		` ORDER BY p.id LIMIT ` + cond.next(f.Limit) + ` OFFSET ` + cond.next(f.Offset)
+
+	rows, err := conn(ctx, m.Conn).QueryContext(ctx, query, cond.args...)
 	if err != nil {
 		return nil, err
 	}
@@ -24,37 +84,395 @@ func (m *propertyRepository) Fetch(ctx context.Context, limit int, offset int) (
 
 	var properties []domain.Property
 	for rows.Next() {
-		var p domain.Property
-		if err := rows.Scan(&p.ID, &p.Title, &p.Description, &p.Address, &p.Price, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		p, err := scanProperty(rows)
+		if err != nil {
 			return nil, err
 		}
 		properties = append(properties, p)
 	}
-	return properties, nil
+	return properties, rows.Err()
+}
+
+// FetchNearby uses the earthdistance extension: earth_box narrows the
+// candidates through the GiST index on ll_to_earth and earth_distance then
+// applies the exact radius.
+func (m *propertyRepository) FetchNearby(ctx context.Context, f domain.PropertyFilter, q domain.GeoQuery) ([]domain.NearbyProperty, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query, args := nearbyQuery(tenant, f, q)
+	rows, err := conn(ctx, m.Conn).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var properties []domain.NearbyProperty
+	for rows.Next() {
+		var n domain.NearbyProperty
+		n.Property, err = scanProperty(rows, &n.DistanceKm)
+		if err != nil {
+			return nil, err
+		}
+		properties = append(properties, n)
+	}
+	return properties, rows.Err()
+}
+
+// nearbyQuery selects the properties matching f within q, nearest first
+func nearbyQuery(tenant int64, f domain.PropertyFilter, q domain.GeoQuery) (string, []interface{}) {
+	cond := propertyConditions(tenant, f)
+	cond.clauses = append(cond.clauses, "p.latitude IS NOT NULL", "p.longitude IS NOT NULL")
+	center := q.Center
+	if q.Box != nil {
+		b := *q.Box
+		center = b.Center()
+		south, north := cond.next(b.South), cond.next(b.North)
+		west, east := cond.next(b.West), cond.next(b.East)
+		// A box across the antimeridian wraps from West to 180 and on from -180 to East
+		join := " AND "
+		if b.West > b.East {
+			join = " OR "
+		}
+		cond.clauses = append(cond.clauses,
+			"p.latitude BETWEEN "+south+" AND "+north,
+			"(p.longitude >= "+west+join+"p.longitude <= "+east+")")
+	}
+
+	lat, lng := cond.next(center.Lat), cond.next(center.Lng)
+	origin := "ll_to_earth(" + lat + ", " + lng + ")"
+	distance := "earth_distance(ll_to_earth(p.latitude, p.longitude), " + origin + ")"
+	if q.Box == nil {
+		radius := cond.next(q.RadiusKm * 1000)
+		cond.clauses = append(cond.clauses,
+			"earth_box("+origin+", "+radius+") @> ll_to_earth(p.latitude, p.longitude)",
+			distance+" <= "+radius)
+	}
+
+	query := `SELECT ` + propertyColumns + `, ` + distance + ` / 1000 AS distance_km FROM properties p` + cond.where() +
+		` ORDER BY distance_km, p.id LIMIT ` + cond.next(f.Limit) + ` OFFSET ` + cond.next(f.Offset)
+	return query, cond.args
 }
 
 func (m *propertyRepository) GetByID(ctx context.Context, id int64) (domain.Property, error) {
-	query := `SELECT id, title, description, address, price, created_at, updated_at FROM properties WHERE id = $1`
-	row := m.Conn.QueryRowContext(ctx, query, id)
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return domain.Property{}, err
+	}
 
-	var p domain.Property
-	err := row.Scan(&p.ID, &p.Title, &p.Description, &p.Address, &p.Price, &p.CreatedAt, &p.UpdatedAt)
+	query := `SELECT ` + propertyColumns + ` FROM properties WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL`
+	p, err := scanProperty(conn(ctx, m.Conn).QueryRowContext(ctx, query, id, tenant))
+	if err == sql.ErrNoRows {
+		return domain.Property{}, domain.ErrPropertyNotFound
+	}
 	return p, err
 }
 
 func (m *propertyRepository) Store(ctx context.Context, p *domain.Property) error {
-	query := `INSERT INTO properties (title, description, address, price, created_at, updated_at) VALUES ($1, $2, $3, $4, NOW(), NOW()) RETURNING id`
-	return m.Conn.QueryRowContext(ctx, query, p.Title, p.Description, p.Address, p.Price).Scan(&p.ID)
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	a := p.Address
+	query := `INSERT INTO properties (tenant_id, title, description, address, rt, rw,
+			village_code, village, district_code, district, regency_code, regency, province_code, province, postal_code, price, currency,
+			property_type, listing_type, bedrooms, bathrooms, land_area, building_area, floors, certificate, furnishing, year_built,
+			latitude, longitude, agent_id, branch_id, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''),
+			NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''), NULLIF($10, ''), NULLIF($11, ''), NULLIF($12, ''), NULLIF($13, ''), NULLIF($14, ''), NULLIF($15, ''), $16, $17,
+			$18, $19, NULLIF($20, 0), NULLIF($21, 0), NULLIF($22::numeric, 0), NULLIF($23::numeric, 0), NULLIF($24, 0), NULLIF($25, ''), NULLIF($26, ''), NULLIF($27, 0),
+			$28, $29, NULLIF($30, 0), NULLIF($31, 0), $32, NOW(), NOW()) RETURNING ` + propertyColumns
+	lat, lng := locationArgs(p.Location)
+	stored, err := scanProperty(conn(ctx, m.Conn).QueryRowContext(ctx, query, tenant, p.Title, p.Description, a.Street, a.RT, a.RW,
+		a.VillageCode, a.Village, a.DistrictCode, a.District, a.RegencyCode, a.Regency, a.ProvinceCode, a.Province, a.PostalCode, p.Price, p.Price.Currency,
+		p.PropertyType, p.ListingType, p.Bedrooms, p.Bathrooms, p.LandArea, p.BuildingArea, p.Floors, p.Certificate, p.Furnishing, p.YearBuilt,
+		lat, lng, p.AgentID, p.BranchID, p.Status))
+	if err != nil {
+		return err
+	}
+	stored.Media, stored.Amenities = p.Media, p.Amenities
+	*p = stored
+	return nil
 }
 
 func (m *propertyRepository) Update(ctx context.Context, p *domain.Property) error {
-	query := `UPDATE properties SET title=$1, description=$2, address=$3, price=$4, updated_at=NOW() WHERE id=$5`
-	_, err := m.Conn.ExecContext(ctx, query, p.Title, p.Description, p.Address, p.Price, p.ID)
-	return err
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	a := p.Address
+	query := `UPDATE properties SET title=$1, description=$2, address=$3, rt=NULLIF($4, ''), rw=NULLIF($5, ''),
+			village_code=NULLIF($6, ''), village=NULLIF($7, ''), district_code=NULLIF($8, ''), district=NULLIF($9, ''),
+			regency_code=NULLIF($10, ''), regency=NULLIF($11, ''), province_code=NULLIF($12, ''), province=NULLIF($13, ''),
+			postal_code=NULLIF($14, ''), price=$15, currency=$16,
+			property_type=$17, listing_type=$18, bedrooms=NULLIF($19, 0), bathrooms=NULLIF($20, 0), land_area=NULLIF($21::numeric, 0),
+			building_area=NULLIF($22::numeric, 0), floors=NULLIF($23, 0), certificate=NULLIF($24, ''), furnishing=NULLIF($25, ''),
+			year_built=NULLIF($26, 0), latitude=$27, longitude=$28,
+			geocode_confidence=$29, geocode_provider=$30, geocoded_address=$31, geocoded_at=$32, updated_at=NOW()
+		WHERE id=$33 AND tenant_id=$34 AND deleted_at IS NULL RETURNING ` + propertyColumns
+	lat, lng := locationArgs(p.Location)
+	confidence, provider, geocoded, geocodedAt := geocodeArgs(p.Geocode)
+	stored, err := scanProperty(conn(ctx, m.Conn).QueryRowContext(ctx, query, p.Title, p.Description, a.Street, a.RT, a.RW,
+		a.VillageCode, a.Village, a.DistrictCode, a.District,
+		a.RegencyCode, a.Regency, a.ProvinceCode, a.Province,
+		a.PostalCode, p.Price, p.Price.Currency,
+		p.PropertyType, p.ListingType, p.Bedrooms, p.Bathrooms, p.LandArea,
+		p.BuildingArea, p.Floors, p.Certificate, p.Furnishing,
+		p.YearBuilt, lat, lng,
+		confidence, provider, geocoded, geocodedAt, p.ID, tenant))
+	if err == sql.ErrNoRows {
+		return domain.ErrPropertyNotFound
+	}
+	if err != nil {
+		return err
+	}
+	stored.Media, stored.Amenities = p.Media, p.Amenities
+	*p = stored
+	return nil
 }
 
 func (m *propertyRepository) Delete(ctx context.Context, id int64) error {
-	query := `DELETE FROM properties WHERE id = $1`
-	_, err := m.Conn.ExecContext(ctx, query, id)
-	return err
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Move the row to the trash and leave a tombstone behind for the change feed
+	query := `WITH deleted AS (UPDATE properties SET deleted_at = NOW() WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL RETURNING id, tenant_id)
+		INSERT INTO tombstones (tenant_id, entity, entity_id, deleted_at) SELECT tenant_id, 'property', id, NOW() FROM deleted`
+	res, err := conn(ctx, m.Conn).ExecContext(ctx, query, id, tenant)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return domain.ErrPropertyNotFound
+	}
+	return nil
+}
+
+func (m *propertyRepository) FetchTrashed(ctx context.Context, limit int, offset int) ([]domain.Property, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + propertyColumns + ` FROM properties WHERE tenant_id = $1 AND deleted_at IS NOT NULL AND merged_into IS NULL ORDER BY deleted_at DESC, id LIMIT $2 OFFSET $3`
+	rows, err := conn(ctx, m.Conn).QueryContext(ctx, query, tenant, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var properties []domain.Property
+	for rows.Next() {
+		p, err := scanProperty(rows)
+		if err != nil {
+			return nil, err
+		}
+		properties = append(properties, p)
+	}
+	return properties, rows.Err()
+}
+
+func (m *propertyRepository) GetTrashed(ctx context.Context, id int64) (domain.Property, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return domain.Property{}, err
+	}
+
+	query := `SELECT ` + propertyColumns + ` FROM properties WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NOT NULL AND merged_into IS NULL`
+	p, err := scanProperty(conn(ctx, m.Conn).QueryRowContext(ctx, query, id, tenant))
+	if err == sql.ErrNoRows {
+		return domain.Property{}, domain.ErrNotInTrash
+	}
+	return p, err
+}
+
+// Restore takes id out of the trash. updated_at moves forward so change feed
+// clients that saw the tombstone pick the row up again.
+func (m *propertyRepository) Restore(ctx context.Context, id int64) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE properties SET deleted_at = NULL, updated_at = NOW() WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NOT NULL AND merged_into IS NULL`
+	res, err := conn(ctx, m.Conn).ExecContext(ctx, query, id, tenant)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return domain.ErrNotInTrash
+	}
+	return nil
+}
+
+func (m *propertyRepository) UpdateStatus(ctx context.Context, id int64, from string, to string) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE properties SET status = $1, updated_at = NOW()`
+	if column, ok := statusColumns[to]; ok {
+		query += `, ` + column + ` = NOW()`
+	}
+	query += ` WHERE id = $2 AND tenant_id = $3 AND status = $4 AND deleted_at IS NULL`
+	res, err := conn(ctx, m.Conn).ExecContext(ctx, query, to, id, tenant, from)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return domain.ErrInvalidTransition
+	}
+	return nil
+}
+
+func (m *propertyRepository) UpdateGeocode(ctx context.Context, id int64, updatedAt time.Time, loc domain.GeoPoint, g domain.Geocode) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE properties SET latitude=$1, longitude=$2, geocode_confidence=$3, geocode_provider=$4,
+			geocoded_address=$5, geocoded_at=$6, updated_at=NOW()
+		WHERE id=$7 AND tenant_id=$8 AND updated_at=$9 AND deleted_at IS NULL`
+	res, err := conn(ctx, m.Conn).ExecContext(ctx, query, loc.Lat, loc.Lng, g.Confidence, g.Provider, g.Address, g.GeocodedAt, id, tenant, updatedAt)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return domain.ErrPropertyNotFound
+	}
+	return nil
+}
+
+// FetchDuplicateCandidates only narrows the search; the candidates are scored
+// by domain.CompareProperties
+func (m *propertyRepository) FetchDuplicateCandidates(ctx context.Context, p domain.Property, limit int) ([]domain.Property, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cond := &conditions{}
+	cond.add("p.tenant_id = %s", tenant)
+	cond.add("p.id <> %s", p.ID)
+	cond.add("p.listing_type = %s", p.ListingType)
+	cond.clauses = append(cond.clauses, "p.deleted_at IS NULL")
+
+	var near []string
+	switch {
+	case p.Address.VillageCode != "":
+		near = append(near, "p.village_code = "+cond.next(p.Address.VillageCode))
+	case p.Address.DistrictCode != "":
+		near = append(near, "p.district_code = "+cond.next(p.Address.DistrictCode))
+	}
+	if p.Location != nil {
+		origin := "ll_to_earth(" + cond.next(p.Location.Lat) + ", " + cond.next(p.Location.Lng) + ")"
+		near = append(near, "(p.latitude IS NOT NULL AND p.longitude IS NOT NULL AND earth_box("+origin+", 300) @> ll_to_earth(p.latitude, p.longitude))")
+	}
+	// Photos match like in domain.CompareProperties, by the bits their hashes differ in
+	near = append(near, `EXISTS (SELECT 1 FROM property_media a JOIN property_media b
+		ON bit_count((a.phash # b.phash)::bit(64)) <= `+cond.next(domain.SimilarPhotoDistance)+`
+		WHERE a.property_id = p.id AND b.property_id = `+cond.next(p.ID)+`)`)
+	cond.clauses = append(cond.clauses, "("+strings.Join(near, " OR ")+")")
+
+	query := `SELECT ` + propertyColumns + ` FROM properties p` + cond.where() + ` ORDER BY p.id LIMIT ` + cond.next(limit)
+	rows, err := conn(ctx, m.Conn).QueryContext(ctx, query, cond.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var properties []domain.Property
+	for rows.Next() {
+		c, err := scanProperty(rows)
+		if err != nil {
+			return nil, err
+		}
+		properties = append(properties, c)
+	}
+	return properties, rows.Err()
+}
+
+func (m *propertyRepository) Merge(ctx context.Context, id int64, duplicateID int64) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	return withinTx(ctx, m.Conn, func(tx *sql.Tx) error {
+		return merge(ctx, tx, tenant, id, duplicateID)
+	})
+}
+
+func merge(ctx context.Context, tx *sql.Tx, tenant int64, id int64, duplicateID int64) error {
+	// Both rows are locked in id order so that concurrent merges can't deadlock
+	var locked int
+	err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM (SELECT id FROM properties
+		WHERE id IN ($1, $2) AND tenant_id = $3 AND deleted_at IS NULL ORDER BY id FOR UPDATE) l`, id, duplicateID, tenant).Scan(&locked)
+	if err != nil {
+		return err
+	}
+	if locked != 2 {
+		return domain.ErrPropertyNotFound
+	}
+
+	statements := []string{
+		// Photos go after those of id; the duplicate's cover stays one only if id had none
+		`UPDATE property_media SET property_id = $1,
+			position = position + (SELECT COALESCE(MAX(position), 0) FROM property_media WHERE property_id = $1),
+			is_cover = is_cover AND NOT EXISTS (SELECT 1 FROM property_media WHERE property_id = $1 AND is_cover)
+		WHERE property_id = $2 AND tenant_id = $3`,
+		`UPDATE price_history SET property_id = $1 WHERE property_id = $2 AND tenant_id = $3`,
+		// Amenities are combined; where both have one, the value of id is kept
+		`INSERT INTO property_amenities (tenant_id, property_id, amenity_id, value)
+		SELECT tenant_id, $1, amenity_id, value FROM property_amenities WHERE property_id = $2 AND tenant_id = $3
+		ON CONFLICT (property_id, amenity_id) DO NOTHING`,
+		// Revisions are renumbered to follow those of id
+		`UPDATE property_revisions SET property_id = $1,
+			revision = revision + o.n, rolled_back_from = rolled_back_from + o.n
+		FROM (SELECT COALESCE(MAX(revision), 0) AS n FROM property_revisions WHERE property_id = $1) o
+		WHERE property_id = $2 AND tenant_id = $3`,
+		`WITH merged AS (UPDATE properties SET deleted_at = NOW(), merged_into = $1, updated_at = NOW()
+			WHERE id = $2 AND tenant_id = $3 RETURNING id, tenant_id)
+		INSERT INTO tombstones (tenant_id, entity, entity_id, deleted_at) SELECT tenant_id, 'property', id, NOW() FROM merged`,
+		`UPDATE properties SET updated_at = NOW() WHERE id = $1 AND tenant_id = $3`,
+	}
+	for _, query := range statements {
+		if _, err := tx.ExecContext(ctx, query, id, duplicateID, tenant); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Purge runs from the worker and is not tenant scoped
+func (m *propertyRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	res, err := conn(ctx, m.Conn).ExecContext(ctx, `DELETE FROM properties WHERE deleted_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// locationArgs returns the latitude and longitude columns, NULL without a location
+func locationArgs(g *domain.GeoPoint) (interface{}, interface{}) {
+	if g == nil {
+		return nil, nil
+	}
+	return g.Lat, g.Lng
+}
+
+// geocodeArgs returns the geocode columns, NULL for a location entered by hand
+func geocodeArgs(g *domain.Geocode) (interface{}, interface{}, interface{}, interface{}) {
+	if g == nil {
+		return nil, nil, nil, nil
+	}
+	return g.Confidence, g.Provider, g.Address, g.GeocodedAt
 }
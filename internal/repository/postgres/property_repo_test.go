@@ -0,0 +1,35 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"nusatek-backend/internal/domain"
+)
+
+func TestNearbyQuery(t *testing.T) {
+	t.Run("radius", func(t *testing.T) {
+		query, args := nearbyQuery(2, domain.PropertyFilter{ListingType: "sale", Limit: 20}, domain.GeoQuery{Center: domain.GeoPoint{Lat: -6.2, Lng: 106.8}, RadiusKm: 5})
+		assert.Contains(t, query, "p.tenant_id = $1")
+		assert.Contains(t, query, "p.listing_type = $2")
+		assert.Contains(t, query, "earth_box(ll_to_earth($3, $4), $5) @> ll_to_earth(p.latitude, p.longitude)")
+		assert.Contains(t, query, "ORDER BY distance_km, p.id LIMIT $6 OFFSET $7")
+		assert.Equal(t, []interface{}{int64(2), "sale", -6.2, 106.8, 5000.0, 20, 0}, args)
+	})
+
+	t.Run("bounding box", func(t *testing.T) {
+		box := &domain.BoundingBox{South: -6.4, West: 106.6, North: -6.1, East: 107.0}
+		query, args := nearbyQuery(2, domain.PropertyFilter{Limit: 100}, domain.GeoQuery{Box: box})
+		assert.Contains(t, query, "p.latitude BETWEEN $2 AND $3")
+		assert.Contains(t, query, "(p.longitude >= $4 AND p.longitude <= $5)")
+		assert.NotContains(t, query, "earth_box")
+		// Ranked from the centre of the box
+		assert.Equal(t, []interface{}{int64(2), -6.4, -6.1, 106.6, 107.0, -6.25, 106.8, 100, 0}, args)
+	})
+
+	t.Run("bounding box across the antimeridian", func(t *testing.T) {
+		box := &domain.BoundingBox{South: -1, West: 179, North: 1, East: -179}
+		query, _ := nearbyQuery(2, domain.PropertyFilter{Limit: 100}, domain.GeoQuery{Box: box})
+		assert.Contains(t, query, "(p.longitude >= $4 OR p.longitude <= $5)")
+	})
+}
@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"nusatek-backend/internal/domain"
+)
+
+// regionRepository reads the administrative regions dataset, which is shared
+// by every tenant
+type regionRepository struct {
+	Conn *sql.DB
+}
+
+func NewRegionRepository(Conn *sql.DB) domain.RegionRepository {
+	return &regionRepository{Conn}
+}
+
+const regionColumns = `code, name, level, COALESCE(parent_code, '')`
+
+func (m *regionRepository) Fetch(ctx context.Context, parent string, name string) ([]domain.Region, error) {
+	query := `SELECT ` + regionColumns + ` FROM regions
+		WHERE parent_code IS NOT DISTINCT FROM NULLIF($1, '')
+			AND ($2 = '' OR name ILIKE '%' || $2 || '%')
+		ORDER BY code`
+	return m.fetch(ctx, query, parent, escapeLike(name))
+}
+
+// escapeLike makes s match itself in a LIKE pattern, so that searching for
+// "100%" or "_" does not match every name
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (m *regionRepository) GetByCodes(ctx context.Context, codes []string) ([]domain.Region, error) {
+	query := `SELECT ` + regionColumns + ` FROM regions WHERE code = ANY($1) ORDER BY level`
+	return m.fetch(ctx, query, pq.Array(codes))
+}
+
+func (m *regionRepository) fetch(ctx context.Context, query string, args ...interface{}) ([]domain.Region, error) {
+	rows, err := m.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var regions []domain.Region
+	for rows.Next() {
+		var r domain.Region
+		if err := rows.Scan(&r.Code, &r.Name, &r.Level, &r.ParentCode); err != nil {
+			return nil, err
+		}
+		regions = append(regions, r)
+	}
+	return regions, rows.Err()
+}
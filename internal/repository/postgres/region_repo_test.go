@@ -0,0 +1,19 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeLike(t *testing.T) {
+	for in, want := range map[string]string{
+		"Bandung":  "Bandung",
+		"%":        `\%`,
+		"kota_":    `kota\_`,
+		`Jl. 10\%`: `Jl. 10\\\%`,
+		"":         "",
+	} {
+		assert.Equal(t, want, escapeLike(in), in)
+	}
+}
@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"nusatek-backend/internal/domain"
+)
+
+type revisionRepository struct {
+	Conn *sql.DB
+}
+
+func NewPropertyRevisionRepository(Conn *sql.DB) domain.PropertyRevisionRepository {
+	return &revisionRepository{Conn}
+}
+
+const revisionColumns = `id, property_id, revision, snapshot, COALESCE(author_id, 0), COALESCE(rolled_back_from, 0), created_at`
+
+func scanRevision(row rowScanner) (domain.PropertyRevision, error) {
+	var (
+		r        domain.PropertyRevision
+		snapshot []byte
+	)
+	if err := row.Scan(&r.ID, &r.PropertyID, &r.Revision, &snapshot, &r.AuthorID, &r.RolledBackFrom, &r.CreatedAt); err != nil {
+		return domain.PropertyRevision{}, err
+	}
+	if err := json.Unmarshal(snapshot, &r.Property); err != nil {
+		return domain.PropertyRevision{}, err
+	}
+	return r, nil
+}
+
+func (m *revisionRepository) Fetch(ctx context.Context, propertyID int64, limit int, offset int) ([]domain.PropertyRevision, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + revisionColumns + ` FROM property_revisions WHERE property_id = $1 AND tenant_id = $2 ORDER BY revision DESC LIMIT $3 OFFSET $4`
+	rows, err := conn(ctx, m.Conn).QueryContext(ctx, query, propertyID, tenant, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []domain.PropertyRevision
+	for rows.Next() {
+		r, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, r)
+	}
+	return revisions, rows.Err()
+}
+
+func (m *revisionRepository) Get(ctx context.Context, propertyID int64, revision int) (domain.PropertyRevision, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return domain.PropertyRevision{}, err
+	}
+
+	query := `SELECT ` + revisionColumns + ` FROM property_revisions WHERE property_id = $1 AND revision = $2 AND tenant_id = $3`
+	r, err := scanRevision(conn(ctx, m.Conn).QueryRowContext(ctx, query, propertyID, revision, tenant))
+	if err == sql.ErrNoRows {
+		return domain.PropertyRevision{}, domain.ErrRevisionNotFound
+	}
+	return r, err
+}
+
+func (m *revisionRepository) Store(ctx context.Context, r *domain.PropertyRevision) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+	snapshot, err := json.Marshal(r.Property)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO property_revisions (tenant_id, property_id, revision, snapshot, author_id, rolled_back_from, created_at)
+		SELECT $1, $2, COALESCE(MAX(revision), 0) + 1, $3, NULLIF($4, 0), NULLIF($5, 0), NOW() FROM property_revisions WHERE property_id = $2
+		RETURNING id, revision, created_at`
+	// Concurrent writers can pick the same number; the unique index rejects
+	// all but one and the others try again with the next number
+	return retryUnique(ctx, m.Conn, 4, func(q dbtx) error {
+		return q.QueryRowContext(ctx, query, tenant, r.PropertyID, snapshot, r.AuthorID, r.RolledBackFrom).Scan(&r.ID, &r.Revision, &r.CreatedAt)
+	})
+}
@@ -0,0 +1,15 @@
+package postgres
+
+import (
+	"context"
+	"nusatek-backend/internal/domain"
+)
+
+// tenantID returns the tenant every tenant-owned query must be scoped to
+func tenantID(ctx context.Context) (int64, error) {
+	id, ok := domain.TenantFromContext(ctx)
+	if !ok {
+		return 0, domain.ErrTenantRequired
+	}
+	return id, nil
+}
@@ -0,0 +1,198 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"nusatek-backend/internal/domain"
+)
+
+// statement is a query the repositories sent to the database
+type statement struct {
+	query string
+	args  []driver.Value
+}
+
+// recorder is a database that finds no rows and records every statement
+type recorder struct {
+	mu         sync.Mutex
+	statements []statement
+}
+
+func (r *recorder) Connect(context.Context) (driver.Conn, error) { return recorderConn{r}, nil }
+func (r *recorder) Driver() driver.Driver                        { return nil }
+
+func (r *recorder) take() []statement {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.statements
+	r.statements = nil
+	return s
+}
+
+type recorderConn struct{ r *recorder }
+
+func (c recorderConn) record(query string, args []driver.NamedValue) {
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		values[i] = a.Value
+	}
+	c.r.mu.Lock()
+	c.r.statements = append(c.r.statements, statement{query, values})
+	c.r.mu.Unlock()
+}
+
+func (c recorderConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.record(query, args)
+	return noRows{}, nil
+}
+
+func (c recorderConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.record(query, args)
+	return driver.RowsAffected(0), nil
+}
+
+func (c recorderConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c recorderConn) Close() error                              { return nil }
+func (c recorderConn) Begin() (driver.Tx, error)                 { return noTx{}, nil }
+
+type noRows struct{}
+
+func (noRows) Columns() []string              { return nil }
+func (noRows) Close() error                   { return nil }
+func (noRows) Next(dest []driver.Value) error { return io.EOF }
+
+type noTx struct{}
+
+func (noTx) Commit() error   { return nil }
+func (noTx) Rollback() error { return nil }
+
+// TestRepositoriesScopeToTenant checks that every query a tenant's request
+// can make binds that tenant, so rows of other agencies are never found
+func TestRepositoriesScopeToTenant(t *testing.T) {
+	rec := &recorder{}
+	db := sql.OpenDB(rec)
+	defer db.Close()
+
+	properties := NewPropertyRepository(db)
+	customers := NewCustomerRepository(db)
+	webhooks := NewWebhookRepository(db)
+
+	const tenant = int64(41)
+	calls := map[string]func(ctx context.Context) error{
+		"properties.Fetch": func(ctx context.Context) error {
+			_, err := properties.Fetch(ctx, domain.PropertyFilter{Limit: 20})
+			return err
+		},
+		"properties.FetchNearby": func(ctx context.Context) error {
+			_, err := properties.FetchNearby(ctx, domain.PropertyFilter{Limit: 20}, domain.GeoQuery{Center: domain.GeoPoint{Lat: -6.2, Lng: 106.8}, RadiusKm: 5})
+			return err
+		},
+		"properties.GetByID": func(ctx context.Context) error {
+			_, err := properties.GetByID(ctx, 7)
+			return err
+		},
+		"properties.Update": func(ctx context.Context) error {
+			return properties.Update(ctx, &domain.Property{ID: 7, Title: "Rumah"})
+		},
+		"properties.Delete": func(ctx context.Context) error {
+			return properties.Delete(ctx, 7)
+		},
+		"properties.FetchTrashed": func(ctx context.Context) error {
+			_, err := properties.FetchTrashed(ctx, 20, 0)
+			return err
+		},
+		"properties.GetTrashed": func(ctx context.Context) error {
+			_, err := properties.GetTrashed(ctx, 7)
+			return err
+		},
+		"properties.Restore": func(ctx context.Context) error {
+			return properties.Restore(ctx, 7)
+		},
+		"properties.UpdateStatus": func(ctx context.Context) error {
+			return properties.UpdateStatus(ctx, 7, domain.PropertyDraft, domain.PropertyPublished)
+		},
+		"customers.Fetch": func(ctx context.Context) error {
+			_, err := customers.Fetch(ctx, 20, 0)
+			return err
+		},
+		"customers.GetByID": func(ctx context.Context) error {
+			_, err := customers.GetByID(ctx, 7)
+			return err
+		},
+		"customers.Update": func(ctx context.Context) error {
+			return customers.Update(ctx, &domain.Customer{ID: 7, Name: "Budi"})
+		},
+		"customers.Delete": func(ctx context.Context) error {
+			return customers.Delete(ctx, 7)
+		},
+		"customers.FetchTrashed": func(ctx context.Context) error {
+			_, err := customers.FetchTrashed(ctx, 20, 0)
+			return err
+		},
+		"customers.GetTrashed": func(ctx context.Context) error {
+			_, err := customers.GetTrashed(ctx, 7)
+			return err
+		},
+		"customers.Restore": func(ctx context.Context) error {
+			return customers.Restore(ctx, 7)
+		},
+		"webhooks.Fetch": func(ctx context.Context) error {
+			_, err := webhooks.Fetch(ctx)
+			return err
+		},
+		"webhooks.FetchActive": func(ctx context.Context) error {
+			_, err := webhooks.FetchActive(ctx)
+			return err
+		},
+		"webhooks.GetByID": func(ctx context.Context) error {
+			_, err := webhooks.GetByID(ctx, 7)
+			return err
+		},
+		"webhooks.Update": func(ctx context.Context) error {
+			return webhooks.Update(ctx, &domain.Webhook{ID: 7, URL: "https://example.com/hook"})
+		},
+		"webhooks.Delete": func(ctx context.Context) error {
+			return webhooks.Delete(ctx, 7)
+		},
+		"webhooks.FetchDeliveries": func(ctx context.Context) error {
+			_, err := webhooks.FetchDeliveries(ctx, 7, 20, 0)
+			return err
+		},
+		"webhooks.GetDelivery": func(ctx context.Context) error {
+			_, err := webhooks.GetDelivery(ctx, 7, 3)
+			return err
+		},
+	}
+
+	ctx := domain.ContextWithTenant(context.Background(), tenant)
+	for name, call := range calls {
+		call(ctx)
+		statements := rec.take()
+		assert.NotEmpty(t, statements, name)
+		for _, s := range statements {
+			assert.Contains(t, s.query, "tenant_id", name)
+			assert.Contains(t, s.args, tenant, "%s: %s", name, s.query)
+		}
+
+		// Without a tenant nothing reaches the database
+		assert.ErrorIs(t, call(context.Background()), domain.ErrTenantRequired, name)
+		assert.Empty(t, rec.take(), name)
+	}
+
+	// Another agency's record looks like a missing one
+	_, err := properties.GetByID(ctx, 7)
+	assert.ErrorIs(t, err, domain.ErrPropertyNotFound)
+	_, err = customers.GetByID(ctx, 7)
+	assert.ErrorIs(t, err, domain.ErrCustomerNotFound)
+	_, err = webhooks.GetByID(ctx, 7)
+	assert.ErrorIs(t, err, domain.ErrWebhookNotFound)
+	assert.ErrorIs(t, properties.Delete(ctx, 7), domain.ErrPropertyNotFound)
+	assert.ErrorIs(t, customers.Delete(ctx, 7), domain.ErrCustomerNotFound)
+	assert.ErrorIs(t, webhooks.Delete(ctx, 7), domain.ErrWebhookNotFound)
+}
@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+
+	"nusatek-backend/internal/domain"
+)
+
+type txKey struct{}
+
+// dbtx is the part of *sql.DB and *sql.Tx the repositories use
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn returns the transaction ctx runs in, or db outside of one
+func conn(ctx context.Context, db *sql.DB) dbtx {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// withinTx runs fn in the transaction ctx runs in, or in a new one
+func withinTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(tx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+type transactor struct {
+	Conn *sql.DB
+}
+
+func NewTransactor(Conn *sql.DB) domain.Transactor {
+	return &transactor{Conn}
+}
+
+func (t *transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withinTx(ctx, t.Conn, func(tx *sql.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// retryUnique runs fn up to attempts times while it fails on a unique
+// violation. Inside a transaction every attempt runs under a savepoint, as a
+// failed statement would otherwise abort the whole transaction.
+func retryUnique(ctx context.Context, db *sql.DB, attempts int, fn func(q dbtx) error) error {
+	q := conn(ctx, db)
+	tx, nested := q.(*sql.Tx)
+	for attempt := 1; ; attempt++ {
+		if nested {
+			if _, err := tx.ExecContext(ctx, `SAVEPOINT retry_unique`); err != nil {
+				return err
+			}
+		}
+		err := fn(q)
+		var pqErr *pq.Error
+		if attempt < attempts && errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			if nested {
+				if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT retry_unique`); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if nested && err == nil {
+			_, err = tx.ExecContext(ctx, `RELEASE SAVEPOINT retry_unique`)
+		}
+		return err
+	}
+}
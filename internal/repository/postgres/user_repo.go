@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"nusatek-backend/internal/domain"
+)
+
+type userRepository struct {
+	Conn *sql.DB
+}
+
+func NewUserRepository(Conn *sql.DB) domain.UserRepository {
+	return &userRepository{Conn}
+}
+
+func (m *userRepository) GetByID(ctx context.Context, id int64) (domain.User, error) {
+	query := `SELECT id, email, name, role, tenant_id, COALESCE(branch_id, 0), password_hash, created_at, updated_at FROM users WHERE id = $1`
+	return m.get(ctx, query, id)
+}
+
+func (m *userRepository) GetByEmail(ctx context.Context, email string) (domain.User, error) {
+	query := `SELECT id, email, name, role, tenant_id, COALESCE(branch_id, 0), password_hash, created_at, updated_at FROM users WHERE LOWER(email) = LOWER($1)`
+	return m.get(ctx, query, email)
+}
+
+func (m *userRepository) get(ctx context.Context, query string, arg interface{}) (domain.User, error) {
+	var u domain.User
+	err := m.Conn.QueryRowContext(ctx, query, arg).Scan(&u.ID, &u.Email, &u.Name, &u.Role, &u.TenantID, &u.BranchID, &u.PasswordHash, &u.CreatedAt, &u.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return u, domain.ErrUserNotFound
+	}
+	return u, err
+}
+
+func (m *userRepository) Store(ctx context.Context, u *domain.User) error {
+	query := `INSERT INTO users (email, name, role, tenant_id, branch_id, password_hash, created_at, updated_at) VALUES ($1, $2, $3, $4, NULLIF($5, 0), $6, NOW(), NOW()) RETURNING id, created_at, updated_at`
+	return m.Conn.QueryRowContext(ctx, query, u.Email, u.Name, u.Role, u.TenantID, u.BranchID, u.PasswordHash).Scan(&u.ID, &u.CreatedAt, &u.UpdatedAt)
+}
+
+func (m *userRepository) Count(ctx context.Context) (int64, error) {
+	var n int64
+	err := m.Conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&n)
+	return n, err
+}
@@ -0,0 +1,217 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"nusatek-backend/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+type webhookRepository struct {
+	Conn *sql.DB
+}
+
+func NewWebhookRepository(Conn *sql.DB) domain.WebhookRepository {
+	return &webhookRepository{Conn}
+}
+
+const webhookColumns = `id, url, event_types, secret, active, created_at, updated_at`
+
+const deliveryColumns = `id, webhook_id, tenant_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+	COALESCE(response_status, 0), COALESCE(last_error, ''), delivered_at, created_at`
+
+func (m *webhookRepository) Fetch(ctx context.Context) ([]domain.Webhook, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE tenant_id = $1 ORDER BY id`
+	return m.fetch(ctx, query, tenant)
+}
+
+func (m *webhookRepository) FetchActive(ctx context.Context) ([]domain.Webhook, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE active AND tenant_id = $1 ORDER BY id`
+	return m.fetch(ctx, query, tenant)
+}
+
+func (m *webhookRepository) fetch(ctx context.Context, query string, args ...interface{}) ([]domain.Webhook, error) {
+	rows, err := m.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []domain.Webhook
+	for rows.Next() {
+		var w domain.Webhook
+		if err := rows.Scan(&w.ID, &w.URL, pq.Array(&w.EventTypes), &w.Secret, &w.Active, &w.CreatedAt, &w.UpdatedAt); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, rows.Err()
+}
+
+func (m *webhookRepository) GetByID(ctx context.Context, id int64) (domain.Webhook, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1 AND tenant_id = $2`
+	row := m.Conn.QueryRowContext(ctx, query, id, tenant)
+
+	var w domain.Webhook
+	err = row.Scan(&w.ID, &w.URL, pq.Array(&w.EventTypes), &w.Secret, &w.Active, &w.CreatedAt, &w.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return w, domain.ErrWebhookNotFound
+	}
+	return w, err
+}
+
+func (m *webhookRepository) Store(ctx context.Context, w *domain.Webhook) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO webhooks (tenant_id, url, event_types, secret, active, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, NOW(), NOW()) RETURNING id, created_at, updated_at`
+	return m.Conn.QueryRowContext(ctx, query, tenant, w.URL, pq.Array(w.EventTypes), w.Secret, w.Active).Scan(&w.ID, &w.CreatedAt, &w.UpdatedAt)
+}
+
+func (m *webhookRepository) Update(ctx context.Context, w *domain.Webhook) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE webhooks SET url=$1, event_types=$2, secret=$3, active=$4, updated_at=NOW() WHERE id=$5 AND tenant_id=$6`
+	res, err := m.Conn.ExecContext(ctx, query, w.URL, pq.Array(w.EventTypes), w.Secret, w.Active, w.ID, tenant)
+	if err != nil {
+		return err
+	}
+	return webhookAffected(res)
+}
+
+func (m *webhookRepository) Delete(ctx context.Context, id int64) error {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `DELETE FROM webhooks WHERE id = $1 AND tenant_id = $2`
+	res, err := m.Conn.ExecContext(ctx, query, id, tenant)
+	if err != nil {
+		return err
+	}
+	return webhookAffected(res)
+}
+
+func (m *webhookRepository) StoreDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	query := `INSERT INTO webhook_deliveries (webhook_id, tenant_id, event_id, event_type, payload, status, next_attempt_at, created_at, updated_at)
+		SELECT id, tenant_id, $2, $3, $4, $5, NOW(), NOW(), NOW() FROM webhooks WHERE id = $1
+		ON CONFLICT (webhook_id, event_id) DO NOTHING`
+	_, err := m.Conn.ExecContext(ctx, query, d.WebhookID, d.EventID, d.EventType, []byte(d.Payload), d.Status)
+	return err
+}
+
+func (m *webhookRepository) FetchDeliveries(ctx context.Context, webhookID int64, limit int, offset int) ([]domain.WebhookDelivery, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE webhook_id = $1 AND tenant_id = $2 ORDER BY id DESC LIMIT $3 OFFSET $4`
+	return m.fetchDeliveries(ctx, query, webhookID, tenant, limit, offset)
+}
+
+func (m *webhookRepository) FetchDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+	// Claim due deliveries by pushing their next attempt out, so concurrent
+	// dispatchers never send the same delivery twice.
+	query := `UPDATE webhook_deliveries SET next_attempt_at = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + deliveryColumns
+	return m.fetchDeliveries(ctx, query, limit, lease.Seconds())
+}
+
+func (m *webhookRepository) fetchDeliveries(ctx context.Context, query string, args ...interface{}) ([]domain.WebhookDelivery, error) {
+	rows, err := m.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []domain.WebhookDelivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func (m *webhookRepository) GetDelivery(ctx context.Context, webhookID int64, id int64) (domain.WebhookDelivery, error) {
+	tenant, err := tenantID(ctx)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE webhook_id = $1 AND id = $2 AND tenant_id = $3`
+	d, err := scanDelivery(m.Conn.QueryRowContext(ctx, query, webhookID, id, tenant))
+	if errors.Is(err, sql.ErrNoRows) {
+		return d, domain.ErrWebhookNotFound
+	}
+	return d, err
+}
+
+func (m *webhookRepository) UpdateDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	query := `UPDATE webhook_deliveries
+		SET status=$1, attempts=$2, next_attempt_at=$3, response_status=NULLIF($4, 0), last_error=NULLIF($5, ''), delivered_at=$6, updated_at=NOW()
+		WHERE id=$7`
+	_, err := m.Conn.ExecContext(ctx, query, d.Status, d.Attempts, d.NextAttemptAt, d.ResponseStatus, d.LastError, d.DeliveredAt, d.ID)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanDelivery(row rowScanner) (domain.WebhookDelivery, error) {
+	var (
+		d       domain.WebhookDelivery
+		payload []byte
+	)
+	err := row.Scan(&d.ID, &d.WebhookID, &d.TenantID, &d.EventID, &d.EventType, &payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&d.ResponseStatus, &d.LastError, &d.DeliveredAt, &d.CreatedAt)
+	d.Payload = payload
+	return d, err
+}
+
+func webhookAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrWebhookNotFound
+	}
+	return nil
+}
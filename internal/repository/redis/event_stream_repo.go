@@ -0,0 +1,110 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"nusatek-backend/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	eventStreamKey     = "events:stream"
+	eventStreamChannel = "events:live"
+)
+
+type eventStreamRepository struct {
+	Client *redis.Client
+	// maxLen caps the history kept for Last-Event-ID resume
+	maxLen int64
+}
+
+func NewEventStreamRepository(client *redis.Client, maxLen int64) domain.EventStreamRepository {
+	return &eventStreamRepository{Client: client, maxLen: maxLen}
+}
+
+func (r *eventStreamRepository) Append(ctx context.Context, evt domain.Event) (domain.StreamEvent, error) {
+	body, err := json.Marshal(evt)
+	if err != nil {
+		return domain.StreamEvent{}, err
+	}
+
+	id, err := r.Client.XAdd(ctx, &redis.XAddArgs{
+		Stream: eventStreamKey,
+		MaxLen: r.maxLen,
+		Approx: true,
+		Values: map[string]interface{}{"event": body},
+	}).Result()
+	if err != nil {
+		return domain.StreamEvent{}, err
+	}
+
+	se := domain.StreamEvent{ID: id, Event: evt}
+	msg, err := json.Marshal(se)
+	if err != nil {
+		return se, err
+	}
+	return se, r.Client.Publish(ctx, eventStreamChannel, msg).Err()
+}
+
+func (r *eventStreamRepository) Since(ctx context.Context, lastID string, limit int) ([]domain.StreamEvent, error) {
+	if lastID == "" {
+		return nil, nil
+	}
+
+	msgs, err := r.Client.XRangeN(ctx, eventStreamKey, "("+lastID, "+", int64(limit)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]domain.StreamEvent, 0, len(msgs))
+	for _, msg := range msgs {
+		raw, _ := msg.Values["event"].(string)
+
+		var evt domain.Event
+		if err := json.Unmarshal([]byte(raw), &evt); err != nil {
+			return nil, err
+		}
+		events = append(events, domain.StreamEvent{ID: msg.ID, Event: evt})
+	}
+	return events, nil
+}
+
+func (r *eventStreamRepository) Subscribe(ctx context.Context) (<-chan domain.StreamEvent, error) {
+	pubsub := r.Client.Subscribe(ctx, eventStreamChannel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	out := make(chan domain.StreamEvent)
+	go func() {
+		defer close(out)
+		defer pubsub.Close()
+
+		msgs := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				var se domain.StreamEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &se); err != nil {
+					log.Printf("event stream: dropping malformed message: %v", err)
+					continue
+				}
+				select {
+				case out <- se:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
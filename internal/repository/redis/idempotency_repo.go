@@ -0,0 +1,85 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"nusatek-backend/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type idempotencyRecord struct {
+	Fingerprint string                     `json:"fingerprint"`
+	Response    *domain.IdempotentResponse `json:"response,omitempty"`
+}
+
+type idempotencyRepository struct {
+	Client *redis.Client
+	// lockTTL bounds how long a crashed request can hold a key
+	lockTTL time.Duration
+	// responseTTL is how long completed responses are replayed
+	responseTTL time.Duration
+}
+
+func NewIdempotencyRepository(client *redis.Client, lockTTL, responseTTL time.Duration) domain.IdempotencyRepository {
+	return &idempotencyRepository{
+		Client:      client,
+		lockTTL:     lockTTL,
+		responseTTL: responseTTL,
+	}
+}
+
+func (r *idempotencyRepository) Begin(ctx context.Context, key string, fingerprint string) (*domain.IdempotentResponse, error) {
+	pending, err := json.Marshal(idempotencyRecord{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := r.Client.SetNX(ctx, idempotencyKey(key), pending, r.lockTTL).Result()
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return nil, nil
+	}
+
+	val, err := r.Client.Get(ctx, idempotencyKey(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// The earlier reservation expired between the two calls
+		return nil, domain.ErrIdempotencyInProgress
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var existing idempotencyRecord
+	if err := json.Unmarshal(val, &existing); err != nil {
+		return nil, err
+	}
+	if existing.Fingerprint != fingerprint {
+		return nil, domain.ErrIdempotencyKeyReused
+	}
+	if existing.Response == nil {
+		return nil, domain.ErrIdempotencyInProgress
+	}
+	return existing.Response, nil
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, key string, fingerprint string, resp domain.IdempotentResponse) error {
+	done, err := json.Marshal(idempotencyRecord{Fingerprint: fingerprint, Response: &resp})
+	if err != nil {
+		return err
+	}
+	return r.Client.Set(ctx, idempotencyKey(key), done, r.responseTTL).Err()
+}
+
+func (r *idempotencyRepository) Release(ctx context.Context, key string) error {
+	return r.Client.Del(ctx, idempotencyKey(key)).Err()
+}
+
+func idempotencyKey(key string) string {
+	return "idempotency:" + key
+}
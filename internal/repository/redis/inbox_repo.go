@@ -0,0 +1,47 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"nusatek-backend/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	inboxProcessing = "processing"
+	inboxProcessed  = "processed"
+)
+
+type inboxRepository struct {
+	Client *redis.Client
+	// claimTTL bounds how long a crashed worker can hold an event
+	claimTTL time.Duration
+	// processedTTL is how long processed ids are remembered for deduplication
+	processedTTL time.Duration
+}
+
+func NewInboxRepository(client *redis.Client, claimTTL, processedTTL time.Duration) domain.InboxRepository {
+	return &inboxRepository{
+		Client:       client,
+		claimTTL:     claimTTL,
+		processedTTL: processedTTL,
+	}
+}
+
+func (r *inboxRepository) Claim(ctx context.Context, consumer string, eventID string) (bool, error) {
+	return r.Client.SetNX(ctx, inboxKey(consumer, eventID), inboxProcessing, r.claimTTL).Result()
+}
+
+func (r *inboxRepository) MarkProcessed(ctx context.Context, consumer string, eventID string) error {
+	return r.Client.Set(ctx, inboxKey(consumer, eventID), inboxProcessed, r.processedTTL).Err()
+}
+
+func (r *inboxRepository) Release(ctx context.Context, consumer string, eventID string) error {
+	return r.Client.Del(ctx, inboxKey(consumer, eventID)).Err()
+}
+
+func inboxKey(consumer, eventID string) string {
+	return "inbox:" + consumer + ":" + eventID
+}
@@ -0,0 +1,80 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"nusatek-backend/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript keeps one sorted-set member per request in the last
+// window and only adds the current request when there is room, atomically.
+// It returns {allowed, count, milliseconds until the oldest request expires}.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+
+local reset = window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, count, reset}
+`)
+
+type rateLimiter struct {
+	Client *redis.Client
+}
+
+// NewRateLimiter returns a sliding-window log limiter shared by all API instances
+func NewRateLimiter(client *redis.Client) domain.RateLimiter {
+	return &rateLimiter{Client: client}
+}
+
+func (r *rateLimiter) Allow(ctx context.Context, key string, limit domain.RateLimit) (domain.RateLimitResult, error) {
+	if limit.Requests <= 0 {
+		return domain.RateLimitResult{Allowed: true}, nil
+	}
+
+	now := time.Now().UnixMilli()
+	res, err := slidingWindowScript.Run(ctx, r.Client, []string{"ratelimit:" + key},
+		now, limit.Window.Milliseconds(), limit.Requests, strconv.FormatInt(now, 10)+"-"+requestMember()).Int64Slice()
+	if err != nil {
+		return domain.RateLimitResult{}, err
+	}
+
+	remaining := limit.Requests - int(res[1])
+	if remaining < 0 {
+		remaining = 0
+	}
+	return domain.RateLimitResult{
+		Allowed:   res[0] == 1,
+		Limit:     limit.Requests,
+		Remaining: remaining,
+		Reset:     time.Duration(res[2]) * time.Millisecond,
+	}, nil
+}
+
+// requestMember makes sorted-set members unique within the same millisecond
+func requestMember() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
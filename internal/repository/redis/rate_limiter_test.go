@@ -0,0 +1,47 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"nusatek-backend/internal/domain"
+)
+
+func TestRateLimiterBurst(t *testing.T) {
+	mr := miniredis.RunT(t)
+	limiter := NewRateLimiter(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	ctx := context.Background()
+	limit := domain.RateLimit{Requests: 3, Window: time.Minute}
+
+	// A burst gets exactly the limit through, then is refused until the window moves on
+	for i := 0; i < 3; i++ {
+		res, err := limiter.Allow(ctx, "default:ip:203.0.113.7", limit)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed, i)
+		assert.Equal(t, 2-i, res.Remaining)
+	}
+	for i := 0; i < 2; i++ {
+		res, err := limiter.Allow(ctx, "default:ip:203.0.113.7", limit)
+		require.NoError(t, err)
+		assert.False(t, res.Allowed)
+		assert.Equal(t, 0, res.Remaining)
+		assert.True(t, res.Reset > 0 && res.Reset <= time.Minute, res.Reset)
+	}
+
+	// Refused requests are not counted, and other clients have their own budget
+	members, err := mr.ZMembers("ratelimit:default:ip:203.0.113.7")
+	require.NoError(t, err)
+	assert.Len(t, members, 3)
+	res, err := limiter.Allow(ctx, "default:ip:198.51.100.2", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	res, err = limiter.Allow(ctx, "default:ip:203.0.113.7", domain.RateLimit{})
+	require.NoError(t, err)
+	assert.True(t, res.Allowed, "zero requests is unlimited")
+}
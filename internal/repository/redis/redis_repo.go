@@ -3,6 +3,7 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"strconv"
 	"time"
 
 	"nusatek-backend/internal/domain"
@@ -19,6 +20,11 @@ func NewPropertyCacheRepository(client *redis.Client) domain.PropertyCacheReposi
 }
 
 func (r *propertyCacheRepository) Get(ctx context.Context, key string) (*domain.Property, error) {
+	key, err := tenantKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
 	val, err := r.Client.Get(ctx, key).Result()
 	if err != nil {
 		return nil, err
@@ -33,6 +39,11 @@ func (r *propertyCacheRepository) Get(ctx context.Context, key string) (*domain.
 }
 
 func (r *propertyCacheRepository) Set(ctx context.Context, key string, p *domain.Property, ttl time.Duration) error {
+	key, err := tenantKey(ctx, key)
+	if err != nil {
+		return err
+	}
+
 	jsonBytes, err := json.Marshal(p)
 	if err != nil {
 		return err
@@ -42,5 +53,19 @@ func (r *propertyCacheRepository) Set(ctx context.Context, key string, p *domain
 }
 
 func (r *propertyCacheRepository) Delete(ctx context.Context, key string) error {
+	key, err := tenantKey(ctx, key)
+	if err != nil {
+		return err
+	}
+
 	return r.Client.Del(ctx, key).Err()
 }
+
+// tenantKey prefixes key with the tenant in ctx so agencies never share cache entries
+func tenantKey(ctx context.Context, key string) (string, error) {
+	id, ok := domain.TenantFromContext(ctx)
+	if !ok {
+		return "", domain.ErrTenantRequired
+	}
+	return "tenant:" + strconv.FormatInt(id, 10) + ":" + key, nil
+}
@@ -0,0 +1,83 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"nusatek-backend/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type tokenRepository struct {
+	Client *redis.Client
+	// familyTTL must outlive every refresh token of a revoked family
+	familyTTL time.Duration
+}
+
+func NewTokenRepository(client *redis.Client, familyTTL time.Duration) domain.TokenRepository {
+	return &tokenRepository{Client: client, familyTTL: familyTTL}
+}
+
+func (r *tokenRepository) StoreRefresh(ctx context.Context, tokenHash string, s domain.RefreshSession, ttl time.Duration) error {
+	body, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return r.Client.Set(ctx, "refresh:"+tokenHash, body, ttl).Err()
+}
+
+func (r *tokenRepository) ConsumeRefresh(ctx context.Context, tokenHash string, usedTTL time.Duration) (domain.RefreshSession, error) {
+	val, err := r.Client.GetDel(ctx, "refresh:"+tokenHash).Result()
+	if errors.Is(err, redis.Nil) {
+		return domain.RefreshSession{}, domain.ErrInvalidToken
+	}
+	if err != nil {
+		return domain.RefreshSession{}, err
+	}
+
+	if err := r.Client.Set(ctx, "refresh_used:"+tokenHash, val, usedTTL).Err(); err != nil {
+		return domain.RefreshSession{}, err
+	}
+	return decodeSession(val)
+}
+
+func (r *tokenRepository) UsedRefresh(ctx context.Context, tokenHash string) (domain.RefreshSession, error) {
+	val, err := r.Client.Get(ctx, "refresh_used:"+tokenHash).Result()
+	if errors.Is(err, redis.Nil) {
+		return domain.RefreshSession{}, domain.ErrInvalidToken
+	}
+	if err != nil {
+		return domain.RefreshSession{}, err
+	}
+	return decodeSession(val)
+}
+
+func (r *tokenRepository) RevokeFamily(ctx context.Context, family string) error {
+	return r.Client.Set(ctx, "refresh_family_revoked:"+family, 1, r.familyTTL).Err()
+}
+
+func (r *tokenRepository) FamilyRevoked(ctx context.Context, family string) (bool, error) {
+	n, err := r.Client.Exists(ctx, "refresh_family_revoked:"+family).Result()
+	return n > 0, err
+}
+
+func (r *tokenRepository) RevokeAccess(ctx context.Context, tokenID string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	return r.Client.Set(ctx, "revoked_access:"+tokenID, 1, ttl).Err()
+}
+
+func (r *tokenRepository) AccessRevoked(ctx context.Context, tokenID string) (bool, error) {
+	n, err := r.Client.Exists(ctx, "revoked_access:"+tokenID).Result()
+	return n > 0, err
+}
+
+func decodeSession(val string) (domain.RefreshSession, error) {
+	var s domain.RefreshSession
+	err := json.Unmarshal([]byte(val), &s)
+	return s, err
+}
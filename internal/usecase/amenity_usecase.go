@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"nusatek-backend/internal/domain"
+)
+
+type amenityUsecase struct {
+	amenityRepo  domain.AmenityRepository
+	propertyRepo domain.PropertyRepository
+	cacheRepo    domain.PropertyCacheRepository
+	revisionRepo domain.PropertyRevisionRepository
+	authorizer   domain.Authorizer
+	tx           domain.Transactor
+	timeout      time.Duration
+}
+
+func NewAmenityUsecase(d PropertyDeps) domain.AmenityUsecase {
+	return &amenityUsecase{
+		amenityRepo:  d.Amenities,
+		propertyRepo: d.Properties,
+		cacheRepo:    d.Cache,
+		revisionRepo: d.Revisions,
+		authorizer:   d.Authorizer,
+		tx:           d.Tx,
+		timeout:      d.Timeout,
+	}
+}
+
+// Fetch is open to everyone who may read properties, to pick amenities from
+func (u *amenityUsecase) Fetch(c context.Context) ([]domain.Amenity, error) {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizer.Authorize(ctx, domain.PermPropertiesRead, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	return u.amenityRepo.Fetch(ctx)
+}
+
+func (u *amenityUsecase) Store(c context.Context, a *domain.Amenity) error {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizer.Authorize(ctx, domain.PermAmenitiesManage, domain.Resource{}); err != nil {
+		return err
+	}
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	return u.amenityRepo.Store(ctx, a)
+}
+
+func (u *amenityUsecase) Update(c context.Context, a *domain.Amenity) error {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizer.Authorize(ctx, domain.PermAmenitiesManage, domain.Resource{}); err != nil {
+		return err
+	}
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	return u.amenityRepo.Update(ctx, a)
+}
+
+func (u *amenityUsecase) Delete(c context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizer.Authorize(ctx, domain.PermAmenitiesManage, domain.Resource{}); err != nil {
+		return err
+	}
+
+	// The properties that lose the amenity get a new revision without it
+	var affected []int64
+	err := withinTx(ctx, u.tx, func(ctx context.Context) error {
+		var err error
+		if affected, err = u.amenityRepo.FetchPropertyIDs(ctx, id); err != nil {
+			return err
+		}
+		if err := u.amenityRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		for _, propertyID := range affected {
+			p, err := u.propertyRepo.GetByID(ctx, propertyID)
+			if errors.Is(err, domain.ErrPropertyNotFound) {
+				// Properties in the trash keep their revisions as they were
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := attachAmenities(ctx, u.amenityRepo, &p); err != nil {
+				return err
+			}
+			if err := recordRevision(ctx, u.revisionRepo, p, 0); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, propertyID := range affected {
+		_ = u.cacheRepo.Delete(ctx, propertyCacheKey(propertyID))
+	}
+	return nil
+}
+
+// resolveAmenities looks the amenities of a property up in the catalogue by
+// code and fills in their details, sorted by code. Values are only accepted
+// for amenities measured in a unit.
+func resolveAmenities(ctx context.Context, repo domain.AmenityRepository, amenities []domain.PropertyAmenity) error {
+	if len(amenities) == 0 {
+		return nil
+	}
+	codes := make([]string, len(amenities))
+	seen := make(map[string]bool, len(amenities))
+	for i, a := range amenities {
+		if seen[a.Code] {
+			return fmt.Errorf("%w: amenity %q is listed twice", domain.ErrInvalidProperty, a.Code)
+		}
+		seen[a.Code] = true
+		codes[i] = a.Code
+	}
+	if repo == nil {
+		return nil
+	}
+
+	catalogue, err := repo.FetchByCodes(ctx, codes)
+	if err != nil {
+		return err
+	}
+	byCode := make(map[string]domain.Amenity, len(catalogue))
+	for _, a := range catalogue {
+		byCode[a.Code] = a
+	}
+	for i, a := range amenities {
+		known, ok := byCode[a.Code]
+		switch {
+		case !ok:
+			return fmt.Errorf("%w: unknown amenity %q", domain.ErrInvalidProperty, a.Code)
+		case a.Value < 0:
+			return fmt.Errorf("%w: amenity %q cannot have a negative value", domain.ErrInvalidProperty, a.Code)
+		case a.Value != 0 && known.Unit == "":
+			return fmt.Errorf("%w: amenity %q takes no value", domain.ErrInvalidProperty, a.Code)
+		}
+		amenities[i] = domain.PropertyAmenity{AmenityID: known.ID, Code: known.Code, Name: known.Name,
+			Category: known.Category, Unit: known.Unit, Value: a.Value}
+	}
+	sort.Slice(amenities, func(i, j int) bool { return amenities[i].Code < amenities[j].Code })
+	return nil
+}
+
+// attachAmenities fills in the amenities of properties with one query
+func attachAmenities(ctx context.Context, repo domain.AmenityRepository, properties ...*domain.Property) error {
+	if repo == nil || len(properties) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(properties))
+	for i, p := range properties {
+		ids[i] = p.ID
+	}
+	amenities, err := repo.FetchByProperties(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, p := range properties {
+		p.Amenities = amenities[p.ID]
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"nusatek-backend/internal/domain"
+	"nusatek-backend/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAmenityRepo struct {
+	mock.Mock
+}
+
+func (m *MockAmenityRepo) Fetch(ctx context.Context) ([]domain.Amenity, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Amenity), args.Error(1)
+}
+func (m *MockAmenityRepo) GetByID(ctx context.Context, id int64) (domain.Amenity, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.Amenity), args.Error(1)
+}
+func (m *MockAmenityRepo) FetchByCodes(ctx context.Context, codes []string) ([]domain.Amenity, error) {
+	args := m.Called(ctx, codes)
+	return args.Get(0).([]domain.Amenity), args.Error(1)
+}
+func (m *MockAmenityRepo) Store(ctx context.Context, a *domain.Amenity) error {
+	args := m.Called(ctx, a)
+	return args.Error(0)
+}
+func (m *MockAmenityRepo) Update(ctx context.Context, a *domain.Amenity) error {
+	args := m.Called(ctx, a)
+	return args.Error(0)
+}
+func (m *MockAmenityRepo) Delete(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *MockAmenityRepo) FetchPropertyIDs(ctx context.Context, amenityID int64) ([]int64, error) {
+	args := m.Called(ctx, amenityID)
+	return args.Get(0).([]int64), args.Error(1)
+}
+func (m *MockAmenityRepo) FetchByProperties(ctx context.Context, propertyIDs []int64) (map[int64][]domain.PropertyAmenity, error) {
+	args := m.Called(ctx, propertyIDs)
+	return args.Get(0).(map[int64][]domain.PropertyAmenity), args.Error(1)
+}
+func (m *MockAmenityRepo) SetForProperty(ctx context.Context, propertyID int64, amenities []domain.PropertyAmenity) error {
+	args := m.Called(ctx, propertyID, amenities)
+	return args.Error(0)
+}
+
+func TestUpdateAmenities(t *testing.T) {
+	ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 4, TenantID: 2})
+	catalogue := []domain.Amenity{
+		{ID: 1, Code: "pln", Name: "Listrik PLN", Category: domain.AmenityUtility, Unit: "VA"},
+		{ID: 2, Code: "pool", Name: "Kolam renang", Category: domain.AmenityFacility},
+	}
+	current := map[int64][]domain.PropertyAmenity{9: {{AmenityID: 2, Code: "pool", Name: "Kolam renang", Category: domain.AmenityFacility}}}
+	property := func(amenities ...domain.PropertyAmenity) *domain.Property {
+		return &domain.Property{ID: 9, Title: "Rumah", PropertyType: domain.PropertyTypeHouse, ListingType: domain.ListingSale, Amenities: amenities}
+	}
+
+	t.Run("replaces the amenities given", func(t *testing.T) {
+		mockRepo := new(MockPropertyRepo)
+		mockCache := new(MockCacheRepo)
+		mockAmenities := new(MockAmenityRepo)
+		u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: mockCache, Authorizer: allowAll{}, Amenities: mockAmenities, Timeout: 2 * time.Second})
+
+		mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, AgentID: 4}, nil).Once()
+		mockAmenities.On("FetchByCodes", mock.Anything, []string{"pool", "pln"}).Return(catalogue, nil).Once()
+		mockAmenities.On("FetchByProperties", mock.Anything, []int64{9}).Return(current, nil).Once()
+		mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Property")).Return(nil).Once()
+		mockCache.On("Delete", mock.Anything, "property:9").Return(nil).Once()
+		want := []domain.PropertyAmenity{
+			{AmenityID: 1, Code: "pln", Name: "Listrik PLN", Category: domain.AmenityUtility, Unit: "VA", Value: 2200},
+			{AmenityID: 2, Code: "pool", Name: "Kolam renang", Category: domain.AmenityFacility},
+		}
+		mockAmenities.On("SetForProperty", mock.Anything, int64(9), want).Return(nil).Once()
+
+		p := property(domain.PropertyAmenity{Code: "pool"}, domain.PropertyAmenity{Code: "pln", Value: 2200})
+		assert.NoError(t, u.Update(ctx, p))
+		assert.Equal(t, want, p.Amenities)
+		mockAmenities.AssertExpectations(t)
+	})
+
+	t.Run("keeps the amenities when left out", func(t *testing.T) {
+		mockRepo := new(MockPropertyRepo)
+		mockCache := new(MockCacheRepo)
+		mockAmenities := new(MockAmenityRepo)
+		u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: mockCache, Authorizer: allowAll{}, Amenities: mockAmenities, Timeout: 2 * time.Second})
+
+		mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, AgentID: 4}, nil).Once()
+		mockAmenities.On("FetchByProperties", mock.Anything, []int64{9}).Return(current, nil).Once()
+		mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Property")).Return(nil).Once()
+		mockCache.On("Delete", mock.Anything, "property:9").Return(nil).Once()
+
+		p := property()
+		assert.NoError(t, u.Update(ctx, p))
+		assert.Equal(t, current[9], p.Amenities)
+		mockAmenities.AssertNotCalled(t, "SetForProperty", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("fails the update when the amenities cannot be written", func(t *testing.T) {
+		mockRepo := new(MockPropertyRepo)
+		mockCache := new(MockCacheRepo)
+		mockAmenities := new(MockAmenityRepo)
+		u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: mockCache, Authorizer: allowAll{}, Amenities: mockAmenities, Timeout: 2 * time.Second})
+
+		mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, AgentID: 4}, nil).Once()
+		mockAmenities.On("FetchByCodes", mock.Anything, []string{"pool"}).Return(catalogue, nil).Once()
+		mockAmenities.On("FetchByProperties", mock.Anything, []int64{9}).Return(current, nil).Once()
+		mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Property")).Return(nil).Once()
+		mockAmenities.On("SetForProperty", mock.Anything, int64(9), mock.Anything).Return(errors.New("connection reset")).Once()
+
+		assert.Error(t, u.Update(ctx, property(domain.PropertyAmenity{Code: "pool"})))
+		mockCache.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects unknown amenities and values", func(t *testing.T) {
+		for _, amenities := range [][]domain.PropertyAmenity{
+			{{Code: "helipad"}},
+			{{Code: "pool", Value: 1}},
+			{{Code: "pool"}, {Code: "pool"}},
+		} {
+			mockRepo := new(MockPropertyRepo)
+			mockAmenities := new(MockAmenityRepo)
+			u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: new(MockCacheRepo), Authorizer: allowAll{}, Amenities: mockAmenities, Timeout: 2 * time.Second})
+
+			mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, AgentID: 4}, nil).Once()
+			mockAmenities.On("FetchByCodes", mock.Anything, mock.Anything).Return(catalogue, nil).Maybe()
+
+			err := u.Update(ctx, property(amenities...))
+			assert.ErrorIs(t, err, domain.ErrInvalidProperty)
+			mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestAmenityStoreValidates(t *testing.T) {
+	ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 1, TenantID: 2})
+	mockAmenities := new(MockAmenityRepo)
+	u := usecase.NewAmenityUsecase(usecase.PropertyDeps{Amenities: mockAmenities, Authorizer: allowAll{}, Timeout: 2 * time.Second})
+
+	err := u.Store(ctx, &domain.Amenity{Code: "Near Mall", Name: "Dekat mal", Category: domain.AmenityAccess})
+	assert.ErrorIs(t, err, domain.ErrInvalidAmenity)
+
+	a := &domain.Amenity{Code: "near_mall", Name: " Dekat mal ", Category: domain.AmenityAccess}
+	mockAmenities.On("Store", mock.Anything, a).Return(nil).Once()
+	assert.NoError(t, u.Store(ctx, a))
+	assert.Equal(t, "Dekat mal", a.Name)
+	mockAmenities.AssertExpectations(t)
+}
+
+func TestAmenityDeleteUpdatesProperties(t *testing.T) {
+	ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 1, TenantID: 2})
+	mockRepo := new(MockPropertyRepo)
+	mockCache := new(MockCacheRepo)
+	mockRevisions := new(MockRevisionRepo)
+	mockAmenities := new(MockAmenityRepo)
+	u := usecase.NewAmenityUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: mockCache, Revisions: mockRevisions, Amenities: mockAmenities, Authorizer: allowAll{}, Tx: fakeTx{}, Timeout: 2 * time.Second})
+
+	carport := []domain.PropertyAmenity{{AmenityID: 3, Code: "carport", Name: "Carport", Category: domain.AmenityFacility}}
+	mockAmenities.On("FetchPropertyIDs", mock.MatchedBy(inTx), int64(2)).Return([]int64{9, 11}, nil).Once()
+	mockAmenities.On("Delete", mock.MatchedBy(inTx), int64(2)).Return(nil).Once()
+	mockRepo.On("GetByID", mock.MatchedBy(inTx), int64(9)).Return(domain.Property{ID: 9, Title: "Rumah"}, nil).Once()
+	// Property 11 is in the trash
+	mockRepo.On("GetByID", mock.MatchedBy(inTx), int64(11)).Return(domain.Property{}, domain.ErrPropertyNotFound).Once()
+	mockAmenities.On("FetchByProperties", mock.MatchedBy(inTx), []int64{9}).Return(map[int64][]domain.PropertyAmenity{9: carport}, nil).Once()
+	mockRevisions.On("Store", mock.MatchedBy(inTx), mock.MatchedBy(func(r *domain.PropertyRevision) bool {
+		return r.PropertyID == 9 && assert.ObjectsAreEqual(carport, r.Property.Amenities)
+	})).Return(nil).Once()
+	mockCache.On("Delete", mock.Anything, "property:9").Return(nil).Once()
+	mockCache.On("Delete", mock.Anything, "property:11").Return(nil).Once()
+
+	assert.NoError(t, u.Delete(ctx, 2))
+	mockAmenities.AssertExpectations(t)
+	mockRevisions.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
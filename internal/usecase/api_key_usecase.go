@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"nusatek-backend/internal/domain"
+)
+
+// apiKeyDisplayLength is how much of the key is kept in clear for display
+const apiKeyDisplayLength = 12
+
+// apiKeyScopes are the scopes a key may be issued with. Managing users and
+// keys always requires a signed-in person.
+var apiKeyScopes = map[string]bool{
+	domain.PermPropertiesRead:   true,
+	domain.PermPropertiesCreate: true,
+	domain.PermPropertiesUpdate: true,
+	domain.PermPropertiesDelete: true,
+	"properties:write":          true,
+	"properties:*":              true,
+	domain.PermCustomersRead:    true,
+	domain.PermCustomersCreate:  true,
+	domain.PermCustomersUpdate:  true,
+	domain.PermCustomersDelete:  true,
+	"customers:write":           true,
+	"customers:*":               true,
+	domain.PermWebhooksManage:   true,
+}
+
+type apiKeyUsecase struct {
+	apiKeyRepo domain.APIKeyRepository
+	authorizer domain.Authorizer
+	timeout    time.Duration
+}
+
+func NewAPIKeyUsecase(k domain.APIKeyRepository, az domain.Authorizer, timeout time.Duration) domain.APIKeyUsecase {
+	return &apiKeyUsecase{
+		apiKeyRepo: k,
+		authorizer: az,
+		timeout:    timeout,
+	}
+}
+
+func (u *apiKeyUsecase) Fetch(c context.Context) ([]domain.APIKey, error) {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizer.Authorize(ctx, domain.PermAPIKeysManage, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	return u.apiKeyRepo.Fetch(ctx)
+}
+
+func (u *apiKeyUsecase) Issue(c context.Context, k *domain.APIKey) (domain.IssuedAPIKey, error) {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizer.Authorize(ctx, domain.PermAPIKeysManage, domain.Resource{}); err != nil {
+		return domain.IssuedAPIKey{}, err
+	}
+
+	k.Name = strings.TrimSpace(k.Name)
+	if k.Name == "" {
+		return domain.IssuedAPIKey{}, fmt.Errorf("%w: name is required", domain.ErrInvalidAPIKey)
+	}
+	if len(k.Scopes) == 0 {
+		return domain.IssuedAPIKey{}, fmt.Errorf("%w: at least one scope is required", domain.ErrInvalidAPIKey)
+	}
+	for _, s := range k.Scopes {
+		if !apiKeyScopes[s] {
+			return domain.IssuedAPIKey{}, fmt.Errorf("%w: unknown scope %q", domain.ErrInvalidAPIKey, s)
+		}
+	}
+	if k.ExpiresAt != nil && !k.ExpiresAt.After(time.Now()) {
+		return domain.IssuedAPIKey{}, fmt.Errorf("%w: expires_at must be in the future", domain.ErrInvalidAPIKey)
+	}
+
+	principal, _ := domain.PrincipalFromContext(ctx)
+	k.CreatedBy = principal.UserID
+
+	key, err := newAPIKey()
+	if err != nil {
+		return domain.IssuedAPIKey{}, err
+	}
+	k.Prefix = key[:apiKeyDisplayLength]
+	k.KeyHash = hashToken(key)
+
+	if err := u.apiKeyRepo.Store(ctx, k); err != nil {
+		return domain.IssuedAPIKey{}, err
+	}
+	return domain.IssuedAPIKey{APIKey: *k, Key: key}, nil
+}
+
+func (u *apiKeyUsecase) Rotate(c context.Context, id int64) (domain.IssuedAPIKey, error) {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizer.Authorize(ctx, domain.PermAPIKeysManage, domain.Resource{}); err != nil {
+		return domain.IssuedAPIKey{}, err
+	}
+
+	k, err := u.apiKeyRepo.GetByID(ctx, id)
+	if err != nil {
+		return domain.IssuedAPIKey{}, err
+	}
+
+	key, err := newAPIKey()
+	if err != nil {
+		return domain.IssuedAPIKey{}, err
+	}
+	k.Prefix = key[:apiKeyDisplayLength]
+	k.KeyHash = hashToken(key)
+
+	// The previous key stops working as soon as this returns
+	if err := u.apiKeyRepo.Rotate(ctx, &k); err != nil {
+		return domain.IssuedAPIKey{}, err
+	}
+	return domain.IssuedAPIKey{APIKey: k, Key: key}, nil
+}
+
+func (u *apiKeyUsecase) Revoke(c context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizer.Authorize(ctx, domain.PermAPIKeysManage, domain.Resource{}); err != nil {
+		return err
+	}
+	return u.apiKeyRepo.Revoke(ctx, id)
+}
+
+func (u *apiKeyUsecase) Authenticate(c context.Context, key string) (domain.Principal, error) {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if !strings.HasPrefix(key, domain.APIKeyPrefix) {
+		return domain.Principal{}, domain.ErrInvalidAPIKey
+	}
+
+	k, err := u.apiKeyRepo.GetByHash(ctx, hashToken(key))
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		return domain.Principal{}, domain.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return domain.Principal{}, err
+	}
+
+	now := time.Now().UTC()
+	if !k.Active(now) {
+		return domain.Principal{}, domain.ErrInvalidAPIKey
+	}
+
+	// Usage tracking is best effort and must not fail the request
+	if err := u.apiKeyRepo.TouchLastUsed(ctx, k.ID, now); err != nil {
+		log.Printf("api key %d: failed to record last use: %v", k.ID, err)
+	}
+
+	return domain.Principal{
+		Kind:     domain.PrincipalAPIKey,
+		TenantID: k.TenantID,
+		APIKeyID: k.ID,
+		Scopes:   k.Scopes,
+	}, nil
+}
+
+func newAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return domain.APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
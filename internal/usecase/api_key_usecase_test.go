@@ -0,0 +1,112 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"nusatek-backend/internal/domain"
+	"nusatek-backend/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockAPIKeyRepo struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepo) Fetch(ctx context.Context) ([]domain.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) GetByID(ctx context.Context, id int64) (domain.APIKey, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) GetByHash(ctx context.Context, keyHash string) (domain.APIKey, error) {
+	args := m.Called(ctx, keyHash)
+	return args.Get(0).(domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) Store(ctx context.Context, k *domain.APIKey) error {
+	args := m.Called(ctx, k)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepo) Rotate(ctx context.Context, k *domain.APIKey) error {
+	args := m.Called(ctx, k)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepo) Revoke(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepo) TouchLastUsed(ctx context.Context, id int64, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func TestAPIKeyAuthenticate(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepo)
+	u := usecase.NewAPIKeyUsecase(mockRepo, allowAll{}, 2*time.Second)
+	admin := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 1, Role: domain.RoleAdmin})
+
+	var stored domain.APIKey
+	mockRepo.On("Store", mock.Anything, mock.AnythingOfType("*domain.APIKey")).Run(func(args mock.Arguments) {
+		k := args.Get(1).(*domain.APIKey)
+		k.ID = 5
+		stored = *k
+	}).Return(nil).Once()
+
+	issued, err := u.Issue(admin, &domain.APIKey{Name: "Portal", Scopes: []string{domain.PermPropertiesRead}})
+	require.NoError(t, err)
+	assert.Contains(t, issued.Key, domain.APIKeyPrefix)
+	assert.NotEqual(t, issued.Key, stored.KeyHash)
+	assert.Equal(t, issued.Key[:len(stored.Prefix)], stored.Prefix)
+
+	t.Run("valid key", func(t *testing.T) {
+		mockRepo.On("GetByHash", mock.Anything, stored.KeyHash).Return(stored, nil).Once()
+		mockRepo.On("TouchLastUsed", mock.Anything, int64(5), mock.Anything).Return(nil).Once()
+
+		p, err := u.Authenticate(context.Background(), issued.Key)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), p.APIKeyID)
+		assert.Equal(t, []string{domain.PermPropertiesRead}, p.Scopes)
+		// The key acts for the integration, not for the admin who issued it
+		assert.True(t, p.IsAPIKey())
+		assert.Zero(t, p.UserID)
+		assert.Empty(t, p.Role)
+	})
+
+	t.Run("revoked key", func(t *testing.T) {
+		revoked := stored
+		now := time.Now()
+		revoked.RevokedAt = &now
+		mockRepo.On("GetByHash", mock.Anything, stored.KeyHash).Return(revoked, nil).Once()
+
+		_, err := u.Authenticate(context.Background(), issued.Key)
+		assert.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+	})
+
+	t.Run("unknown scope", func(t *testing.T) {
+		_, err := u.Issue(admin, &domain.APIKey{Name: "Portal", Scopes: []string{domain.PermUsersManage}})
+		assert.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+	})
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestScopeAllows(t *testing.T) {
+	assert.True(t, domain.ScopeAllows([]string{"properties:read"}, domain.PermPropertiesRead))
+	assert.False(t, domain.ScopeAllows([]string{"properties:read"}, domain.PermPropertiesUpdate))
+	assert.True(t, domain.ScopeAllows([]string{"customers:write"}, domain.PermCustomersCreate))
+	assert.False(t, domain.ScopeAllows([]string{"customers:write"}, domain.PermCustomersRead))
+	assert.True(t, domain.ScopeAllows([]string{"customers:*"}, domain.PermCustomersRead))
+	assert.False(t, domain.ScopeAllows([]string{"customers:*"}, domain.PermPropertiesRead))
+}
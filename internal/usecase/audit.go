@@ -0,0 +1,43 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"nusatek-backend/internal/domain"
+)
+
+// recordAudit appends an audit entry for a mutation, taking the actor,
+// tenant and request id from ctx. before and after may be nil. It runs in the
+// mutation's transaction, so a failed write rolls the change back with it.
+func recordAudit(ctx context.Context, repo domain.AuditRepository, action string, entity string, entityID int64, before interface{}, after interface{}) error {
+	if repo == nil {
+		return nil
+	}
+
+	e := domain.AuditEntry{
+		Action:    action,
+		Entity:    entity,
+		EntityID:  entityID,
+		RequestID: domain.RequestIDFromContext(ctx),
+	}
+	e.TenantID, _ = domain.TenantFromContext(ctx)
+	if p, ok := domain.PrincipalFromContext(ctx); ok {
+		e.ActorID = p.UserID
+		e.APIKeyID = p.APIKeyID
+	}
+
+	var err error
+	if before != nil {
+		if e.Before, err = json.Marshal(before); err != nil {
+			return fmt.Errorf("audit: %s %s %d: %w", action, entity, entityID, err)
+		}
+	}
+	if after != nil {
+		if e.After, err = json.Marshal(after); err != nil {
+			return fmt.Errorf("audit: %s %s %d: %w", action, entity, entityID, err)
+		}
+	}
+	return repo.Store(ctx, &e)
+}
@@ -0,0 +1,32 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"nusatek-backend/internal/domain"
+)
+
+type auditUsecase struct {
+	auditRepo  domain.AuditRepository
+	authorizer domain.Authorizer
+	timeout    time.Duration
+}
+
+func NewAuditUsecase(a domain.AuditRepository, az domain.Authorizer, timeout time.Duration) domain.AuditUsecase {
+	return &auditUsecase{
+		auditRepo:  a,
+		authorizer: az,
+		timeout:    timeout,
+	}
+}
+
+func (u *auditUsecase) Fetch(c context.Context, f domain.AuditFilter) ([]domain.AuditEntry, error) {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizer.Authorize(ctx, domain.PermAuditRead, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	return u.auditRepo.Fetch(ctx, f)
+}
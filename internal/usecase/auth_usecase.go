@@ -0,0 +1,290 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"nusatek-backend/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const tokenIssuer = "nusatek-backend"
+
+// Stream tokens carry streamAudience, which access tokens lack, so neither is
+// accepted in place of the other. They only need to last until the stream is
+// open, as it stays open after they expire.
+const (
+	streamAudience = "stream"
+	streamTokenTTL = time.Minute
+)
+
+// dummyHash is compared against when a login email is unknown, so that
+// unknown and known emails take the same time to reject.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("nusatek-dummy-password"), bcrypt.DefaultCost)
+
+type accessClaims struct {
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+	TenantID int64  `json:"tenant_id"`
+	BranchID int64  `json:"branch_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type authUsecase struct {
+	userRepo   domain.UserRepository
+	tokenRepo  domain.TokenRepository
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	timeout    time.Duration
+}
+
+func NewAuthUsecase(u domain.UserRepository, t domain.TokenRepository, secret string, accessTTL, refreshTTL, timeout time.Duration) domain.AuthUsecase {
+	return &authUsecase{
+		userRepo:   u,
+		tokenRepo:  t,
+		secret:     []byte(secret),
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+		timeout:    timeout,
+	}
+}
+
+func (a *authUsecase) Login(c context.Context, email string, password string) (domain.TokenPair, error) {
+	ctx, cancel := context.WithTimeout(c, a.timeout)
+	defer cancel()
+
+	user, err := a.userRepo.GetByEmail(ctx, email)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return domain.TokenPair{}, domain.ErrInvalidCredentials
+	}
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return domain.TokenPair{}, domain.ErrInvalidCredentials
+	}
+
+	return a.issue(ctx, user, newTokenID())
+}
+
+func (a *authUsecase) Refresh(c context.Context, refreshToken string) (domain.TokenPair, error) {
+	ctx, cancel := context.WithTimeout(c, a.timeout)
+	defer cancel()
+
+	tokenHash := hashToken(refreshToken)
+	session, err := a.tokenRepo.ConsumeRefresh(ctx, tokenHash, a.refreshTTL)
+	if errors.Is(err, domain.ErrInvalidToken) {
+		// A token that was already rotated is being replayed: assume it was
+		// stolen and revoke every token descended from the same login.
+		if used, usedErr := a.tokenRepo.UsedRefresh(ctx, tokenHash); usedErr == nil {
+			_ = a.tokenRepo.RevokeFamily(ctx, used.Family)
+		}
+		return domain.TokenPair{}, domain.ErrInvalidToken
+	}
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+
+	revoked, err := a.tokenRepo.FamilyRevoked(ctx, session.Family)
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+	if revoked {
+		return domain.TokenPair{}, domain.ErrInvalidToken
+	}
+
+	user, err := a.userRepo.GetByID(ctx, session.UserID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return domain.TokenPair{}, domain.ErrInvalidToken
+	}
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+
+	return a.issue(ctx, user, session.Family)
+}
+
+func (a *authUsecase) Logout(c context.Context, p domain.Principal, refreshToken string) error {
+	ctx, cancel := context.WithTimeout(c, a.timeout)
+	defer cancel()
+
+	// API keys are revoked through their own endpoint
+	if p.TokenID == "" {
+		return domain.ErrInvalidToken
+	}
+
+	if err := a.tokenRepo.RevokeAccess(ctx, p.TokenID, time.Until(p.ExpiresAt)); err != nil {
+		return err
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+	session, err := a.tokenRepo.ConsumeRefresh(ctx, hashToken(refreshToken), a.refreshTTL)
+	if errors.Is(err, domain.ErrInvalidToken) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if session.UserID != p.UserID {
+		return domain.ErrInvalidToken
+	}
+	return a.tokenRepo.RevokeFamily(ctx, session.Family)
+}
+
+func (a *authUsecase) Authenticate(c context.Context, accessToken string) (domain.Principal, error) {
+	return a.authenticate(c, accessToken, "")
+}
+
+func (a *authUsecase) AuthenticateStream(c context.Context, streamToken string) (domain.Principal, error) {
+	return a.authenticate(c, streamToken, streamAudience)
+}
+
+// StreamToken is only issued to people: integrations can send headers
+func (a *authUsecase) StreamToken(c context.Context, p domain.Principal) (domain.StreamToken, error) {
+	if p.IsAPIKey() || p.UserID == 0 {
+		return domain.StreamToken{}, domain.ErrForbidden
+	}
+
+	now := time.Now()
+	expires := now.Add(streamTokenTTL)
+	if !p.ExpiresAt.IsZero() && p.ExpiresAt.Before(expires) {
+		expires = p.ExpiresAt
+	}
+	claims := accessClaims{
+		Email:    p.Email,
+		Role:     p.Role,
+		TenantID: p.TenantID,
+		BranchID: p.BranchID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:   tokenIssuer,
+			Subject:  strconv.FormatInt(p.UserID, 10),
+			Audience: jwt.ClaimStrings{streamAudience},
+			// Sharing the access token's ID revokes both on logout
+			ID:        p.TokenID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expires),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(a.secret)
+	if err != nil {
+		return domain.StreamToken{}, err
+	}
+	return domain.StreamToken{Token: token, ExpiresIn: int64(expires.Sub(now).Seconds())}, nil
+}
+
+// authenticate checks a token issued for audience, empty for access tokens
+func (a *authUsecase) authenticate(c context.Context, token string, audience string) (domain.Principal, error) {
+	ctx, cancel := context.WithTimeout(c, a.timeout)
+	defer cancel()
+
+	var claims accessClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return a.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(tokenIssuer), jwt.WithExpirationRequired())
+	if err != nil {
+		return domain.Principal{}, domain.ErrInvalidToken
+	}
+	if strings.Join(claims.Audience, " ") != audience {
+		return domain.Principal{}, domain.ErrInvalidToken
+	}
+
+	userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return domain.Principal{}, domain.ErrInvalidToken
+	}
+	// Tokens issued before tenants existed must be refreshed
+	if claims.TenantID == 0 {
+		return domain.Principal{}, domain.ErrInvalidToken
+	}
+
+	revoked, err := a.tokenRepo.AccessRevoked(ctx, claims.ID)
+	if err != nil {
+		return domain.Principal{}, err
+	}
+	if revoked {
+		return domain.Principal{}, domain.ErrInvalidToken
+	}
+
+	return domain.Principal{
+		Kind:      domain.PrincipalUser,
+		UserID:    userID,
+		Email:     claims.Email,
+		Role:      claims.Role,
+		TenantID:  claims.TenantID,
+		BranchID:  claims.BranchID,
+		TokenID:   claims.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+// issue creates a new access token and a refresh token in the given family
+func (a *authUsecase) issue(ctx context.Context, user domain.User, family string) (domain.TokenPair, error) {
+	now := time.Now()
+	claims := accessClaims{
+		Email:    user.Email,
+		Role:     user.Role,
+		TenantID: user.TenantID,
+		BranchID: user.BranchID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Subject:   strconv.FormatInt(user.ID, 10),
+			ID:        newTokenID(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(a.accessTTL)),
+		},
+	}
+	access, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(a.secret)
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+
+	refresh, err := newRefreshToken()
+	if err != nil {
+		return domain.TokenPair{}, err
+	}
+	session := domain.RefreshSession{UserID: user.ID, Family: family}
+	if err := a.tokenRepo.StoreRefresh(ctx, hashToken(refresh), session, a.refreshTTL); err != nil {
+		return domain.TokenPair{}, err
+	}
+
+	return domain.TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(a.accessTTL.Seconds()),
+	}, nil
+}
+
+func newTokenID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func newRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Refresh tokens are only stored hashed
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,174 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"nusatek-backend/internal/domain"
+	"nusatek-backend/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type MockUserRepo struct {
+	mock.Mock
+}
+
+func (m *MockUserRepo) GetByID(ctx context.Context, id int64) (domain.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.User), args.Error(1)
+}
+func (m *MockUserRepo) GetByEmail(ctx context.Context, email string) (domain.User, error) {
+	args := m.Called(ctx, email)
+	return args.Get(0).(domain.User), args.Error(1)
+}
+func (m *MockUserRepo) Store(ctx context.Context, u *domain.User) error {
+	args := m.Called(ctx, u)
+	return args.Error(0)
+}
+func (m *MockUserRepo) Count(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// memoryTokenRepo is an in-memory domain.TokenRepository
+type memoryTokenRepo struct {
+	refresh, used   map[string]domain.RefreshSession
+	revokedFamilies map[string]bool
+	revokedAccess   map[string]bool
+}
+
+func newMemoryTokenRepo() *memoryTokenRepo {
+	return &memoryTokenRepo{
+		refresh:         map[string]domain.RefreshSession{},
+		used:            map[string]domain.RefreshSession{},
+		revokedFamilies: map[string]bool{},
+		revokedAccess:   map[string]bool{},
+	}
+}
+
+func (m *memoryTokenRepo) StoreRefresh(ctx context.Context, hash string, s domain.RefreshSession, ttl time.Duration) error {
+	m.refresh[hash] = s
+	return nil
+}
+func (m *memoryTokenRepo) ConsumeRefresh(ctx context.Context, hash string, ttl time.Duration) (domain.RefreshSession, error) {
+	s, ok := m.refresh[hash]
+	if !ok {
+		return s, domain.ErrInvalidToken
+	}
+	delete(m.refresh, hash)
+	m.used[hash] = s
+	return s, nil
+}
+func (m *memoryTokenRepo) UsedRefresh(ctx context.Context, hash string) (domain.RefreshSession, error) {
+	s, ok := m.used[hash]
+	if !ok {
+		return s, domain.ErrInvalidToken
+	}
+	return s, nil
+}
+func (m *memoryTokenRepo) RevokeFamily(ctx context.Context, family string) error {
+	m.revokedFamilies[family] = true
+	return nil
+}
+func (m *memoryTokenRepo) FamilyRevoked(ctx context.Context, family string) (bool, error) {
+	return m.revokedFamilies[family], nil
+}
+func (m *memoryTokenRepo) RevokeAccess(ctx context.Context, id string, ttl time.Duration) error {
+	m.revokedAccess[id] = true
+	return nil
+}
+func (m *memoryTokenRepo) AccessRevoked(ctx context.Context, id string) (bool, error) {
+	return m.revokedAccess[id], nil
+}
+
+func TestAuthRefreshRotation(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret-password"), bcrypt.MinCost)
+	user := domain.User{ID: 3, Email: "agent@nusatek.id", TenantID: 2, PasswordHash: string(hash)}
+
+	mockUsers := new(MockUserRepo)
+	mockUsers.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	mockUsers.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	tokens := newMemoryTokenRepo()
+
+	u := usecase.NewAuthUsecase(mockUsers, tokens, "test-secret", time.Minute, time.Hour, 2*time.Second)
+	ctx := context.Background()
+
+	t.Run("wrong password", func(t *testing.T) {
+		_, err := u.Login(ctx, user.Email, "wrong-password")
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+	})
+
+	first, err := u.Login(ctx, user.Email, "secret-password")
+	assert.NoError(t, err)
+
+	principal, err := u.Authenticate(ctx, first.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, principal.UserID)
+	assert.Equal(t, user.TenantID, principal.TenantID)
+
+	second, err := u.Refresh(ctx, first.RefreshToken)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+	t.Run("reused refresh token revokes the family", func(t *testing.T) {
+		_, err := u.Refresh(ctx, first.RefreshToken)
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+
+		_, err = u.Refresh(ctx, second.RefreshToken)
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+	})
+
+	t.Run("logout revokes the access token", func(t *testing.T) {
+		assert.NoError(t, u.Logout(ctx, principal, ""))
+		_, err := u.Authenticate(ctx, first.AccessToken)
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+	})
+}
+
+func TestStreamToken(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret-password"), bcrypt.MinCost)
+	user := domain.User{ID: 3, Email: "agent@nusatek.id", TenantID: 2, PasswordHash: string(hash)}
+
+	mockUsers := new(MockUserRepo)
+	mockUsers.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	tokens := newMemoryTokenRepo()
+
+	u := usecase.NewAuthUsecase(mockUsers, tokens, "test-secret", 15*time.Minute, time.Hour, 2*time.Second)
+	ctx := context.Background()
+
+	pair, err := u.Login(ctx, user.Email, "secret-password")
+	assert.NoError(t, err)
+	principal, err := u.Authenticate(ctx, pair.AccessToken)
+	assert.NoError(t, err)
+
+	stream, err := u.StreamToken(ctx, principal)
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, stream.ExpiresIn, int64(60))
+
+	p, err := u.AuthenticateStream(ctx, stream.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, p.UserID)
+	assert.Equal(t, user.TenantID, p.TenantID)
+
+	t.Run("tokens only work for their purpose", func(t *testing.T) {
+		_, err := u.Authenticate(ctx, stream.Token)
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+		_, err = u.AuthenticateStream(ctx, pair.AccessToken)
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+	})
+
+	t.Run("API keys use headers", func(t *testing.T) {
+		_, err := u.StreamToken(ctx, domain.Principal{Kind: domain.PrincipalAPIKey, APIKeyID: 5, TenantID: 2})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("logout revokes stream tokens", func(t *testing.T) {
+		assert.NoError(t, u.Logout(ctx, principal, ""))
+		_, err := u.AuthenticateStream(ctx, stream.Token)
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+	})
+}
@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"nusatek-backend/internal/domain"
+)
+
+type cachedGrants struct {
+	grants   []domain.Grant
+	loadedAt time.Time
+}
+
+// rbacAuthorizer checks the principal's role grants stored in PostgreSQL.
+// Grants change rarely, so they are cached per role for cacheTTL.
+type rbacAuthorizer struct {
+	permissionRepo domain.PermissionRepository
+	cacheTTL       time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedGrants
+}
+
+func NewAuthorizer(p domain.PermissionRepository, cacheTTL time.Duration) domain.Authorizer {
+	return &rbacAuthorizer{
+		permissionRepo: p,
+		cacheTTL:       cacheTTL,
+		cache:          make(map[string]cachedGrants),
+	}
+}
+
+func (a *rbacAuthorizer) Authorize(ctx context.Context, permission string, resource domain.Resource) error {
+	principal, ok := domain.PrincipalFromContext(ctx)
+	if !ok {
+		return domain.ErrForbidden
+	}
+
+	// API keys act for an integration, not a person, so only their scopes apply
+	if principal.IsAPIKey() {
+		if domain.ScopeAllows(principal.Scopes, permission) {
+			return nil
+		}
+		return domain.ErrForbidden
+	}
+
+	grants, err := a.grants(ctx, principal.Role)
+	if err != nil {
+		return err
+	}
+
+	for _, g := range grants {
+		if g.Permission != permission {
+			continue
+		}
+		switch g.Scope {
+		case domain.ScopeAny:
+			return nil
+		case domain.ScopeBranch:
+			if principal.BranchID != 0 && resource.BranchID == principal.BranchID {
+				return nil
+			}
+			// Records in no branch can still be edited by their owner
+			if resource.OwnerID == principal.UserID {
+				return nil
+			}
+		case domain.ScopeOwn:
+			if resource.OwnerID == principal.UserID {
+				return nil
+			}
+		}
+	}
+	return domain.ErrForbidden
+}
+
+func (a *rbacAuthorizer) grants(ctx context.Context, role string) ([]domain.Grant, error) {
+	a.mu.Lock()
+	cached, ok := a.cache[role]
+	a.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < a.cacheTTL {
+		return cached.grants, nil
+	}
+
+	grants, err := a.permissionRepo.FetchGrants(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	a.cache[role] = cachedGrants{grants: grants, loadedAt: time.Now()}
+	a.mu.Unlock()
+	return grants, nil
+}
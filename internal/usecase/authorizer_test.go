@@ -0,0 +1,65 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"nusatek-backend/internal/domain"
+	"nusatek-backend/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPermissionRepo struct {
+	mock.Mock
+}
+
+func (m *MockPermissionRepo) FetchGrants(ctx context.Context, role string) ([]domain.Grant, error) {
+	args := m.Called(ctx, role)
+	return args.Get(0).([]domain.Grant), args.Error(1)
+}
+
+func TestAuthorizerScopes(t *testing.T) {
+	mockRepo := new(MockPermissionRepo)
+	mockRepo.On("FetchGrants", mock.Anything, domain.RoleAgent).Return([]domain.Grant{
+		{Permission: domain.PermPropertiesUpdate, Scope: domain.ScopeOwn},
+	}, nil).Once()
+	mockRepo.On("FetchGrants", mock.Anything, domain.RoleManager).Return([]domain.Grant{
+		{Permission: domain.PermPropertiesUpdate, Scope: domain.ScopeBranch},
+	}, nil).Once()
+
+	az := usecase.NewAuthorizer(mockRepo, time.Minute)
+
+	agent := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 7, Role: domain.RoleAgent, BranchID: 1})
+	manager := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 9, Role: domain.RoleManager, BranchID: 1})
+
+	t.Run("agent edits own listing", func(t *testing.T) {
+		assert.NoError(t, az.Authorize(agent, domain.PermPropertiesUpdate, domain.Resource{OwnerID: 7, BranchID: 1}))
+	})
+
+	t.Run("agent cannot edit a colleague's listing", func(t *testing.T) {
+		err := az.Authorize(agent, domain.PermPropertiesUpdate, domain.Resource{OwnerID: 8, BranchID: 1})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("manager edits listings in their branch only", func(t *testing.T) {
+		assert.NoError(t, az.Authorize(manager, domain.PermPropertiesUpdate, domain.Resource{OwnerID: 8, BranchID: 1}))
+		err := az.Authorize(manager, domain.PermPropertiesUpdate, domain.Resource{OwnerID: 8, BranchID: 2})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("ungranted permission", func(t *testing.T) {
+		err := az.Authorize(agent, domain.PermUsersManage, domain.Resource{})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("no principal", func(t *testing.T) {
+		err := az.Authorize(context.Background(), domain.PermPropertiesUpdate, domain.Resource{})
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	// Grants were served from cache after the first lookup per role
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"nusatek-backend/internal/domain"
+)
+
+// kindPermissions maps change kinds to the permission needed to read them
+var kindPermissions = map[string]string{
+	domain.ChangeKindProperty: domain.PermPropertiesRead,
+	domain.ChangeKindCustomer: domain.PermCustomersRead,
+}
+
+type changeUsecase struct {
+	changeRepo domain.ChangeRepository
+	authorizer domain.Authorizer
+	timeout    time.Duration
+}
+
+func NewChangeUsecase(c domain.ChangeRepository, az domain.Authorizer, timeout time.Duration) domain.ChangeUsecase {
+	return &changeUsecase{
+		changeRepo: c,
+		authorizer: az,
+		timeout:    timeout,
+	}
+}
+
+func (u *changeUsecase) FetchSince(c context.Context, token string, kinds []string, limit int) (domain.ChangeFeed, error) {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	cursor, err := decodeChangeToken(token)
+	if err != nil {
+		return domain.ChangeFeed{}, err
+	}
+	if len(kinds) == 0 {
+		kinds = []string{domain.ChangeKindProperty, domain.ChangeKindCustomer}
+	}
+	if err := authorizeKinds(ctx, u.authorizer, kinds); err != nil {
+		return domain.ChangeFeed{}, err
+	}
+
+	changes, err := u.changeRepo.FetchSince(ctx, cursor, kinds, limit)
+	if err != nil {
+		return domain.ChangeFeed{}, err
+	}
+
+	feed := domain.ChangeFeed{
+		Changes:   changes,
+		NextToken: token,
+		HasMore:   len(changes) == limit,
+	}
+	if feed.Changes == nil {
+		feed.Changes = []domain.Change{}
+	}
+	if len(changes) > 0 {
+		last := changes[len(changes)-1]
+		feed.NextToken = encodeChangeToken(domain.ChangeCursor{Seq: last.Seq, Kind: last.Kind, ID: last.ID})
+	}
+	return feed, nil
+}
+
+// authorizeKinds checks read access to every requested entity kind
+func authorizeKinds(ctx context.Context, az domain.Authorizer, kinds []string) error {
+	for _, kind := range kinds {
+		if permission, ok := kindPermissions[kind]; ok {
+			if err := az.Authorize(ctx, permission, domain.Resource{}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Tokens are opaque to clients: base64url("<seq>:<kind>:<id>")
+func encodeChangeToken(cursor domain.ChangeCursor) string {
+	raw := fmt.Sprintf("%d:%s:%d", cursor.Seq, cursor.Kind, cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeChangeToken(token string) (domain.ChangeCursor, error) {
+	if token == "" {
+		return domain.ChangeCursor{}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return domain.ChangeCursor{}, domain.ErrInvalidChangeToken
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 3 {
+		return domain.ChangeCursor{}, domain.ErrInvalidChangeToken
+	}
+	seq, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return domain.ChangeCursor{}, domain.ErrInvalidChangeToken
+	}
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return domain.ChangeCursor{}, domain.ErrInvalidChangeToken
+	}
+
+	return domain.ChangeCursor{Seq: seq, Kind: parts[1], ID: id}, nil
+}
@@ -0,0 +1,53 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"nusatek-backend/internal/domain"
+	"nusatek-backend/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockChangeRepo struct {
+	mock.Mock
+}
+
+func (m *MockChangeRepo) FetchSince(ctx context.Context, cursor domain.ChangeCursor, kinds []string, limit int) ([]domain.Change, error) {
+	args := m.Called(ctx, cursor, kinds, limit)
+	return args.Get(0).([]domain.Change), args.Error(1)
+}
+
+func TestChangeFeedContinuation(t *testing.T) {
+	mockRepo := new(MockChangeRepo)
+	u := usecase.NewChangeUsecase(mockRepo, allowAll{}, 2*time.Second)
+
+	changedAt := time.Date(2024, 5, 1, 10, 0, 0, 123456000, time.UTC)
+	allKinds := []string{domain.ChangeKindProperty, domain.ChangeKindCustomer}
+
+	mockRepo.On("FetchSince", mock.Anything, domain.ChangeCursor{}, allKinds, 2).Return([]domain.Change{
+		{Kind: domain.ChangeKindCustomer, ID: 4, Op: domain.ChangeCreated, ChangedAt: changedAt, Seq: 7410},
+		// Committed later, by a transaction that started earlier
+		{Kind: domain.ChangeKindProperty, ID: 9, Op: domain.ChangeDeleted, ChangedAt: changedAt.Add(-time.Second), Seq: 7412},
+	}, nil).Once()
+
+	first, err := u.FetchSince(context.Background(), "", nil, 2)
+	assert.NoError(t, err)
+	assert.True(t, first.HasMore)
+	assert.NotEmpty(t, first.NextToken)
+
+	next := domain.ChangeCursor{Seq: 7412, Kind: domain.ChangeKindProperty, ID: 9}
+	mockRepo.On("FetchSince", mock.Anything, next, allKinds, 2).Return([]domain.Change{}, nil).Once()
+
+	second, err := u.FetchSince(context.Background(), first.NextToken, nil, 2)
+	assert.NoError(t, err)
+	assert.False(t, second.HasMore)
+	assert.Equal(t, first.NextToken, second.NextToken)
+	mockRepo.AssertExpectations(t)
+
+	_, err = u.FetchSince(context.Background(), "not-a-token", nil, 2)
+	assert.ErrorIs(t, err, domain.ErrInvalidChangeToken)
+}
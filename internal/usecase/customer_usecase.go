@@ -4,16 +4,28 @@ import (
 	"context"
 	"nusatek-backend/internal/domain"
 	"time"
+
+	"nusatek-backend/pkg/rabbitmq"
 )
 
 type customerUsecase struct {
-	customerRepo domain.CustomerRepository
+	customerRepo   domain.CustomerRepository
+	mqChannel      rabbitmq.Publisher
+	streamRepo     domain.EventStreamRepository
+	authorizer     domain.Authorizer
+	auditRepo      domain.AuditRepository
+	tx             domain.Transactor
 	contextTimeout time.Duration
 }
 
-func NewCustomerUsecase(c domain.CustomerRepository, timeout time.Duration) domain.CustomerUsecase {
+func NewCustomerUsecase(c domain.CustomerRepository, mq rabbitmq.Publisher, s domain.EventStreamRepository, az domain.Authorizer, au domain.AuditRepository, tx domain.Transactor, timeout time.Duration) domain.CustomerUsecase {
 	return &customerUsecase{
 		customerRepo:   c,
+		mqChannel:      mq,
+		streamRepo:     s,
+		authorizer:     az,
+		auditRepo:      au,
+		tx:             tx,
 		contextTimeout: timeout,
 	}
 }
@@ -21,29 +33,127 @@ func NewCustomerUsecase(c domain.CustomerRepository, timeout time.Duration) doma
 func (du *customerUsecase) Fetch(c context.Context, limit int, offset int) ([]domain.Customer, error) {
 	ctx, cancel := context.WithTimeout(c, du.contextTimeout)
 	defer cancel()
+
+	if err := du.authorizer.Authorize(ctx, domain.PermCustomersRead, domain.Resource{}); err != nil {
+		return nil, err
+	}
 	return du.customerRepo.Fetch(ctx, limit, offset)
 }
 
 func (du *customerUsecase) GetByID(c context.Context, id int64) (domain.Customer, error) {
 	ctx, cancel := context.WithTimeout(c, du.contextTimeout)
 	defer cancel()
+
+	if err := du.authorizer.Authorize(ctx, domain.PermCustomersRead, domain.Resource{}); err != nil {
+		return domain.Customer{}, err
+	}
 	return du.customerRepo.GetByID(ctx, id)
 }
 
 func (du *customerUsecase) Store(c context.Context, m *domain.Customer) error {
 	ctx, cancel := context.WithTimeout(c, du.contextTimeout)
 	defer cancel()
-	return du.customerRepo.Store(ctx, m)
+
+	if err := du.authorizer.Authorize(ctx, domain.PermCustomersCreate, domain.Resource{}); err != nil {
+		return err
+	}
+
+	err := withinTx(ctx, du.tx, func(ctx context.Context) error {
+		if err := du.customerRepo.Store(ctx, m); err != nil {
+			return err
+		}
+		return recordAudit(ctx, du.auditRepo, domain.AuditCreate, domain.ChangeKindCustomer, m.ID, nil, m)
+	})
+	if err != nil {
+		return err
+	}
+
+	_ = publishEvent(ctx, du.mqChannel, du.streamRepo, "customer_events", domain.EventCustomerCreated, m.ID, m)
+	return nil
 }
 
 func (du *customerUsecase) Update(c context.Context, m *domain.Customer) error {
 	ctx, cancel := context.WithTimeout(c, du.contextTimeout)
 	defer cancel()
-	return du.customerRepo.Update(ctx, m)
+
+	if err := du.authorizer.Authorize(ctx, domain.PermCustomersUpdate, domain.Resource{}); err != nil {
+		return err
+	}
+
+	existing, err := du.customerRepo.GetByID(ctx, m.ID)
+	if err != nil {
+		return err
+	}
+
+	err = withinTx(ctx, du.tx, func(ctx context.Context) error {
+		if err := du.customerRepo.Update(ctx, m); err != nil {
+			return err
+		}
+		return recordAudit(ctx, du.auditRepo, domain.AuditUpdate, domain.ChangeKindCustomer, m.ID, existing, m)
+	})
+	if err != nil {
+		return err
+	}
+
+	_ = publishEvent(ctx, du.mqChannel, du.streamRepo, "customer_events", domain.EventCustomerUpdated, m.ID, m)
+	return nil
 }
 
 func (du *customerUsecase) Delete(c context.Context, id int64) error {
 	ctx, cancel := context.WithTimeout(c, du.contextTimeout)
 	defer cancel()
-	return du.customerRepo.Delete(ctx, id)
+
+	if err := du.authorizer.Authorize(ctx, domain.PermCustomersDelete, domain.Resource{}); err != nil {
+		return err
+	}
+
+	existing, err := du.customerRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = withinTx(ctx, du.tx, func(ctx context.Context) error {
+		if err := du.customerRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, du.auditRepo, domain.AuditDelete, domain.ChangeKindCustomer, id, existing, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	_ = publishEvent(ctx, du.mqChannel, du.streamRepo, "customer_events", domain.EventCustomerDeleted, id, map[string]int64{"id": id})
+	return nil
+}
+
+// Restore takes a customer out of the trash. It needs the same permission as Delete.
+func (du *customerUsecase) Restore(c context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(c, du.contextTimeout)
+	defer cancel()
+
+	if err := du.authorizer.Authorize(ctx, domain.PermCustomersDelete, domain.Resource{}); err != nil {
+		return err
+	}
+
+	trashed, err := du.customerRepo.GetTrashed(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	var restored domain.Customer
+	err = withinTx(ctx, du.tx, func(ctx context.Context) error {
+		if err := du.customerRepo.Restore(ctx, id); err != nil {
+			return err
+		}
+		if restored, err = du.customerRepo.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, du.auditRepo, domain.AuditRestore, domain.ChangeKindCustomer, id, trashed, restored)
+	})
+	if err != nil {
+		return err
+	}
+
+	_ = publishEvent(ctx, du.mqChannel, du.streamRepo, "customer_events", domain.EventCustomerRestored, id, restored)
+	return nil
 }
@@ -0,0 +1,95 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"nusatek-backend/internal/domain"
+	"nusatek-backend/internal/usecase"
+)
+
+type MockCustomerRepo struct {
+	mock.Mock
+}
+
+func (m *MockCustomerRepo) Fetch(ctx context.Context, limit int, offset int) ([]domain.Customer, error) {
+	args := m.Called(ctx, limit, offset)
+	return args.Get(0).([]domain.Customer), args.Error(1)
+}
+func (m *MockCustomerRepo) GetByID(ctx context.Context, id int64) (domain.Customer, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.Customer), args.Error(1)
+}
+func (m *MockCustomerRepo) Store(ctx context.Context, c *domain.Customer) error {
+	args := m.Called(ctx, c)
+	return args.Error(0)
+}
+func (m *MockCustomerRepo) Update(ctx context.Context, c *domain.Customer) error {
+	args := m.Called(ctx, c)
+	return args.Error(0)
+}
+func (m *MockCustomerRepo) Delete(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *MockCustomerRepo) FetchTrashed(ctx context.Context, limit int, offset int) ([]domain.Customer, error) {
+	args := m.Called(ctx, limit, offset)
+	return args.Get(0).([]domain.Customer), args.Error(1)
+}
+func (m *MockCustomerRepo) GetTrashed(ctx context.Context, id int64) (domain.Customer, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.Customer), args.Error(1)
+}
+func (m *MockCustomerRepo) Restore(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *MockCustomerRepo) Purge(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestRestoreCustomer(t *testing.T) {
+	ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 4, TenantID: 2})
+	deletedAt := time.Now().Add(-time.Hour)
+	trashed := domain.Customer{ID: 5, Name: "Siti", Email: "siti@example.com", DeletedAt: &deletedAt}
+
+	mockRepo := new(MockCustomerRepo)
+	mockAudit := new(MockAuditRepo)
+	mq := &queue{}
+	u := usecase.NewCustomerUsecase(mockRepo, mq, nil, allowAll{}, mockAudit, fakeTx{}, 2*time.Second)
+
+	mockRepo.On("GetTrashed", mock.Anything, int64(5)).Return(trashed, nil)
+	mockRepo.On("Restore", mock.MatchedBy(inTx), int64(5)).Return(nil).Once()
+	mockRepo.On("GetByID", mock.MatchedBy(inTx), int64(5)).Return(domain.Customer{ID: 5, Name: "Siti", Email: "siti@example.com"}, nil).Once()
+	mockAudit.On("Store", mock.MatchedBy(inTx), mock.MatchedBy(func(e *domain.AuditEntry) bool {
+		return e.Action == domain.AuditRestore && e.EntityID == 5
+	})).Return(nil).Once()
+
+	assert.NoError(t, u.Restore(ctx, 5))
+	assert.Len(t, mq.published, 1)
+	mockRepo.AssertExpectations(t)
+	mockAudit.AssertExpectations(t)
+}
+
+func TestRestoreCustomerEmailTaken(t *testing.T) {
+	ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 4, TenantID: 2})
+	mockRepo := new(MockCustomerRepo)
+	mockAudit := new(MockAuditRepo)
+	mq := &queue{}
+	u := usecase.NewCustomerUsecase(mockRepo, mq, nil, allowAll{}, mockAudit, fakeTx{}, 2*time.Second)
+
+	// The email went to a new customer while this one was in the trash
+	mockRepo.On("GetTrashed", mock.Anything, int64(5)).Return(domain.Customer{ID: 5, Email: "siti@example.com"}, nil)
+	mockRepo.On("Restore", mock.Anything, int64(5)).Return(domain.ErrCustomerEmailTaken).Once()
+	assert.ErrorIs(t, u.Restore(ctx, 5), domain.ErrCustomerEmailTaken)
+
+	mockRepo.On("GetTrashed", mock.Anything, int64(6)).Return(domain.Customer{}, domain.ErrNotInTrash)
+	assert.ErrorIs(t, u.Restore(ctx, 6), domain.ErrNotInTrash)
+
+	assert.Empty(t, mq.published)
+	mockAudit.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+}
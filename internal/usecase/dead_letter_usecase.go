@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+
+	"nusatek-backend/internal/domain"
+	"nusatek-backend/pkg/rabbitmq"
+
+	"github.com/streadway/amqp"
+)
+
+type deadLetterUsecase struct {
+	mqChannel  rabbitmq.Channel
+	authorizer domain.Authorizer
+}
+
+func NewDeadLetterUsecase(mq rabbitmq.Channel, az domain.Authorizer) domain.DeadLetterUsecase {
+	return &deadLetterUsecase{
+		mqChannel:  mq,
+		authorizer: az,
+	}
+}
+
+func (u *deadLetterUsecase) Fetch(c context.Context, limit int) ([]domain.DeadLetter, error) {
+	if err := u.authorize(c); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := rabbitmq.PeekMessages(u.mqChannel, rabbitmq.DeadLetterQueue, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	letters := make([]domain.DeadLetter, 0, len(deliveries))
+	for _, d := range deliveries {
+		letters = append(letters, toDeadLetter(d))
+	}
+	return letters, nil
+}
+
+func (u *deadLetterUsecase) Replay(c context.Context, ids []string) (int, error) {
+	if err := u.authorize(c); err != nil {
+		return 0, err
+	}
+
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	replayed, err := rabbitmq.ReplayMessages(u.mqChannel, rabbitmq.DeadLetterQueue, func(d amqp.Delivery) bool {
+		return wanted[rabbitmq.MessageID(d)]
+	})
+	if err != nil {
+		return replayed, err
+	}
+	if replayed == 0 && len(ids) > 0 {
+		return 0, domain.ErrDeadLetterNotFound
+	}
+	return replayed, nil
+}
+
+func (u *deadLetterUsecase) ReplayAll(c context.Context) (int, error) {
+	if err := u.authorize(c); err != nil {
+		return 0, err
+	}
+
+	return rabbitmq.ReplayMessages(u.mqChannel, rabbitmq.DeadLetterQueue, func(amqp.Delivery) bool {
+		return true
+	})
+}
+
+// authorize lets only operators of the platform near the queue, which holds
+// the events of every tenant, even if an agency's role was granted access
+func (u *deadLetterUsecase) authorize(ctx context.Context) error {
+	if err := u.authorizer.Authorize(ctx, domain.PermDeadLettersManage, domain.Resource{}); err != nil {
+		return err
+	}
+	if p, _ := domain.PrincipalFromContext(ctx); p.Role != domain.RolePlatformAdmin || p.IsAPIKey() {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+func toDeadLetter(d amqp.Delivery) domain.DeadLetter {
+	death := rabbitmq.Death(d)
+
+	body := json.RawMessage(d.Body)
+	if !json.Valid(d.Body) {
+		// Keep the response valid JSON even for non-JSON payloads
+		body, _ = json.Marshal(string(d.Body))
+	}
+
+	return domain.DeadLetter{
+		MessageID:  rabbitmq.MessageID(d),
+		Exchange:   death.Exchange,
+		RoutingKey: death.RoutingKey,
+		Queue:      death.Queue,
+		Reason:     death.Reason,
+		Attempts:   death.Count,
+		FailedAt:   death.Time,
+		Body:       body,
+	}
+}
@@ -0,0 +1,33 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"nusatek-backend/internal/domain"
+	"nusatek-backend/internal/usecase"
+	"nusatek-backend/pkg/rabbitmq"
+)
+
+func TestDeadLettersNeedPlatformAdmin(t *testing.T) {
+	// Without a broker, callers that get past authorization see ErrNoChannel
+	u := usecase.NewDeadLetterUsecase(nil, allowAll{})
+
+	for role, want := range map[string]error{
+		domain.RolePlatformAdmin: rabbitmq.ErrNoChannel,
+		domain.RoleAdmin:         domain.ErrForbidden,
+		domain.RoleManager:       domain.ErrForbidden,
+	} {
+		ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 1, TenantID: 2, Role: role})
+		_, err := u.Fetch(ctx, 10)
+		assert.ErrorIs(t, err, want, role)
+		_, err = u.Replay(ctx, []string{"a"})
+		assert.ErrorIs(t, err, want, role)
+		_, err = u.ReplayAll(ctx)
+		assert.ErrorIs(t, err, want, role)
+	}
+
+	_, err := u.Fetch(context.Background(), 10)
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+}
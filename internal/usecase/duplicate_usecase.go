@@ -0,0 +1,185 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"nusatek-backend/internal/domain"
+	"nusatek-backend/pkg/rabbitmq"
+)
+
+const (
+	// duplicateCandidates bounds the properties scored for one lookup
+	duplicateCandidates = 100
+	maxDuplicates       = 20
+)
+
+type duplicateUsecase struct {
+	propertyRepo domain.PropertyRepository
+	mediaRepo    domain.MediaRepository
+	cacheRepo    domain.PropertyCacheRepository
+	mqChannel    rabbitmq.Publisher
+	streamRepo   domain.EventStreamRepository
+	authorizer   domain.Authorizer
+	auditRepo    domain.AuditRepository
+	revisionRepo domain.PropertyRevisionRepository
+	amenityRepo  domain.AmenityRepository
+	tx           domain.Transactor
+	timeout      time.Duration
+}
+
+func NewDuplicateUsecase(d PropertyDeps) domain.DuplicateUsecase {
+	return &duplicateUsecase{
+		propertyRepo: d.Properties,
+		mediaRepo:    d.Media,
+		cacheRepo:    d.Cache,
+		mqChannel:    d.MQ,
+		streamRepo:   d.Stream,
+		authorizer:   d.Authorizer,
+		auditRepo:    d.Audit,
+		revisionRepo: d.Revisions,
+		amenityRepo:  d.Amenities,
+		tx:           d.Tx,
+		timeout:      d.Timeout,
+	}
+}
+
+func (u *duplicateUsecase) FetchDuplicates(c context.Context, id int64) ([]domain.DuplicateMatch, error) {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizer.Authorize(ctx, domain.PermPropertiesRead, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	p, err := u.propertyRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := u.propertyRepo.FetchDuplicateCandidates(ctx, p, duplicateCandidates)
+	if err != nil {
+		return nil, err
+	}
+	if err := attachMedia(ctx, u.mediaRepo, append(propertyRefs(candidates), &p)...); err != nil {
+		return nil, err
+	}
+
+	matches := []domain.DuplicateMatch{}
+	for _, candidate := range candidates {
+		score, reasons := domain.CompareProperties(p, candidate)
+		if score >= domain.DuplicateThreshold {
+			matches = append(matches, domain.DuplicateMatch{Property: candidate, Score: score, Reasons: reasons})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if len(matches) > maxDuplicates {
+		matches = matches[:maxDuplicates]
+	}
+	return matches, nil
+}
+
+// Merge keeps id as the listing, so its title, price and status win; the
+// duplicate only fills in what id is missing
+func (u *duplicateUsecase) Merge(c context.Context, id int64, duplicateID int64) (domain.Property, error) {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if id == duplicateID {
+		return domain.Property{}, fmt.Errorf("%w: a property can't be merged into itself", domain.ErrInvalidMerge)
+	}
+	p, err := u.propertyRepo.GetByID(ctx, id)
+	if err != nil {
+		return domain.Property{}, err
+	}
+	duplicate, err := u.propertyRepo.GetByID(ctx, duplicateID)
+	if err != nil {
+		return domain.Property{}, err
+	}
+	if err := u.authorizer.Authorize(ctx, domain.PermPropertiesUpdate, domain.Resource{OwnerID: p.AgentID, BranchID: p.BranchID}); err != nil {
+		return domain.Property{}, err
+	}
+	if err := u.authorizer.Authorize(ctx, domain.PermPropertiesDelete, domain.Resource{OwnerID: duplicate.AgentID, BranchID: duplicate.BranchID}); err != nil {
+		return domain.Property{}, err
+	}
+	if p.ListingType != duplicate.ListingType {
+		return domain.Property{}, fmt.Errorf("%w: a listing for %s can't be merged with one for %s", domain.ErrInvalidMerge, p.ListingType, duplicate.ListingType)
+	}
+
+	if err := attachAmenities(ctx, u.amenityRepo, &p, &duplicate); err != nil {
+		return domain.Property{}, err
+	}
+
+	merged := p
+	err = withinTx(ctx, u.tx, func(ctx context.Context) error {
+		if fillMissing(&merged, duplicate) {
+			if err := u.propertyRepo.Update(ctx, &merged); err != nil {
+				return err
+			}
+		}
+		if err := u.propertyRepo.Merge(ctx, id, duplicateID); err != nil {
+			return err
+		}
+		if merged, err = u.propertyRepo.GetByID(ctx, id); err != nil {
+			return err
+		}
+		if err := attachAmenities(ctx, u.amenityRepo, &merged); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, u.auditRepo, domain.AuditMerge, domain.ChangeKindProperty, id, p, merged); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, u.auditRepo, domain.AuditMerge, domain.ChangeKindProperty, duplicateID, duplicate, nil); err != nil {
+			return err
+		}
+		// The duplicate's revisions were appended to those of id; the merged state goes after them
+		return recordRevision(ctx, u.revisionRepo, merged, 0)
+	})
+	if err != nil {
+		return domain.Property{}, err
+	}
+	_ = u.cacheRepo.Delete(ctx, propertyCacheKey(id))
+	_ = u.cacheRepo.Delete(ctx, propertyCacheKey(duplicateID))
+
+	_ = publishEvent(ctx, u.mqChannel, u.streamRepo, "property_events", domain.EventPropertyMerged, id,
+		map[string]int64{"id": id, "duplicate_id": duplicateID})
+
+	return merged, attachMedia(ctx, u.mediaRepo, &merged)
+}
+
+// fillMissing copies the details p lacks from other and reports whether any were
+func fillMissing(p *domain.Property, other domain.Property) bool {
+	before := *p
+	if p.Description == "" {
+		p.Description = other.Description
+	}
+	if p.Address.Street == "" && len(p.Address.Codes()) == 0 {
+		p.Address = other.Address
+	}
+	if p.Location == nil {
+		p.Location, p.Geocode = other.Location, other.Geocode
+	}
+	fillInt := func(v *int, o int) {
+		if *v == 0 {
+			*v = o
+		}
+	}
+	fillInt(&p.Bedrooms, other.Bedrooms)
+	fillInt(&p.Bathrooms, other.Bathrooms)
+	fillInt(&p.Floors, other.Floors)
+	fillInt(&p.YearBuilt, other.YearBuilt)
+	if p.LandArea == 0 {
+		p.LandArea = other.LandArea
+	}
+	if p.BuildingArea == 0 {
+		p.BuildingArea = other.BuildingArea
+	}
+	if p.Certificate == "" {
+		p.Certificate = other.Certificate
+	}
+	if p.Furnishing == "" {
+		p.Furnishing = other.Furnishing
+	}
+	changes, err := diffProperties(before, *p)
+	return err != nil || len(changes) > 0
+}
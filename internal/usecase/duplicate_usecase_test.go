@@ -0,0 +1,103 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"nusatek-backend/internal/domain"
+	"nusatek-backend/internal/usecase"
+)
+
+func TestFetchDuplicates(t *testing.T) {
+	ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 4, TenantID: 2})
+	mockRepo := new(MockPropertyRepo)
+	mockMedia := new(MockMediaRepo)
+	u := usecase.NewDuplicateUsecase(usecase.PropertyDeps{Properties: mockRepo, Media: mockMedia, Cache: new(MockCacheRepo), Authorizer: allowAll{}, Timeout: 2 * time.Second})
+
+	house := domain.Property{ID: 9, Address: domain.Address{Street: "Jl. Melati 5", VillageCode: "3171071001"},
+		PropertyType: "rumah", ListingType: "sale", Bedrooms: 3, Bathrooms: 2, Location: &domain.GeoPoint{Lat: -6.2, Lng: 106.8}}
+	same := house
+	same.ID, same.Address.Street = 12, "jalan melati 5"
+	other := house
+	other.ID, other.Address.Street, other.Bedrooms, other.Location = 15, "Jl. Mawar 1", 5, nil
+
+	mockRepo.On("GetByID", mock.Anything, int64(9)).Return(house, nil)
+	mockRepo.On("FetchDuplicateCandidates", mock.Anything, house, 100).Return([]domain.Property{other, same}, nil)
+	mockMedia.On("FetchByProperties", mock.Anything, []int64{15, 12, 9}).Return(map[int64][]domain.PropertyMedia{}, nil)
+
+	matches, err := u.FetchDuplicates(ctx, 9)
+	assert.NoError(t, err)
+	if assert.Len(t, matches, 1) {
+		assert.Equal(t, int64(12), matches[0].Property.ID)
+		assert.Equal(t, []string{domain.MatchAddress, domain.MatchLocation, domain.MatchAttributes}, matches[0].Reasons)
+	}
+}
+
+func TestMergeProperties(t *testing.T) {
+	ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 4, TenantID: 2})
+	mockRepo := new(MockPropertyRepo)
+	mockMedia := new(MockMediaRepo)
+	mockCache := new(MockCacheRepo)
+	mockRevisions := new(MockRevisionRepo)
+	mockAmenities := new(MockAmenityRepo)
+	u := usecase.NewDuplicateUsecase(usecase.PropertyDeps{Properties: mockRepo, Media: mockMedia, Cache: mockCache, Authorizer: allowAll{}, Revisions: mockRevisions, Amenities: mockAmenities, Tx: fakeTx{}, Timeout: 2 * time.Second})
+
+	_, err := u.Merge(ctx, 9, 9)
+	assert.ErrorIs(t, err, domain.ErrInvalidMerge)
+
+	kept := domain.Property{ID: 9, Title: "Rumah Melati", ListingType: "sale", Bedrooms: 3, AgentID: 4}
+	duplicate := domain.Property{ID: 12, Title: "Dijual rumah", Description: "Dekat tol", ListingType: "sale", Bedrooms: 4, LandArea: 120}
+	pool := domain.PropertyAmenity{AmenityID: 2, Code: "pool"}
+	cctv := domain.PropertyAmenity{AmenityID: 5, Code: "cctv"}
+	merged := kept
+	merged.Description, merged.LandArea = "Dekat tol", 120
+	merged.Amenities = []domain.PropertyAmenity{pool}
+
+	mockRepo.On("GetByID", mock.Anything, int64(9)).Return(kept, nil).Once()
+	mockRepo.On("GetByID", mock.Anything, int64(12)).Return(duplicate, nil).Once()
+	mockAmenities.On("FetchByProperties", mock.Anything, []int64{9, 12}).Return(map[int64][]domain.PropertyAmenity{9: {pool}, 12: {cctv}}, nil).Once()
+	// The merge adds the duplicate's amenities to those of the kept listing
+	mockAmenities.On("FetchByProperties", mock.MatchedBy(inTx), []int64{9}).Return(map[int64][]domain.PropertyAmenity{9: {cctv, pool}}, nil).Once()
+	// Only what the kept listing lacks is copied over, in the merge's transaction
+	mockRepo.On("Update", mock.MatchedBy(inTx), &merged).Return(nil).Once()
+	mockRepo.On("Merge", mock.MatchedBy(inTx), int64(9), int64(12)).Return(nil).Once()
+	mockCache.On("Delete", mock.Anything, "property:9").Return(nil).Once()
+	mockCache.On("Delete", mock.Anything, "property:12").Return(nil).Once()
+	mockRepo.On("GetByID", mock.Anything, int64(9)).Return(merged, nil).Once()
+	mockRevisions.On("Store", mock.MatchedBy(inTx), mock.MatchedBy(func(r *domain.PropertyRevision) bool { return r.PropertyID == 9 })).Return(nil).Once()
+	mockMedia.On("FetchByProperties", mock.Anything, []int64{9}).Return(map[int64][]domain.PropertyMedia{}, nil)
+
+	p, err := u.Merge(ctx, 9, 12)
+	assert.NoError(t, err)
+	assert.Equal(t, "Dekat tol", p.Description)
+	assert.Equal(t, 3, p.Bedrooms)
+	assert.Equal(t, []domain.PropertyAmenity{cctv, pool}, p.Amenities)
+	mockRepo.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+	mockRevisions.AssertExpectations(t)
+
+	// The duplicate's photos keep their keys and are now those of the kept listing
+	moved := domain.PropertyMedia{ID: 7, PropertyID: 9, Key: "tenants/2/properties/12/c.png", ContentType: "image/png",
+		Variants: map[string]domain.MediaVariant{"thumbnail": {Width: 320, Height: 240}}}
+	blobs := memoryBlobs{moved.Key: []byte("photo"), moved.VariantKey("thumbnail"): []byte("thumb")}
+	mockRepo.On("GetByID", mock.Anything, int64(12)).Return(domain.Property{}, domain.ErrPropertyNotFound)
+	mockRepo.On("GetByID", mock.Anything, int64(9)).Return(merged, nil).Twice()
+	mockMedia.On("FetchByKey", mock.Anything, mock.Anything).Return([]domain.PropertyMedia{moved}, nil)
+	media := usecase.NewMediaUsecase(usecase.PropertyDeps{Properties: mockRepo, Media: mockMedia, Blobs: blobs, Authorizer: allowAll{}, Timeout: 2 * time.Second})
+	for key, want := range map[string]string{moved.Key: "image/png", moved.VariantKey("thumbnail"): "image/jpeg"} {
+		r, contentType, err := media.Open(ctx, key)
+		if assert.NoError(t, err, key) {
+			r.Close()
+			assert.Equal(t, want, contentType)
+		}
+	}
+
+	rent := domain.Property{ID: 15, ListingType: "rent"}
+	mockRepo.On("GetByID", mock.Anything, int64(9)).Return(kept, nil).Once()
+	mockRepo.On("GetByID", mock.Anything, int64(15)).Return(rent, nil).Once()
+	_, err = u.Merge(ctx, 9, 15)
+	assert.ErrorIs(t, err, domain.ErrInvalidMerge)
+}
@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamIDAfter(t *testing.T) {
+	for _, tc := range []struct {
+		a, b  string
+		after bool
+	}{
+		{"1700000000001-0", "1700000000000-0", true},
+		{"1700000000000-1", "1700000000000-0", true},
+		{"1700000000000-0", "1700000000000-0", false},
+		{"1700000000000-0", "1700000000000-1", false},
+		// Numbers, not strings, are compared
+		{"1700000000000-10", "1700000000000-9", true},
+		{"10000000000000-0", "9999999999999-0", true},
+		{"999-0", "1000-0", false},
+	} {
+		assert.Equal(t, tc.after, streamIDAfter(tc.a, tc.b), "%s after %s", tc.a, tc.b)
+	}
+}
+
+func TestParseStreamID(t *testing.T) {
+	for id, valid := range map[string]bool{
+		"1700000000000-0":  true,
+		"0-1":              true,
+		"1700000000000":    false,
+		"1700000000000-":   false,
+		"-0":               false,
+		"abc-0":            false,
+		"1-2-3":            false,
+		"-1-0":             false,
+		"1700000000000-0 ": false,
+		"$":                false,
+		"":                 false,
+	} {
+		_, _, ok := parseStreamID(id)
+		assert.Equal(t, valid, ok, id)
+	}
+}
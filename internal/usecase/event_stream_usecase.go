@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"nusatek-backend/internal/domain"
+)
+
+// subscriberBuffer is how many events a slow client may lag behind before it is
+// disconnected; it resumes from its Last-Event-ID on reconnect.
+const subscriberBuffer = 64
+
+type eventStreamUsecase struct {
+	streamRepo domain.EventStreamRepository
+	authorizer domain.Authorizer
+	// replayPage is how many events of history are read at a time
+	replayPage int
+
+	mu          sync.Mutex
+	running     bool
+	subscribers map[chan domain.StreamEvent]struct{}
+}
+
+func NewEventStreamUsecase(s domain.EventStreamRepository, az domain.Authorizer, replayPage int) domain.EventStreamUsecase {
+	return &eventStreamUsecase{
+		streamRepo:  s,
+		authorizer:  az,
+		replayPage:  replayPage,
+		subscribers: make(map[chan domain.StreamEvent]struct{}),
+	}
+}
+
+func (u *eventStreamUsecase) Subscribe(ctx context.Context, lastEventID string, kinds []string) (<-chan domain.StreamEvent, error) {
+	if len(kinds) == 0 {
+		kinds = []string{domain.ChangeKindProperty, domain.ChangeKindCustomer}
+	}
+	if err := authorizeKinds(ctx, u.authorizer, kinds); err != nil {
+		return nil, err
+	}
+	tenant, ok := domain.TenantFromContext(ctx)
+	if !ok {
+		return nil, domain.ErrTenantRequired
+	}
+	if _, _, ok := parseStreamID(lastEventID); lastEventID != "" && !ok {
+		return nil, domain.ErrInvalidEventID
+	}
+
+	if err := u.start(); err != nil {
+		return nil, err
+	}
+
+	// Register before reading history so nothing published in between is missed
+	live := make(chan domain.StreamEvent, subscriberBuffer)
+	u.add(live)
+
+	replay, err := u.streamRepo.Since(ctx, lastEventID, u.replayPage)
+	if err != nil {
+		u.remove(live)
+		return nil, err
+	}
+
+	wanted := make(map[string]bool, len(kinds))
+	for _, k := range kinds {
+		wanted[k] = true
+	}
+
+	out := make(chan domain.StreamEvent)
+	go func() {
+		defer close(out)
+		defer u.remove(live)
+
+		last := lastEventID
+		send := func(se domain.StreamEvent) bool {
+			last = se.ID
+			// The stream is shared by all agencies
+			if se.Event.Tenant() != tenant {
+				return true
+			}
+			if len(wanted) > 0 && !wanted[se.Event.Kind()] {
+				return true
+			}
+			select {
+			case out <- se:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		// History is read page by page up to the head of the stream, which
+		// holds the events of every agency, so no event after lastEventID is
+		// skipped however many are pending
+		for page := replay; len(page) > 0; {
+			for _, se := range page {
+				if !send(se) {
+					return
+				}
+			}
+			if len(page) < u.replayPage {
+				break
+			}
+			next, err := u.streamRepo.Since(ctx, last, u.replayPage)
+			if err != nil {
+				// The client resumes from the last event it got when it reconnects
+				log.Printf("event stream: replay after %s failed: %v", last, err)
+				return
+			}
+			page = next
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case se, ok := <-live:
+				if !ok {
+					return
+				}
+				// Skip events already sent from history
+				if last != "" && !streamIDAfter(se.ID, last) {
+					continue
+				}
+				if !send(se) {
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// start opens the single Redis subscription shared by all clients of this instance
+func (u *eventStreamUsecase) start() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.running {
+		return nil
+	}
+
+	events, err := u.streamRepo.Subscribe(context.Background())
+	if err != nil {
+		return err
+	}
+	u.running = true
+
+	go func() {
+		for se := range events {
+			u.broadcast(se)
+		}
+
+		log.Println("event stream: subscription closed")
+		u.mu.Lock()
+		u.running = false
+		for ch := range u.subscribers {
+			delete(u.subscribers, ch)
+			close(ch)
+		}
+		u.mu.Unlock()
+	}()
+	return nil
+}
+
+func (u *eventStreamUsecase) broadcast(se domain.StreamEvent) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for ch := range u.subscribers {
+		select {
+		case ch <- se:
+		default:
+			delete(u.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (u *eventStreamUsecase) add(ch chan domain.StreamEvent) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.subscribers[ch] = struct{}{}
+}
+
+func (u *eventStreamUsecase) remove(ch chan domain.StreamEvent) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if _, ok := u.subscribers[ch]; ok {
+		delete(u.subscribers, ch)
+		close(ch)
+	}
+}
+
+// streamIDAfter reports whether Redis stream id a ("<ms>-<seq>") sorts after b
+func streamIDAfter(a, b string) bool {
+	aMs, aSeq, _ := parseStreamID(a)
+	bMs, bSeq, _ := parseStreamID(b)
+	if aMs != bMs {
+		return aMs > bMs
+	}
+	return aSeq > bSeq
+}
+
+func parseStreamID(id string) (ms uint64, seq uint64, ok bool) {
+	msPart, seqPart, found := strings.Cut(id, "-")
+	ms, msErr := strconv.ParseUint(msPart, 10, 64)
+	seq, seqErr := strconv.ParseUint(seqPart, 10, 64)
+	return ms, seq, found && msErr == nil && seqErr == nil
+}
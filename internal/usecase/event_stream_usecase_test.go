@@ -0,0 +1,131 @@
+package usecase_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"nusatek-backend/internal/domain"
+	"nusatek-backend/internal/usecase"
+)
+
+// fakeStream replays history from a slice and delivers live events from a channel
+type fakeStream struct {
+	history []domain.StreamEvent
+	live    chan domain.StreamEvent
+	since   []string
+}
+
+func (s *fakeStream) Append(ctx context.Context, evt domain.Event) (domain.StreamEvent, error) {
+	return domain.StreamEvent{}, nil
+}
+func (s *fakeStream) Since(ctx context.Context, lastID string, limit int) ([]domain.StreamEvent, error) {
+	s.since = append(s.since, lastID)
+	events := s.history
+	for i, se := range s.history {
+		if se.ID == lastID {
+			events = s.history[i+1:]
+		}
+	}
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+func (s *fakeStream) Subscribe(ctx context.Context) (<-chan domain.StreamEvent, error) {
+	return s.live, nil
+}
+
+func streamEvent(id string, tenant int64, eventType string) domain.StreamEvent {
+	return domain.StreamEvent{ID: id, Event: domain.Event{Type: eventType, TenantID: tenant}}
+}
+
+func TestSubscribeSkipsReplayedEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(domain.ContextWithTenant(context.Background(), 2))
+	defer cancel()
+
+	stream := &fakeStream{
+		history: []domain.StreamEvent{
+			streamEvent("1700000000000-0", 2, domain.EventPropertyCreated),
+			streamEvent("1700000000001-0", 3, domain.EventPropertyCreated),
+			streamEvent("1700000000002-0", 2, domain.EventCustomerCreated),
+		},
+		live: make(chan domain.StreamEvent, 8),
+	}
+	u := usecase.NewEventStreamUsecase(stream, allowAll{}, 100)
+
+	events, err := u.Subscribe(ctx, "1699999999999-0", []string{domain.ChangeKindProperty, domain.ChangeKindCustomer})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1699999999999-0"}, stream.since)
+
+	// Live events that raced with the replay arrive again and must be dropped
+	for _, se := range []domain.StreamEvent{
+		streamEvent("1700000000002-0", 2, domain.EventCustomerCreated),
+		streamEvent("1700000000001-5", 2, domain.EventPropertyUpdated),
+		streamEvent("1700000000003-0", 3, domain.EventPropertyUpdated),
+		streamEvent("1700000000003-1", 2, domain.EventPropertyUpdated),
+	} {
+		stream.live <- se
+	}
+
+	var got []string
+	for len(got) < 3 {
+		select {
+		case se := <-events:
+			got = append(got, se.ID)
+		case <-time.After(time.Second):
+			t.Fatalf("got %v, want three events", got)
+		}
+	}
+	// Other tenants' events are left out too
+	assert.Equal(t, []string{"1700000000000-0", "1700000000002-0", "1700000000003-1"}, got)
+}
+
+func TestSubscribeInvalidEventID(t *testing.T) {
+	ctx := domain.ContextWithTenant(context.Background(), 2)
+	u := usecase.NewEventStreamUsecase(&fakeStream{live: make(chan domain.StreamEvent)}, allowAll{}, 100)
+
+	for _, id := range []string{"abc", "1700000000000", "1700000000000-x", "-1"} {
+		_, err := u.Subscribe(ctx, id, nil)
+		assert.ErrorIs(t, err, domain.ErrInvalidEventID, id)
+	}
+}
+
+func TestSubscribeReplaysPastPage(t *testing.T) {
+	ctx, cancel := context.WithCancel(domain.ContextWithTenant(context.Background(), 2))
+	defer cancel()
+
+	// Five pages of history, mostly of another agency
+	stream := &fakeStream{live: make(chan domain.StreamEvent, 8)}
+	var want []string
+	for i := 0; i < 23; i++ {
+		id := fmt.Sprintf("17000000000%02d-0", i)
+		tenant := int64(3)
+		if i%4 == 0 {
+			tenant = 2
+			want = append(want, id)
+		}
+		stream.history = append(stream.history, streamEvent(id, tenant, domain.EventPropertyUpdated))
+	}
+	u := usecase.NewEventStreamUsecase(stream, allowAll{}, 5)
+
+	events, err := u.Subscribe(ctx, "1699999999999-0", nil)
+	require.NoError(t, err)
+	stream.live <- streamEvent("1700000000030-0", 2, domain.EventPropertyUpdated)
+	want = append(want, "1700000000030-0")
+
+	var got []string
+	for len(got) < len(want) {
+		select {
+		case se := <-events:
+			got = append(got, se.ID)
+		case <-time.After(time.Second):
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+	assert.Equal(t, want, got)
+	assert.Equal(t, []string{"1699999999999-0", "1700000000004-0", "1700000000009-0", "1700000000014-0", "1700000000019-0"}, stream.since)
+}
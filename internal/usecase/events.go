@@ -0,0 +1,43 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"nusatek-backend/internal/domain"
+	"nusatek-backend/pkg/rabbitmq"
+)
+
+// publishEvent wraps data in a domain.Event envelope, publishes it to queue and
+// appends it to the live change stream when one is configured.
+func publishEvent(ctx context.Context, ch rabbitmq.Publisher, stream domain.EventStreamRepository, queue string, eventType string, entityID int64, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	tenant, _ := domain.TenantFromContext(ctx)
+	evt := domain.Event{
+		EventID:    rabbitmq.NewMessageID(),
+		Type:       eventType,
+		EntityID:   entityID,
+		TenantID:   tenant,
+		OccurredAt: time.Now().UTC(),
+		Data:       payload,
+	}
+	body, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+
+	if stream != nil {
+		// The stream is best effort: clients resync from the API if they miss a change
+		if _, err := stream.Append(ctx, evt); err != nil {
+			log.Printf("event stream: append %s failed: %v", evt.EventID, err)
+		}
+	}
+
+	return rabbitmq.PublishEventWithID(ch, queue, evt.EventID, body)
+}
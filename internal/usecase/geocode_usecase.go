@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"nusatek-backend/internal/domain"
+)
+
+type geocodeUsecase struct {
+	propertyRepo domain.PropertyRepository
+	cacheRepo    domain.PropertyCacheRepository
+	geocoder     domain.Geocoder
+	timeout      time.Duration
+}
+
+func NewGeocodeUsecase(p domain.PropertyRepository, c domain.PropertyCacheRepository, g domain.Geocoder, timeout time.Duration) domain.GeocodeUsecase {
+	return &geocodeUsecase{
+		propertyRepo: p,
+		cacheRepo:    c,
+		geocoder:     g,
+		timeout:      timeout,
+	}
+}
+
+func (u *geocodeUsecase) GeocodeProperty(c context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	p, err := u.propertyRepo.GetByID(ctx, id)
+	if errors.Is(err, domain.ErrPropertyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	address := p.Address.String()
+	switch {
+	case p.Location != nil && p.Geocode == nil:
+		// Set by hand, which beats any geocoder
+		return nil
+	case p.Geocode != nil && p.Geocode.Address == address:
+		return nil
+	case address == "":
+		return nil
+	}
+
+	res, err := u.geocoder.Geocode(ctx, p.Address)
+	if errors.Is(err, domain.ErrNoGeocodeMatch) {
+		log.Printf("geocode: no match for property %d: %q", id, address)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	g := domain.Geocode{Confidence: res.Confidence, Provider: res.Provider, Address: address, GeocodedAt: time.Now()}
+	err = u.propertyRepo.UpdateGeocode(ctx, id, p.UpdatedAt, res.Location, g)
+	if errors.Is(err, domain.ErrPropertyNotFound) {
+		// Edited meanwhile; the event for that edit geocodes the newer address
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if u.cacheRepo != nil {
+		_ = u.cacheRepo.Delete(ctx, propertyCacheKey(id))
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"nusatek-backend/internal/domain"
+	"nusatek-backend/internal/usecase"
+)
+
+type stubGeocoder struct {
+	res   domain.GeocodeResult
+	err   error
+	calls int
+}
+
+func (g *stubGeocoder) Geocode(ctx context.Context, a domain.Address) (domain.GeocodeResult, error) {
+	g.calls++
+	return g.res, g.err
+}
+
+func TestGeocodeProperty(t *testing.T) {
+	ctx := domain.ContextWithTenant(context.Background(), 2)
+	updatedAt := time.Now()
+	address := domain.Address{Street: "Jl. Braga 10", RegencyCode: "32.73", Regency: "Kota Bandung"}
+	braga := domain.GeoPoint{Lat: -6.9175, Lng: 107.6094}
+
+	t.Run("stores the location of a new address", func(t *testing.T) {
+		mockRepo := new(MockPropertyRepo)
+		mockCache := new(MockCacheRepo)
+		geocoder := &stubGeocoder{res: domain.GeocodeResult{Location: braga, Confidence: 0.8, Provider: "nominatim"}}
+		u := usecase.NewGeocodeUsecase(mockRepo, mockCache, geocoder, 2*time.Second)
+
+		mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, Address: address, UpdatedAt: updatedAt}, nil).Once()
+		var stored domain.Geocode
+		mockRepo.On("UpdateGeocode", mock.Anything, int64(9), updatedAt, braga, mock.AnythingOfType("domain.Geocode")).Run(func(args mock.Arguments) {
+			stored = args.Get(4).(domain.Geocode)
+		}).Return(nil).Once()
+		mockCache.On("Delete", mock.Anything, "property:9").Return(nil).Once()
+
+		assert.NoError(t, u.GeocodeProperty(ctx, 9))
+		assert.Equal(t, 0.8, stored.Confidence)
+		assert.Equal(t, "Jl. Braga 10, Kota Bandung", stored.Address)
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("skips addresses already geocoded and locations set by hand", func(t *testing.T) {
+		mockRepo := new(MockPropertyRepo)
+		geocoder := &stubGeocoder{}
+		u := usecase.NewGeocodeUsecase(mockRepo, new(MockCacheRepo), geocoder, 2*time.Second)
+
+		geocoded := &domain.Geocode{Address: address.String()}
+		mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, Address: address, Location: &braga, Geocode: geocoded}, nil).Once()
+		mockRepo.On("GetByID", mock.Anything, int64(10)).Return(domain.Property{ID: 10, Address: address, Location: &braga}, nil).Once()
+
+		assert.NoError(t, u.GeocodeProperty(ctx, 9))
+		assert.NoError(t, u.GeocodeProperty(ctx, 10))
+		assert.Equal(t, 0, geocoder.calls)
+	})
+
+	t.Run("gives up without a match but retries provider errors", func(t *testing.T) {
+		mockRepo := new(MockPropertyRepo)
+		geocoder := &stubGeocoder{err: domain.ErrNoGeocodeMatch}
+		u := usecase.NewGeocodeUsecase(mockRepo, new(MockCacheRepo), geocoder, 2*time.Second)
+
+		mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, Address: address}, nil).Twice()
+
+		assert.NoError(t, u.GeocodeProperty(ctx, 9))
+		geocoder.err = assert.AnError
+		assert.ErrorIs(t, u.GeocodeProperty(ctx, 9), assert.AnError)
+		mockRepo.AssertNotCalled(t, "UpdateGeocode", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
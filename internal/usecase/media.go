@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"context"
+
+	"nusatek-backend/internal/domain"
+)
+
+// attachMedia fills in the photos of properties with one query
+func attachMedia(ctx context.Context, repo domain.MediaRepository, properties ...*domain.Property) error {
+	if repo == nil || len(properties) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(properties))
+	for i, p := range properties {
+		ids[i] = p.ID
+	}
+	media, err := repo.FetchByProperties(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, p := range properties {
+		p.Media = media[p.ID]
+	}
+	return nil
+}
+
+func propertyRefs(properties []domain.Property) []*domain.Property {
+	refs := make([]*domain.Property, len(properties))
+	for i := range properties {
+		refs[i] = &properties[i]
+	}
+	return refs
+}
@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"time"
+
+	"nusatek-backend/internal/domain"
+)
+
+type mediaProcessingUsecase struct {
+	mediaRepo domain.MediaRepository
+	blobStore domain.BlobStore
+	processor domain.ImageProcessor
+	cacheRepo domain.PropertyCacheRepository
+	timeout   time.Duration
+}
+
+func NewMediaProcessingUsecase(m domain.MediaRepository, b domain.BlobStore, p domain.ImageProcessor, c domain.PropertyCacheRepository, timeout time.Duration) domain.MediaProcessingUsecase {
+	return &mediaProcessingUsecase{
+		mediaRepo: m,
+		blobStore: b,
+		processor: p,
+		cacheRepo: c,
+		timeout:   timeout,
+	}
+}
+
+// Process replaces an uploaded photo with a copy without metadata and stores
+// its resized variants next to it. Redelivered events for processed photos
+// are ignored; a retry after a failure starts over.
+func (u *mediaProcessingUsecase) Process(c context.Context, propertyID int64, id int64) error {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	m, err := u.mediaRepo.Get(ctx, propertyID, id)
+	if errors.Is(err, domain.ErrMediaNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if m.Status != domain.MediaPending {
+		return nil
+	}
+
+	rc, err := u.blobStore.Get(ctx, m.Key)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(io.LimitReader(rc, domain.MaxMediaSize+1))
+	rc.Close()
+	if err != nil {
+		return err
+	}
+
+	img, err := u.processor.Process(data, m.ContentType)
+	if errors.Is(err, domain.ErrInvalidMedia) {
+		// Sniffed as an image on upload but unreadable; trying again won't help
+		log.Printf("media: processing photo %d of property %d: %v", id, propertyID, err)
+		m.Status = domain.MediaFailed
+		return u.save(ctx, m, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	// The variants are stored before the record points at them
+	var keys []string
+	m.Variants = make(map[string]domain.MediaVariant, len(img.Variants))
+	for _, v := range img.Variants {
+		key := m.VariantKey(v.Name)
+		if err := u.blobStore.Put(ctx, key, bytes.NewReader(v.Data), int64(len(v.Data)), "image/jpeg"); err != nil {
+			return err
+		}
+		keys = append(keys, key)
+		m.Variants[v.Name] = domain.MediaVariant{URL: u.blobStore.URL(key), Width: v.Width, Height: v.Height}
+	}
+	if err := u.blobStore.Put(ctx, m.Key, bytes.NewReader(img.Original), int64(len(img.Original)), m.ContentType); err != nil {
+		return err
+	}
+	keys = append(keys, m.Key)
+
+	m.Status = domain.MediaProcessed
+	m.Size = int64(len(img.Original))
+	m.Width, m.Height, m.Hash = img.Width, img.Height, img.Hash
+	return u.save(ctx, m, keys)
+}
+
+// save records the outcome of processing m. If the photo was deleted while it
+// was processed, the files written for it are removed again.
+func (u *mediaProcessingUsecase) save(ctx context.Context, m domain.PropertyMedia, keys []string) error {
+	err := u.mediaRepo.UpdateProcessed(ctx, m)
+	if errors.Is(err, domain.ErrMediaNotFound) {
+		for _, key := range keys {
+			if err := u.blobStore.Delete(ctx, key); err != nil {
+				log.Printf("media: deleting blob %s: %v", key, err)
+			}
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if u.cacheRepo != nil {
+		_ = u.cacheRepo.Delete(ctx, propertyCacheKey(m.PropertyID))
+	}
+	return nil
+}
@@ -0,0 +1,336 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"nusatek-backend/internal/domain"
+	"nusatek-backend/pkg/rabbitmq"
+)
+
+type mediaUsecase struct {
+	propertyRepo domain.PropertyRepository
+	mediaRepo    domain.MediaRepository
+	blobStore    domain.BlobStore
+	images       domain.ImageProcessor
+	cacheRepo    domain.PropertyCacheRepository
+	mqChannel    rabbitmq.Publisher
+	streamRepo   domain.EventStreamRepository
+	authorizer   domain.Authorizer
+	auditRepo    domain.AuditRepository
+	tx           domain.Transactor
+	timeout      time.Duration
+}
+
+func NewMediaUsecase(d PropertyDeps) domain.MediaUsecase {
+	return &mediaUsecase{
+		propertyRepo: d.Properties,
+		mediaRepo:    d.Media,
+		blobStore:    d.Blobs,
+		images:       d.Images,
+		cacheRepo:    d.Cache,
+		mqChannel:    d.MQ,
+		streamRepo:   d.Stream,
+		authorizer:   d.Authorizer,
+		auditRepo:    d.Audit,
+		tx:           d.Tx,
+		timeout:      d.Timeout,
+	}
+}
+
+func (u *mediaUsecase) Fetch(c context.Context, propertyID int64) ([]domain.PropertyMedia, error) {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizer.Authorize(ctx, domain.PermPropertiesRead, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	if _, err := u.propertyRepo.GetByID(ctx, propertyID); err != nil {
+		return nil, err
+	}
+	return u.mediaRepo.Fetch(ctx, propertyID)
+}
+
+// Upload checks the photo's real format from its first bytes rather than
+// trusting the client's Content-Type, and strips its metadata before it is
+// stored. The upload fails unless the worker can be told to process it.
+func (u *mediaUsecase) Upload(c context.Context, propertyID int64, filename string, r io.Reader, size int64) (domain.PropertyMedia, error) {
+	// Uploads of large photos over slow connections need longer than other calls
+	ctx, cancel := context.WithTimeout(c, u.timeout+time.Minute)
+	defer cancel()
+
+	if err := u.authorizeProperty(ctx, propertyID); err != nil {
+		return domain.PropertyMedia{}, err
+	}
+	if size <= 0 || size > domain.MaxMediaSize {
+		return domain.PropertyMedia{}, fmt.Errorf("%w: photos must be at most %d MB", domain.ErrInvalidMedia, domain.MaxMediaSize>>20)
+	}
+	existing, err := u.mediaRepo.Fetch(ctx, propertyID)
+	if err != nil {
+		return domain.PropertyMedia{}, err
+	}
+	if len(existing) >= domain.MaxMediaPerProperty {
+		return domain.PropertyMedia{}, fmt.Errorf("%w: a property can have at most %d photos", domain.ErrInvalidMedia, domain.MaxMediaPerProperty)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, size))
+	if err != nil {
+		return domain.PropertyMedia{}, err
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := domain.MediaContentTypes[contentType]
+	if !ok {
+		return domain.PropertyMedia{}, fmt.Errorf("%w: %s is not a JPEG, PNG or WebP image", domain.ErrInvalidMedia, contentType)
+	}
+	// GPS coordinates must never reach the store, not even until the worker runs
+	if u.images != nil {
+		if data, err = u.images.StripMetadata(data, contentType); err != nil {
+			return domain.PropertyMedia{}, err
+		}
+	}
+
+	tenant, _ := domain.TenantFromContext(ctx)
+	name, err := randomName()
+	if err != nil {
+		return domain.PropertyMedia{}, err
+	}
+	m := domain.PropertyMedia{
+		PropertyID:  propertyID,
+		Key:         domain.MediaKey(tenant, propertyID, name+ext),
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Status:      domain.MediaPending,
+	}
+	m.URL = u.blobStore.URL(m.Key)
+
+	if err := u.blobStore.Put(ctx, m.Key, bytes.NewReader(data), m.Size, contentType); err != nil {
+		return domain.PropertyMedia{}, err
+	}
+	stored, err := u.change(ctx, propertyID, existing, func(ctx context.Context) error {
+		return u.mediaRepo.Store(ctx, &m)
+	})
+	if err != nil {
+		u.deleteBlob(ctx, m.Key)
+		return domain.PropertyMedia{}, err
+	}
+
+	// A photo nobody will process would stay pending, so it is taken back
+	if err := publishEvent(ctx, u.mqChannel, u.streamRepo, "property_events", domain.EventPropertyMediaUploaded, propertyID, m); err != nil {
+		_, derr := u.change(ctx, propertyID, stored, func(ctx context.Context) error {
+			return u.mediaRepo.Delete(ctx, propertyID, m.ID)
+		})
+		if derr != nil {
+			log.Printf("media: removing photo %d of property %d: %v", m.ID, propertyID, derr)
+		}
+		u.deleteBlob(ctx, m.Key)
+		return domain.PropertyMedia{}, fmt.Errorf("media: queueing photo for processing: %w", err)
+	}
+
+	return m, nil
+}
+
+func (u *mediaUsecase) Delete(c context.Context, propertyID int64, id int64) error {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizeProperty(ctx, propertyID); err != nil {
+		return err
+	}
+	existing, err := u.mediaRepo.Fetch(ctx, propertyID)
+	if err != nil {
+		return err
+	}
+	_, err = u.change(ctx, propertyID, existing, func(ctx context.Context) error {
+		return u.mediaRepo.Delete(ctx, propertyID, id)
+	})
+	if err != nil {
+		return err
+	}
+	for _, m := range existing {
+		if m.ID == id {
+			u.deleteBlob(ctx, m.Key)
+			for name := range m.Variants {
+				u.deleteBlob(ctx, m.VariantKey(name))
+			}
+		}
+	}
+	return nil
+}
+
+func (u *mediaUsecase) Reorder(c context.Context, propertyID int64, ids []int64) ([]domain.PropertyMedia, error) {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizeProperty(ctx, propertyID); err != nil {
+		return nil, err
+	}
+	existing, err := u.mediaRepo.Fetch(ctx, propertyID)
+	if err != nil {
+		return nil, err
+	}
+	if !samePhotos(existing, ids) {
+		return nil, fmt.Errorf("%w: the order must list every photo of the property once", domain.ErrInvalidMedia)
+	}
+	return u.change(ctx, propertyID, existing, func(ctx context.Context) error {
+		return u.mediaRepo.Reorder(ctx, propertyID, ids)
+	})
+}
+
+func (u *mediaUsecase) SetCover(c context.Context, propertyID int64, id int64) ([]domain.PropertyMedia, error) {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizeProperty(ctx, propertyID); err != nil {
+		return nil, err
+	}
+	existing, err := u.mediaRepo.Fetch(ctx, propertyID)
+	if err != nil {
+		return nil, err
+	}
+	return u.change(ctx, propertyID, existing, func(ctx context.Context) error {
+		return u.mediaRepo.SetCover(ctx, propertyID, id)
+	})
+}
+
+func (u *mediaUsecase) Open(c context.Context, key string) (io.ReadCloser, string, error) {
+	tenant, _, ok := domain.ParseMediaKey(key)
+	if !ok {
+		return nil, "", domain.ErrMediaNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	// Signed in callers only see their own tenant's photos
+	principal, signedIn := domain.PrincipalFromContext(ctx)
+	if signedIn && principal.TenantID != tenant {
+		return nil, "", domain.ErrMediaNotFound
+	}
+	if !signedIn {
+		ctx = domain.ContextWithTenant(ctx, tenant)
+	}
+
+	// Photos keep their key when a merge moves them to the kept listing, so
+	// access follows the property a photo belongs to now, not the one in its key
+	media, err := u.mediaRepo.FetchByKey(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+	var (
+		propertyID  int64
+		contentType string
+	)
+	for _, m := range media {
+		if m.Key == key {
+			propertyID, contentType = m.PropertyID, m.ContentType
+		}
+		for name := range m.Variants {
+			if m.VariantKey(name) == key {
+				propertyID, contentType = m.PropertyID, "image/jpeg"
+			}
+		}
+	}
+	if contentType == "" {
+		return nil, "", domain.ErrMediaNotFound
+	}
+
+	p, err := u.propertyRepo.GetByID(ctx, propertyID)
+	if errors.Is(err, domain.ErrPropertyNotFound) {
+		return nil, "", domain.ErrMediaNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if p.Status != domain.PropertyPublished {
+		if !signedIn {
+			return nil, "", domain.ErrMediaNotFound
+		}
+		if err := u.authorizer.Authorize(ctx, domain.PermPropertiesRead, domain.Resource{}); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// The caller reads the file after we return, so it must not be cut off
+	// by our timeout
+	r, err := u.blobStore.Get(c, key)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", domain.ErrMediaNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return r, contentType, nil
+}
+
+// authorizeProperty allows changing the photos of properties the caller may update
+func (u *mediaUsecase) authorizeProperty(ctx context.Context, propertyID int64) error {
+	p, err := u.propertyRepo.GetByID(ctx, propertyID)
+	if err != nil {
+		return err
+	}
+	return u.authorizer.Authorize(ctx, domain.PermPropertiesUpdate, domain.Resource{OwnerID: p.AgentID, BranchID: p.BranchID})
+}
+
+// change runs fn, which changes the photos of a property, and audits it in
+// one transaction. It then evicts the cached property and returns its photos
+// as they are now.
+func (u *mediaUsecase) change(ctx context.Context, propertyID int64, before []domain.PropertyMedia, fn func(ctx context.Context) error) ([]domain.PropertyMedia, error) {
+	var after []domain.PropertyMedia
+	err := withinTx(ctx, u.tx, func(ctx context.Context) error {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+		var err error
+		if after, err = u.mediaRepo.Fetch(ctx, propertyID); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.auditRepo, domain.AuditMedia, domain.ChangeKindProperty, propertyID, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+	_ = u.cacheRepo.Delete(ctx, propertyCacheKey(propertyID))
+	return after, nil
+}
+
+// deleteBlob removes a stored file; a failure only leaves an orphan behind
+func (u *mediaUsecase) deleteBlob(ctx context.Context, key string) {
+	if err := u.blobStore.Delete(ctx, key); err != nil {
+		log.Printf("media: deleting blob %s: %v", key, err)
+	}
+}
+
+func samePhotos(media []domain.PropertyMedia, ids []int64) bool {
+	if len(media) != len(ids) {
+		return false
+	}
+	want := make(map[int64]bool, len(media))
+	for _, m := range media {
+		want[m.ID] = true
+	}
+	for _, id := range ids {
+		if !want[id] {
+			return false
+		}
+		delete(want, id)
+	}
+	return true
+}
+
+func randomName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,308 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"nusatek-backend/internal/domain"
+	"nusatek-backend/internal/usecase"
+)
+
+type MockMediaRepo struct {
+	mock.Mock
+}
+
+func (m *MockMediaRepo) Fetch(ctx context.Context, propertyID int64) ([]domain.PropertyMedia, error) {
+	args := m.Called(ctx, propertyID)
+	return args.Get(0).([]domain.PropertyMedia), args.Error(1)
+}
+func (m *MockMediaRepo) FetchByProperties(ctx context.Context, propertyIDs []int64) (map[int64][]domain.PropertyMedia, error) {
+	args := m.Called(ctx, propertyIDs)
+	return args.Get(0).(map[int64][]domain.PropertyMedia), args.Error(1)
+}
+func (m *MockMediaRepo) Get(ctx context.Context, propertyID int64, id int64) (domain.PropertyMedia, error) {
+	args := m.Called(ctx, propertyID, id)
+	return args.Get(0).(domain.PropertyMedia), args.Error(1)
+}
+func (m *MockMediaRepo) Store(ctx context.Context, md *domain.PropertyMedia) error {
+	args := m.Called(ctx, md)
+	return args.Error(0)
+}
+func (m *MockMediaRepo) Delete(ctx context.Context, propertyID int64, id int64) error {
+	args := m.Called(ctx, propertyID, id)
+	return args.Error(0)
+}
+func (m *MockMediaRepo) Reorder(ctx context.Context, propertyID int64, ids []int64) error {
+	args := m.Called(ctx, propertyID, ids)
+	return args.Error(0)
+}
+func (m *MockMediaRepo) SetCover(ctx context.Context, propertyID int64, id int64) error {
+	args := m.Called(ctx, propertyID, id)
+	return args.Error(0)
+}
+func (m *MockMediaRepo) UpdateProcessed(ctx context.Context, md domain.PropertyMedia) error {
+	args := m.Called(ctx, md)
+	return args.Error(0)
+}
+func (m *MockMediaRepo) FetchByKey(ctx context.Context, key string) ([]domain.PropertyMedia, error) {
+	args := m.Called(ctx, key)
+	return args.Get(0).([]domain.PropertyMedia), args.Error(1)
+}
+func (m *MockMediaRepo) FetchPurgeable(ctx context.Context, before time.Time) ([]domain.PropertyMedia, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).([]domain.PropertyMedia), args.Error(1)
+}
+
+// memoryBlobs is a BlobStore kept in memory
+type memoryBlobs map[string][]byte
+
+func (b memoryBlobs) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(r)
+	b[key] = data
+	return err
+}
+func (b memoryBlobs) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(b[key])), nil
+}
+func (b memoryBlobs) Delete(ctx context.Context, key string) error {
+	delete(b, key)
+	return nil
+}
+func (b memoryBlobs) URL(key string) string {
+	return "https://cdn.example.com/" + key
+}
+
+// queue records the messages published to it
+type queue struct {
+	err       error
+	published []amqp.Publishing
+}
+
+func (q *queue) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	if q.err != nil {
+		return q.err
+	}
+	q.published = append(q.published, msg)
+	return nil
+}
+
+func TestUploadMedia(t *testing.T) {
+	ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 4, TenantID: 2})
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 600)...)
+
+	mockRepo := new(MockPropertyRepo)
+	mockMedia := new(MockMediaRepo)
+	mockCache := new(MockCacheRepo)
+	blobs := memoryBlobs{}
+	mq := &queue{}
+	u := usecase.NewMediaUsecase(usecase.PropertyDeps{Properties: mockRepo, Media: mockMedia, Blobs: blobs, Cache: mockCache, MQ: mq, Images: stubProcessor{}, Authorizer: allowAll{}, Timeout: 2 * time.Second})
+
+	mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, AgentID: 4}, nil)
+	mockMedia.On("Fetch", mock.Anything, int64(9)).Return([]domain.PropertyMedia{}, nil)
+	mockMedia.On("Store", mock.Anything, mock.AnythingOfType("*domain.PropertyMedia")).Return(nil).Once()
+	mockCache.On("Delete", mock.Anything, "property:9").Return(nil).Once()
+
+	// The metadata is gone before the photo is stored
+	withGPS := append(append([]byte{}, png...), "+gps"...)
+	m, err := u.Upload(ctx, 9, "depan.png", bytes.NewReader(withGPS), int64(len(withGPS)))
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", m.ContentType)
+	assert.True(t, strings.HasPrefix(m.Key, "tenants/2/properties/9/") && strings.HasSuffix(m.Key, ".png"), m.Key)
+	assert.Equal(t, "https://cdn.example.com/"+m.Key, m.URL)
+	assert.Equal(t, png, blobs[m.Key])
+	assert.Equal(t, int64(len(png)), m.Size)
+	assert.Len(t, mq.published, 1)
+
+	// The content decides, not the file name
+	_, err = u.Upload(ctx, 9, "foto.jpg", strings.NewReader("%PDF-1.7 not a photo"), 20)
+	assert.ErrorIs(t, err, domain.ErrInvalidMedia)
+	_, err = u.Upload(ctx, 9, "huge.png", bytes.NewReader(png), domain.MaxMediaSize+1)
+	assert.ErrorIs(t, err, domain.ErrInvalidMedia)
+	assert.Len(t, blobs, 1)
+	mockMedia.AssertExpectations(t)
+}
+
+func TestUploadMediaUnqueued(t *testing.T) {
+	ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 4, TenantID: 2})
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 600)...)
+
+	mockRepo := new(MockPropertyRepo)
+	mockMedia := new(MockMediaRepo)
+	mockCache := new(MockCacheRepo)
+	blobs := memoryBlobs{}
+	u := usecase.NewMediaUsecase(usecase.PropertyDeps{Properties: mockRepo, Media: mockMedia, Blobs: blobs, Cache: mockCache, MQ: &queue{err: errors.New("channel closed")}, Authorizer: allowAll{}, Timeout: 2 * time.Second})
+
+	mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, AgentID: 4}, nil)
+	mockMedia.On("Fetch", mock.Anything, int64(9)).Return([]domain.PropertyMedia{}, nil)
+	mockMedia.On("Store", mock.Anything, mock.AnythingOfType("*domain.PropertyMedia")).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.PropertyMedia).ID = 5
+	}).Return(nil).Once()
+	mockMedia.On("Delete", mock.Anything, int64(9), int64(5)).Return(nil).Once()
+	mockCache.On("Delete", mock.Anything, "property:9").Return(nil)
+
+	// A photo the worker never hears about is taken back
+	_, err := u.Upload(ctx, 9, "depan.png", bytes.NewReader(png), int64(len(png)))
+	assert.Error(t, err)
+	assert.Empty(t, blobs)
+	mockMedia.AssertExpectations(t)
+}
+
+func TestReorderMedia(t *testing.T) {
+	ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 4, TenantID: 2})
+	mockRepo := new(MockPropertyRepo)
+	mockMedia := new(MockMediaRepo)
+	mockCache := new(MockCacheRepo)
+	u := usecase.NewMediaUsecase(usecase.PropertyDeps{Properties: mockRepo, Media: mockMedia, Blobs: memoryBlobs{}, Cache: mockCache, Authorizer: allowAll{}, Timeout: 2 * time.Second})
+
+	photos := []domain.PropertyMedia{{ID: 1, PropertyID: 9, Position: 1}, {ID: 2, PropertyID: 9, Position: 2}}
+	mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, AgentID: 4}, nil)
+	mockMedia.On("Fetch", mock.Anything, int64(9)).Return(photos, nil)
+
+	_, err := u.Reorder(ctx, 9, []int64{2})
+	assert.ErrorIs(t, err, domain.ErrInvalidMedia)
+	_, err = u.Reorder(ctx, 9, []int64{2, 2})
+	assert.ErrorIs(t, err, domain.ErrInvalidMedia)
+
+	mockMedia.On("Reorder", mock.Anything, int64(9), []int64{2, 1}).Return(nil).Once()
+	mockCache.On("Delete", mock.Anything, "property:9").Return(nil).Once()
+	_, err = u.Reorder(ctx, 9, []int64{2, 1})
+	assert.NoError(t, err)
+	mockMedia.AssertExpectations(t)
+}
+
+func TestOpenMedia(t *testing.T) {
+	agent := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 4, TenantID: 2})
+	otherTenant := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 5, TenantID: 3})
+	draft := domain.PropertyMedia{ID: 1, PropertyID: 9, Key: "tenants/2/properties/9/a.png", ContentType: "image/png"}
+	published := domain.PropertyMedia{ID: 2, PropertyID: 10, Key: "tenants/2/properties/10/b.webp", ContentType: "image/webp",
+		Variants: map[string]domain.MediaVariant{"thumbnail": {Width: 320, Height: 240}}}
+	deleted := domain.PropertyMedia{ID: 3, PropertyID: 11, Key: "tenants/2/properties/11/a.png", ContentType: "image/png"}
+	blobs := memoryBlobs{draft.Key: []byte("draft"), published.Key: []byte("published"), published.VariantKey("thumbnail"): []byte("thumb"), deleted.Key: []byte("deleted")}
+
+	mockRepo := new(MockPropertyRepo)
+	mockMedia := new(MockMediaRepo)
+	tenant2 := mock.MatchedBy(func(ctx context.Context) bool {
+		id, _ := domain.TenantFromContext(ctx)
+		return id == 2
+	})
+	mockRepo.On("GetByID", tenant2, int64(9)).Return(domain.Property{ID: 9, Status: domain.PropertyDraft}, nil)
+	mockRepo.On("GetByID", tenant2, int64(10)).Return(domain.Property{ID: 10, Status: domain.PropertyPublished}, nil)
+	mockRepo.On("GetByID", tenant2, int64(11)).Return(domain.Property{}, domain.ErrPropertyNotFound)
+	// The usecase picks the photo that key is exactly the blob of
+	mockMedia.On("FetchByKey", tenant2, mock.Anything).Return([]domain.PropertyMedia{draft, published, deleted}, nil)
+
+	open := func(u domain.MediaUsecase, ctx context.Context, key string) (string, string, error) {
+		r, contentType, err := u.Open(ctx, key)
+		if err != nil {
+			return "", "", err
+		}
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		return string(data), contentType, err
+	}
+	u := usecase.NewMediaUsecase(usecase.PropertyDeps{Properties: mockRepo, Media: mockMedia, Blobs: blobs, Authorizer: allowAll{}, Timeout: 2 * time.Second})
+
+	// Anyone may see the photos of published properties, variants included
+	data, contentType, err := open(u, context.Background(), published.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, "published", data)
+	assert.Equal(t, "image/webp", contentType)
+	data, contentType, err = open(u, context.Background(), published.VariantKey("thumbnail"))
+	assert.NoError(t, err)
+	assert.Equal(t, "thumb", data)
+	assert.Equal(t, "image/jpeg", contentType)
+
+	// Drafts only to their agency
+	data, _, err = open(u, agent, draft.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, "draft", data)
+	_, _, err = open(u, context.Background(), draft.Key)
+	assert.ErrorIs(t, err, domain.ErrMediaNotFound)
+	_, _, err = open(u, otherTenant, draft.Key)
+	assert.ErrorIs(t, err, domain.ErrMediaNotFound)
+	_, _, err = open(u, otherTenant, published.Key)
+	assert.ErrorIs(t, err, domain.ErrMediaNotFound)
+
+	denied := usecase.NewMediaUsecase(usecase.PropertyDeps{Properties: mockRepo, Media: mockMedia, Blobs: blobs, Authorizer: denyResource{allowed: domain.Resource{OwnerID: 4}}, Timeout: 2 * time.Second})
+	_, _, err = open(denied, agent, draft.Key)
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+
+	// Keys of no photo, of deleted properties or of other files fail alike
+	for _, key := range []string{"tenants/2/properties/9/c.png", "tenants/2/properties/11/a.png", "tenants/2/properties/9/a_thumbnail.jpg", "tenants/2/../../etc/passwd", "a.png", ""} {
+		_, _, err = open(u, agent, key)
+		assert.ErrorIs(t, err, domain.ErrMediaNotFound, key)
+	}
+}
+
+// stubProcessor strips a fake metadata marker and renders one variant
+type stubProcessor struct{}
+
+func (stubProcessor) StripMetadata(data []byte, contentType string) ([]byte, error) {
+	return bytes.TrimSuffix(data, []byte("+gps")), nil
+}
+
+func (stubProcessor) Process(data []byte, contentType string) (domain.ProcessedImage, error) {
+	if !bytes.HasPrefix(data, []byte("photo")) {
+		return domain.ProcessedImage{}, domain.ErrInvalidMedia
+	}
+	return domain.ProcessedImage{
+		Original: bytes.TrimSuffix(data, []byte("+gps")),
+		Width:    1200,
+		Height:   900,
+		Hash:     0xf0f0,
+		Variants: []domain.ImageVariant{{Name: "thumbnail", Data: []byte("thumb"), Width: 320, Height: 240}},
+	}, nil
+}
+
+func TestProcessMedia(t *testing.T) {
+	ctx := domain.ContextWithTenant(context.Background(), 2)
+	mockMedia := new(MockMediaRepo)
+	mockCache := new(MockCacheRepo)
+	blobs := memoryBlobs{"p/9/a.jpg": []byte("photo+gps"), "p/9/b.jpg": []byte("not an image")}
+	u := usecase.NewMediaProcessingUsecase(mockMedia, blobs, stubProcessor{}, mockCache, 2*time.Second)
+
+	mockMedia.On("Get", mock.Anything, int64(9), int64(1)).
+		Return(domain.PropertyMedia{ID: 1, PropertyID: 9, Key: "p/9/a.jpg", ContentType: "image/jpeg", Size: 9, Status: domain.MediaPending}, nil)
+	mockMedia.On("UpdateProcessed", mock.Anything, domain.PropertyMedia{
+		ID: 1, PropertyID: 9, Key: "p/9/a.jpg", ContentType: "image/jpeg", Size: 5, Status: domain.MediaProcessed,
+		Width: 1200, Height: 900, Hash: 0xf0f0,
+		Variants: map[string]domain.MediaVariant{"thumbnail": {URL: "https://cdn.example.com/p/9/a_thumbnail.jpg", Width: 320, Height: 240}},
+	}).Return(nil).Once()
+	mockCache.On("Delete", mock.Anything, "property:9").Return(nil)
+
+	assert.NoError(t, u.Process(ctx, 9, 1))
+	assert.Equal(t, "photo", string(blobs["p/9/a.jpg"]))
+	assert.Equal(t, "thumb", string(blobs["p/9/a_thumbnail.jpg"]))
+
+	// Unreadable photos are marked failed rather than retried
+	mockMedia.On("Get", mock.Anything, int64(9), int64(2)).
+		Return(domain.PropertyMedia{ID: 2, PropertyID: 9, Key: "p/9/b.jpg", ContentType: "image/jpeg", Status: domain.MediaPending}, nil)
+	mockMedia.On("UpdateProcessed", mock.Anything, mock.MatchedBy(func(m domain.PropertyMedia) bool {
+		return m.ID == 2 && m.Status == domain.MediaFailed
+	})).Return(nil).Once()
+	assert.NoError(t, u.Process(ctx, 9, 2))
+
+	// Deleted while processing: the new files are removed again
+	blobs["p/9/c.jpg"] = []byte("photo")
+	mockMedia.On("Get", mock.Anything, int64(9), int64(3)).
+		Return(domain.PropertyMedia{ID: 3, PropertyID: 9, Key: "p/9/c.jpg", ContentType: "image/jpeg", Status: domain.MediaPending}, nil)
+	mockMedia.On("UpdateProcessed", mock.Anything, mock.MatchedBy(func(m domain.PropertyMedia) bool { return m.ID == 3 })).
+		Return(domain.ErrMediaNotFound).Once()
+	assert.NoError(t, u.Process(ctx, 9, 3))
+	assert.NotContains(t, blobs, "p/9/c.jpg")
+	assert.NotContains(t, blobs, "p/9/c_thumbnail.jpg")
+
+	// Redelivered events for processed photos change nothing
+	mockMedia.On("Get", mock.Anything, int64(9), int64(4)).
+		Return(domain.PropertyMedia{ID: 4, PropertyID: 9, Status: domain.MediaProcessed}, nil)
+	assert.NoError(t, u.Process(ctx, 9, 4))
+	mockMedia.AssertExpectations(t)
+}
@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"nusatek-backend/internal/domain"
+)
+
+type priceHistoryUsecase struct {
+	priceRepo  domain.PriceHistoryRepository
+	authorizer domain.Authorizer
+	timeout    time.Duration
+}
+
+func NewPriceHistoryUsecase(p domain.PriceHistoryRepository, az domain.Authorizer, timeout time.Duration) domain.PriceHistoryUsecase {
+	return &priceHistoryUsecase{
+		priceRepo:  p,
+		authorizer: az,
+		timeout:    timeout,
+	}
+}
+
+func (u *priceHistoryUsecase) Fetch(c context.Context, propertyID int64, limit int, offset int) ([]domain.PriceChange, error) {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizer.Authorize(ctx, domain.PermPropertiesRead, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	return u.priceRepo.Fetch(ctx, propertyID, limit, offset)
+}
+
+// Summary aggregates the price changes in currency made in the last period
+func (u *priceHistoryUsecase) Summary(c context.Context, currency string, period time.Duration) (domain.PriceChangeSummary, error) {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizer.Authorize(ctx, domain.PermPropertiesRead, domain.Resource{}); err != nil {
+		return domain.PriceChangeSummary{}, err
+	}
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+	return u.priceRepo.Summary(ctx, currency, time.Now().Add(-period))
+}
+
+// FetchStale lists properties whose price has not been reduced for at least minAge
+func (u *priceHistoryUsecase) FetchStale(c context.Context, minAge time.Duration, limit int, offset int) ([]domain.StaleListing, error) {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizer.Authorize(ctx, domain.PermPropertiesRead, domain.Resource{}); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	listings, err := u.priceRepo.FetchStale(ctx, now.Add(-minAge), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	for i := range listings {
+		since := listings[i].ListedAt
+		if listings[i].LastReductionAt != nil {
+			since = *listings[i].LastReductionAt
+		}
+		listings[i].DaysSinceReduction = int(now.Sub(since) / (24 * time.Hour))
+	}
+	return listings, nil
+}
+
+// recordPriceChange appends to the price history when a write changed the
+// price, in the write's transaction. old is nil for a new listing.
+func recordPriceChange(ctx context.Context, repo domain.PriceHistoryRepository, propertyID int64, old *domain.Money, price domain.Money) error {
+	if repo == nil || (old != nil && *old == price) {
+		return nil
+	}
+
+	c := domain.PriceChange{PropertyID: propertyID, OldPrice: old, NewPrice: price}
+	if principal, ok := domain.PrincipalFromContext(ctx); ok {
+		c.ActorID = principal.UserID
+	}
+	if err := repo.Store(ctx, &c); err != nil {
+		return fmt.Errorf("price history of property %d: %w", propertyID, err)
+	}
+	return nil
+}
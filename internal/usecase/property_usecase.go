@@ -2,43 +2,127 @@ package usecase
 
 import (
 	"context"
-	"fmt"
 	"strconv"
 	"time"
 
 	"nusatek-backend/internal/domain"
-
-	"github.com/streadway/amqp"
+	"nusatek-backend/pkg/rabbitmq"
 )
 
 type propertyUsecase struct {
 	propertyRepo domain.PropertyRepository
 	cacheRepo    domain.PropertyCacheRepository
-	mqChannel    *amqp.Channel
+	mqChannel    rabbitmq.Publisher
+	streamRepo   domain.EventStreamRepository
+	authorizer   domain.Authorizer
+	auditRepo    domain.AuditRepository
+	revisionRepo domain.PropertyRevisionRepository
+	priceRepo    domain.PriceHistoryRepository
+	regionRepo   domain.RegionRepository
+	mediaRepo    domain.MediaRepository
+	amenityRepo  domain.AmenityRepository
+	tx           domain.Transactor
 	timeout      time.Duration
 }
 
-func NewPropertyUsecase(a domain.PropertyRepository, c domain.PropertyCacheRepository, mq *amqp.Channel, timeout time.Duration) domain.PropertyUsecase {
+// PropertyDeps holds the dependencies shared by the property, media and
+// duplicate usecases. Properties, Cache and Authorizer are required; the
+// others are left out of the work when nil.
+type PropertyDeps struct {
+	Properties domain.PropertyRepository
+	Cache      domain.PropertyCacheRepository
+	MQ         rabbitmq.Publisher
+	Stream     domain.EventStreamRepository
+	Authorizer domain.Authorizer
+	Audit      domain.AuditRepository
+	Revisions  domain.PropertyRevisionRepository
+	Prices     domain.PriceHistoryRepository
+	Regions    domain.RegionRepository
+	Media      domain.MediaRepository
+	Blobs      domain.BlobStore
+	Images     domain.ImageProcessor
+	Amenities  domain.AmenityRepository
+	// Tx runs each write together with its audit entry and revision
+	Tx      domain.Transactor
+	Timeout time.Duration
+}
+
+func NewPropertyUsecase(d PropertyDeps) domain.PropertyUsecase {
 	return &propertyUsecase{
-		propertyRepo: a,
-		cacheRepo:    c,
-		mqChannel:    mq,
-		timeout:      timeout,
+		propertyRepo: d.Properties,
+		cacheRepo:    d.Cache,
+		mqChannel:    d.MQ,
+		streamRepo:   d.Stream,
+		authorizer:   d.Authorizer,
+		auditRepo:    d.Audit,
+		revisionRepo: d.Revisions,
+		priceRepo:    d.Prices,
+		regionRepo:   d.Regions,
+		mediaRepo:    d.Media,
+		amenityRepo:  d.Amenities,
+		tx:           d.Tx,
+		timeout:      d.Timeout,
+	}
+}
+
+func (a *propertyUsecase) Fetch(c context.Context, f domain.PropertyFilter) ([]domain.Property, error) {
+	ctx, cancel := context.WithTimeout(c, a.timeout)
+	defer cancel()
+
+	if err := a.authorizer.Authorize(ctx, domain.PermPropertiesRead, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	properties, err := a.propertyRepo.Fetch(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	return properties, a.attach(ctx, propertyRefs(properties)...)
+}
+
+func (a *propertyUsecase) FetchNearby(c context.Context, f domain.PropertyFilter, q domain.GeoQuery) ([]domain.NearbyProperty, error) {
+	ctx, cancel := context.WithTimeout(c, a.timeout)
+	defer cancel()
+
+	if err := a.authorizer.Authorize(ctx, domain.PermPropertiesRead, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	if err := q.Validate(); err != nil {
+		return nil, err
 	}
+	if f.Limit <= 0 || f.Limit > domain.MaxGeoResults {
+		f.Limit = domain.MaxGeoResults
+	}
+	nearby, err := a.propertyRepo.FetchNearby(ctx, f, q)
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]*domain.Property, len(nearby))
+	for i := range nearby {
+		refs[i] = &nearby[i].Property
+	}
+	return nearby, a.attach(ctx, refs...)
 }
 
-func (a *propertyUsecase) Fetch(c context.Context, limit int, offset int) ([]domain.Property, error) {
+func (a *propertyUsecase) Facets(c context.Context, f domain.PropertyFilter) (domain.PropertyFacets, error) {
 	ctx, cancel := context.WithTimeout(c, a.timeout)
 	defer cancel()
-	return a.propertyRepo.Fetch(ctx, limit, offset)
+
+	if err := a.authorizer.Authorize(ctx, domain.PermPropertiesRead, domain.Resource{}); err != nil {
+		return domain.PropertyFacets{}, err
+	}
+	return a.propertyRepo.Facets(ctx, f)
 }
 
 func (a *propertyUsecase) GetByID(c context.Context, id int64) (domain.Property, error) {
 	ctx, cancel := context.WithTimeout(c, a.timeout)
 	defer cancel()
 
+	if err := a.authorizer.Authorize(ctx, domain.PermPropertiesRead, domain.Resource{}); err != nil {
+		return domain.Property{}, err
+	}
+
 	// 1. Try Cache
-	cacheKey := "property:" + strconv.FormatInt(id, 10)
+	cacheKey := propertyCacheKey(id)
 	if cachedProp, err := a.cacheRepo.Get(ctx, cacheKey); err == nil && cachedProp != nil {
 		return *cachedProp, nil
 	}
@@ -48,6 +132,9 @@ func (a *propertyUsecase) GetByID(c context.Context, id int64) (domain.Property,
 	if err != nil {
 		return domain.Property{}, err
 	}
+	if err := a.attach(ctx, &res); err != nil {
+		return domain.Property{}, err
+	}
 
 	// 3. Set Cache (Async or blocking, here blocking for simplicity)
 	_ = a.cacheRepo.Set(ctx, cacheKey, &res, 5*time.Minute)
@@ -59,24 +146,58 @@ func (a *propertyUsecase) Store(c context.Context, p *domain.Property) error {
 	ctx, cancel := context.WithTimeout(c, a.timeout)
 	defer cancel()
 
+	if err := a.authorizer.Authorize(ctx, domain.PermPropertiesCreate, domain.Resource{}); err != nil {
+		return err
+	}
+
+	// New listings belong to the agent creating them and to their branch
+	if principal, ok := domain.PrincipalFromContext(ctx); ok {
+		p.AgentID = principal.UserID
+		p.BranchID = principal.BranchID
+	}
+	if p.Price.Currency == "" {
+		p.Price.Currency = domain.DefaultCurrency
+	}
+	if err := resolveAddress(ctx, a.regionRepo, &p.Address); err != nil {
+		return err
+	}
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	if err := resolveAmenities(ctx, a.amenityRepo, p.Amenities); err != nil {
+		return err
+	}
+	// A location given on creation is set by hand; otherwise the worker geocodes the address
+	p.Geocode = nil
+	p.Media = nil
+	// Listings start as drafts and are published through Transition
+	p.Status = domain.PropertyDraft
+	p.PublishedAt, p.ReservedAt, p.SoldAt, p.RentedAt, p.ArchivedAt = nil, nil, nil, nil, nil
+
 	// 1. Store in DB
-	if err := a.propertyRepo.Store(ctx, p); err != nil {
+	err := withinTx(ctx, a.tx, func(ctx context.Context) error {
+		if err := a.propertyRepo.Store(ctx, p); err != nil {
+			return err
+		}
+		if err := a.setAmenities(ctx, p.ID, p.Amenities); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, a.auditRepo, domain.AuditCreate, domain.ChangeKindProperty, p.ID, nil, p); err != nil {
+			return err
+		}
+		if err := recordRevision(ctx, a.revisionRepo, *p, 0); err != nil {
+			return err
+		}
+		return recordPriceChange(ctx, a.priceRepo, p.ID, nil, p.Price)
+	})
+	if err != nil {
 		return err
 	}
 
 	// 2. Publish Event to RabbitMQ
 	// We do this asynchronously or synchronously depending on consistency requirements.
 	// For this demo, we ignore errors here to not block the response, but in prod we'd handle them.
-	body := []byte(fmt.Sprintf(`{"event": "property_created", "id": %d, "title": "%s"}`, p.ID, p.Title))
-	_ = a.mqChannel.Publish(
-		"",                // exchange
-		"property_events", // routing key (queue name)
-		false,             // mandatory
-		false,             // immediate
-		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
-		})
+	_ = publishEvent(ctx, a.mqChannel, a.streamRepo, "property_events", domain.EventPropertyCreated, p.ID, p)
 
 	return nil
 }
@@ -84,11 +205,316 @@ func (a *propertyUsecase) Store(c context.Context, p *domain.Property) error {
 func (a *propertyUsecase) Update(c context.Context, p *domain.Property) error {
 	ctx, cancel := context.WithTimeout(c, a.timeout)
 	defer cancel()
-	return a.propertyRepo.Update(ctx, p)
+
+	existing, err := a.authorizeExisting(ctx, domain.PermPropertiesUpdate, p.ID)
+	if err != nil {
+		return err
+	}
+	// Clients written before properties had a type keep sending updates without one
+	if p.PropertyType == "" {
+		p.PropertyType = existing.PropertyType
+	}
+	if p.ListingType == "" {
+		p.ListingType = existing.ListingType
+	}
+	if err := resolveAddress(ctx, a.regionRepo, &p.Address); err != nil {
+		return err
+	}
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	if err := resolveAmenities(ctx, a.amenityRepo, p.Amenities); err != nil {
+		return err
+	}
+	return a.update(ctx, existing, p, 0)
+}
+
+// update writes p over existing and records the new revision
+func (a *propertyUsecase) update(ctx context.Context, existing domain.Property, p *domain.Property, rolledBackFrom int) error {
+	// Ownership and status are not editable through Update
+	p.AgentID = existing.AgentID
+	p.BranchID = existing.BranchID
+	p.Status = existing.Status
+	p.PublishedAt, p.ReservedAt, p.SoldAt, p.RentedAt, p.ArchivedAt = existing.PublishedAt, existing.ReservedAt, existing.SoldAt, existing.RentedAt, existing.ArchivedAt
+	// A location left out or sent back unchanged keeps its geocode, so the
+	// worker can tell whether the address moved on since. Any other location
+	// was set by hand.
+	p.Media = nil
+	switch {
+	case p.Location == nil:
+		p.Location, p.Geocode = existing.Location, existing.Geocode
+	case sameLocation(p.Location, existing.Location):
+		p.Geocode = existing.Geocode
+	default:
+		p.Geocode = nil
+	}
+	if p.Price.Currency == "" {
+		p.Price.Currency = domain.DefaultCurrency
+	}
+	// Amenities left out are kept, so older clients do not clear them
+	if err := attachAmenities(ctx, a.amenityRepo, &existing); err != nil {
+		return err
+	}
+	setAmenities := p.Amenities != nil
+	if !setAmenities {
+		p.Amenities = existing.Amenities
+	}
+
+	err := withinTx(ctx, a.tx, func(ctx context.Context) error {
+		if err := a.propertyRepo.Update(ctx, p); err != nil {
+			return err
+		}
+		if setAmenities {
+			if err := a.setAmenities(ctx, p.ID, p.Amenities); err != nil {
+				return err
+			}
+		}
+		if err := recordAudit(ctx, a.auditRepo, domain.AuditUpdate, domain.ChangeKindProperty, p.ID, existing, p); err != nil {
+			return err
+		}
+		if err := recordRevision(ctx, a.revisionRepo, *p, rolledBackFrom); err != nil {
+			return err
+		}
+		return recordPriceChange(ctx, a.priceRepo, p.ID, &existing.Price, p.Price)
+	})
+	if err != nil {
+		return err
+	}
+	_ = a.cacheRepo.Delete(ctx, propertyCacheKey(p.ID))
+
+	_ = publishEvent(ctx, a.mqChannel, a.streamRepo, "property_events", domain.EventPropertyUpdated, p.ID, p)
+
+	return nil
 }
 
 func (a *propertyUsecase) Delete(c context.Context, id int64) error {
 	ctx, cancel := context.WithTimeout(c, a.timeout)
 	defer cancel()
-	return a.propertyRepo.Delete(ctx, id)
+
+	existing, err := a.authorizeExisting(ctx, domain.PermPropertiesDelete, id)
+	if err != nil {
+		return err
+	}
+
+	err = withinTx(ctx, a.tx, func(ctx context.Context) error {
+		if err := a.propertyRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, a.auditRepo, domain.AuditDelete, domain.ChangeKindProperty, id, existing, nil)
+	})
+	if err != nil {
+		return err
+	}
+	_ = a.cacheRepo.Delete(ctx, propertyCacheKey(id))
+
+	_ = publishEvent(ctx, a.mqChannel, a.streamRepo, "property_events", domain.EventPropertyDeleted, id, map[string]int64{"id": id})
+
+	return nil
+}
+
+// Restore takes a property out of the trash. It needs the same permission as Delete.
+func (a *propertyUsecase) Restore(c context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(c, a.timeout)
+	defer cancel()
+
+	trashed, err := a.propertyRepo.GetTrashed(ctx, id)
+	if err != nil {
+		return err
+	}
+	resource := domain.Resource{OwnerID: trashed.AgentID, BranchID: trashed.BranchID}
+	if err := a.authorizer.Authorize(ctx, domain.PermPropertiesDelete, resource); err != nil {
+		return err
+	}
+
+	var restored domain.Property
+	err = withinTx(ctx, a.tx, func(ctx context.Context) error {
+		if err := a.propertyRepo.Restore(ctx, id); err != nil {
+			return err
+		}
+		if restored, err = a.propertyRepo.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, a.auditRepo, domain.AuditRestore, domain.ChangeKindProperty, id, trashed, restored)
+	})
+	if err != nil {
+		return err
+	}
+	_ = a.cacheRepo.Delete(ctx, propertyCacheKey(id))
+
+	_ = publishEvent(ctx, a.mqChannel, a.streamRepo, "property_events", domain.EventPropertyRestored, id, restored)
+
+	return nil
+}
+
+func (a *propertyUsecase) FetchRevisions(c context.Context, id int64, limit int, offset int) ([]domain.PropertyRevision, error) {
+	ctx, cancel := context.WithTimeout(c, a.timeout)
+	defer cancel()
+
+	if _, err := a.authorizeExisting(ctx, domain.PermPropertiesRead, id); err != nil {
+		return nil, err
+	}
+	return a.revisionRepo.Fetch(ctx, id, limit, offset)
+}
+
+func (a *propertyUsecase) DiffRevisions(c context.Context, id int64, from int, to int) (domain.RevisionDiff, error) {
+	ctx, cancel := context.WithTimeout(c, a.timeout)
+	defer cancel()
+
+	if _, err := a.authorizeExisting(ctx, domain.PermPropertiesRead, id); err != nil {
+		return domain.RevisionDiff{}, err
+	}
+
+	older, err := a.revisionRepo.Get(ctx, id, from)
+	if err != nil {
+		return domain.RevisionDiff{}, err
+	}
+	newer, err := a.revisionRepo.Get(ctx, id, to)
+	if err != nil {
+		return domain.RevisionDiff{}, err
+	}
+
+	changes, err := diffProperties(older.Property, newer.Property)
+	if err != nil {
+		return domain.RevisionDiff{}, err
+	}
+	return domain.RevisionDiff{PropertyID: id, From: from, To: to, Changes: changes}, nil
+}
+
+func (a *propertyUsecase) Rollback(c context.Context, id int64, revision int) (domain.Property, error) {
+	ctx, cancel := context.WithTimeout(c, a.timeout)
+	defer cancel()
+
+	existing, err := a.authorizeExisting(ctx, domain.PermPropertiesUpdate, id)
+	if err != nil {
+		return domain.Property{}, err
+	}
+	rev, err := a.revisionRepo.Get(ctx, id, revision)
+	if err != nil {
+		return domain.Property{}, err
+	}
+
+	p := rev.Property
+	p.ID = id
+	p.CreatedAt = existing.CreatedAt
+	p.UpdatedAt = existing.UpdatedAt
+	p.DeletedAt = nil
+	// Revisions from before property and listing types were recorded keep the current ones
+	if p.PropertyType == "" {
+		p.PropertyType = existing.PropertyType
+	}
+	if p.ListingType == "" {
+		p.ListingType = existing.ListingType
+	}
+	// Revisions from before amenities were recorded keep the current ones too
+	if err := resolveAmenities(ctx, a.amenityRepo, p.Amenities); err != nil {
+		return domain.Property{}, err
+	}
+	if err := a.update(ctx, existing, &p, revision); err != nil {
+		return domain.Property{}, err
+	}
+	return p, nil
+}
+
+// Transition moves a property through its listing lifecycle
+func (a *propertyUsecase) Transition(c context.Context, id int64, status string) (domain.Property, error) {
+	ctx, cancel := context.WithTimeout(c, a.timeout)
+	defer cancel()
+
+	if !domain.ValidPropertyStatus(status) {
+		return domain.Property{}, domain.ErrInvalidStatus
+	}
+	existing, err := a.authorizeExisting(ctx, domain.PermPropertiesUpdate, id)
+	if err != nil {
+		return domain.Property{}, err
+	}
+	if !domain.CanTransition(existing.Status, status) {
+		return domain.Property{}, domain.ErrInvalidTransition
+	}
+
+	var updated domain.Property
+	err = withinTx(ctx, a.tx, func(ctx context.Context) error {
+		if err := a.propertyRepo.UpdateStatus(ctx, id, existing.Status, status); err != nil {
+			return err
+		}
+		if updated, err = a.propertyRepo.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, a.auditRepo, domain.AuditStatus, domain.ChangeKindProperty, id, existing, updated)
+	})
+	if err != nil {
+		return domain.Property{}, err
+	}
+	_ = a.cacheRepo.Delete(ctx, propertyCacheKey(id))
+
+	change := domain.PropertyStatusChange{PropertyID: id, From: existing.Status, To: status, ChangedAt: updated.UpdatedAt}
+	_ = publishEvent(ctx, a.mqChannel, a.streamRepo, "property_events", domain.EventPropertyStatusChanged, id, change)
+
+	return updated, nil
+}
+
+func (a *propertyUsecase) FetchPublished(c context.Context, f domain.PropertyFilter) ([]domain.Property, error) {
+	ctx, cancel := context.WithTimeout(c, a.timeout)
+	defer cancel()
+
+	f.Statuses = []string{domain.PropertyPublished}
+	properties, err := a.propertyRepo.Fetch(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	return properties, a.attach(ctx, propertyRefs(properties)...)
+}
+
+func (a *propertyUsecase) GetPublished(c context.Context, id int64) (domain.Property, error) {
+	ctx, cancel := context.WithTimeout(c, a.timeout)
+	defer cancel()
+
+	p, err := a.propertyRepo.GetByID(ctx, id)
+	if err != nil {
+		return domain.Property{}, err
+	}
+	if p.Status != domain.PropertyPublished {
+		return domain.Property{}, domain.ErrPropertyNotFound
+	}
+	return p, a.attach(ctx, &p)
+}
+
+// attach fills in the media and amenities of properties
+func (a *propertyUsecase) attach(ctx context.Context, properties ...*domain.Property) error {
+	if err := attachMedia(ctx, a.mediaRepo, properties...); err != nil {
+		return err
+	}
+	return attachAmenities(ctx, a.amenityRepo, properties...)
+}
+
+// setAmenities writes the resolved amenities of a property
+func (a *propertyUsecase) setAmenities(ctx context.Context, id int64, amenities []domain.PropertyAmenity) error {
+	if a.amenityRepo == nil {
+		return nil
+	}
+	return a.amenityRepo.SetForProperty(ctx, id, amenities)
+}
+
+func sameLocation(a, b *domain.GeoPoint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func propertyCacheKey(id int64) string {
+	return "property:" + strconv.FormatInt(id, 10)
+}
+
+// authorizeExisting loads property id and checks permission against its owner and branch
+func (a *propertyUsecase) authorizeExisting(ctx context.Context, permission string, id int64) (domain.Property, error) {
+	existing, err := a.propertyRepo.GetByID(ctx, id)
+	if err != nil {
+		return domain.Property{}, err
+	}
+
+	resource := domain.Resource{OwnerID: existing.AgentID, BranchID: existing.BranchID}
+	if err := a.authorizer.Authorize(ctx, permission, resource); err != nil {
+		return domain.Property{}, err
+	}
+	return existing, nil
 }
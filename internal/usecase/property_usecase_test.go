@@ -18,10 +18,18 @@ type MockPropertyRepo struct {
 	mock.Mock
 }
 
-func (m *MockPropertyRepo) Fetch(ctx context.Context, limit, offset int) ([]domain.Property, error) {
-	args := m.Called(ctx, limit, offset)
+func (m *MockPropertyRepo) Fetch(ctx context.Context, f domain.PropertyFilter) ([]domain.Property, error) {
+	args := m.Called(ctx, f)
 	return args.Get(0).([]domain.Property), args.Error(1)
 }
+func (m *MockPropertyRepo) FetchNearby(ctx context.Context, f domain.PropertyFilter, q domain.GeoQuery) ([]domain.NearbyProperty, error) {
+	args := m.Called(ctx, f, q)
+	return args.Get(0).([]domain.NearbyProperty), args.Error(1)
+}
+func (m *MockPropertyRepo) UpdateGeocode(ctx context.Context, id int64, updatedAt time.Time, loc domain.GeoPoint, g domain.Geocode) error {
+	args := m.Called(ctx, id, updatedAt, loc, g)
+	return args.Error(0)
+}
 func (m *MockPropertyRepo) GetByID(ctx context.Context, id int64) (domain.Property, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(domain.Property), args.Error(1)
@@ -38,6 +46,38 @@ func (m *MockPropertyRepo) Delete(ctx context.Context, id int64) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
+func (m *MockPropertyRepo) FetchTrashed(ctx context.Context, limit, offset int) ([]domain.Property, error) {
+	args := m.Called(ctx, limit, offset)
+	return args.Get(0).([]domain.Property), args.Error(1)
+}
+func (m *MockPropertyRepo) GetTrashed(ctx context.Context, id int64) (domain.Property, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.Property), args.Error(1)
+}
+func (m *MockPropertyRepo) Restore(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *MockPropertyRepo) UpdateStatus(ctx context.Context, id int64, from, to string) error {
+	args := m.Called(ctx, id, from, to)
+	return args.Error(0)
+}
+func (m *MockPropertyRepo) FetchDuplicateCandidates(ctx context.Context, p domain.Property, limit int) ([]domain.Property, error) {
+	args := m.Called(ctx, p, limit)
+	return args.Get(0).([]domain.Property), args.Error(1)
+}
+func (m *MockPropertyRepo) Merge(ctx context.Context, id int64, duplicateID int64) error {
+	args := m.Called(ctx, id, duplicateID)
+	return args.Error(0)
+}
+func (m *MockPropertyRepo) Facets(ctx context.Context, f domain.PropertyFilter) (domain.PropertyFacets, error) {
+	args := m.Called(ctx, f)
+	return args.Get(0).(domain.PropertyFacets), args.Error(1)
+}
+func (m *MockPropertyRepo) Purge(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
 
 type MockCacheRepo struct {
 	mock.Mock
@@ -59,12 +99,38 @@ func (m *MockCacheRepo) Delete(ctx context.Context, key string) error {
 	return args.Error(0)
 }
 
+// idr returns whole rupiah as Money
+func idr(rupiah int64) domain.Money {
+	return domain.NewMoney(rupiah*100, domain.DefaultCurrency)
+}
+
+// allowAll is a domain.Authorizer that permits everything
+type allowAll struct{}
+
+func (allowAll) Authorize(ctx context.Context, permission string, resource domain.Resource) error {
+	return nil
+}
+
+type txMarker struct{}
+
+// fakeTx is a domain.Transactor that marks the context of its transactions
+type fakeTx struct{}
+
+func (fakeTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(context.WithValue(ctx, txMarker{}, true))
+}
+
+// inTx matches contexts of a fakeTx transaction
+func inTx(ctx context.Context) bool {
+	return ctx.Value(txMarker{}) != nil
+}
+
 func TestGetByID(t *testing.T) {
 	mockRepo := new(MockPropertyRepo)
 	mockCache := new(MockCacheRepo)
 	// Passing nil for amqp channel since we are not testing Store here, or we can mock it if needed but it's a struct pointer in implementation, strict dependency injection would be better with interface.
 	// For this test we only test GetByID which doesn't use RabbitMQ.
-	u := usecase.NewPropertyUsecase(mockRepo, mockCache, nil, 2*time.Second)
+	u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: mockCache, Authorizer: allowAll{}, Timeout: 2 * time.Second})
 
 	t.Run("success from cache", func(t *testing.T) {
 		mockProp := &domain.Property{ID: 1, Title: "Test Property"}
@@ -92,3 +158,429 @@ func TestGetByID(t *testing.T) {
 		mockCache.AssertExpectations(t)
 	})
 }
+
+type MockAuditRepo struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepo) Store(ctx context.Context, e *domain.AuditEntry) error {
+	args := m.Called(ctx, e)
+	return args.Error(0)
+}
+func (m *MockAuditRepo) Fetch(ctx context.Context, f domain.AuditFilter) ([]domain.AuditEntry, error) {
+	args := m.Called(ctx, f)
+	return args.Get(0).([]domain.AuditEntry), args.Error(1)
+}
+
+func TestUpdateRecordsAudit(t *testing.T) {
+	mockRepo := new(MockPropertyRepo)
+	mockCache := new(MockCacheRepo)
+	mockAudit := new(MockAuditRepo)
+	u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: mockCache, Authorizer: allowAll{}, Audit: mockAudit, Timeout: 2 * time.Second})
+
+	ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 4, TenantID: 2})
+	ctx = domain.ContextWithRequestID(ctx, "req-1")
+
+	existing := domain.Property{ID: 9, Title: "Rumah", Price: idr(1000), AgentID: 4}
+	mockRepo.On("GetByID", mock.Anything, int64(9)).Return(existing, nil).Once()
+	mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Property")).Return(nil).Once()
+	mockCache.On("Delete", mock.Anything, "property:9").Return(nil).Once()
+
+	var entry domain.AuditEntry
+	mockAudit.On("Store", mock.Anything, mock.AnythingOfType("*domain.AuditEntry")).Run(func(args mock.Arguments) {
+		entry = *args.Get(1).(*domain.AuditEntry)
+	}).Return(nil).Once()
+
+	err := u.Update(ctx, &domain.Property{ID: 9, Title: "Rumah", Price: idr(1200), PropertyType: domain.PropertyTypeHouse, ListingType: domain.ListingSale})
+	assert.NoError(t, err)
+
+	assert.Equal(t, domain.AuditUpdate, entry.Action)
+	assert.Equal(t, domain.ChangeKindProperty, entry.Entity)
+	assert.Equal(t, int64(9), entry.EntityID)
+	assert.Equal(t, int64(4), entry.ActorID)
+	assert.Equal(t, int64(2), entry.TenantID)
+	assert.Equal(t, "req-1", entry.RequestID)
+	assert.Contains(t, string(entry.Before), `"price":{"amount":"1000.00","currency":"IDR"}`)
+	assert.Contains(t, string(entry.After), `"price":{"amount":"1200.00","currency":"IDR"}`)
+	mockAudit.AssertExpectations(t)
+}
+
+func TestRestore(t *testing.T) {
+	ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 4, TenantID: 2})
+
+	t.Run("restores from trash", func(t *testing.T) {
+		mockRepo := new(MockPropertyRepo)
+		mockCache := new(MockCacheRepo)
+		u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: mockCache, Authorizer: allowAll{}, Timeout: 2 * time.Second})
+
+		deletedAt := time.Now()
+		mockRepo.On("GetTrashed", mock.Anything, int64(9)).Return(domain.Property{ID: 9, AgentID: 4, DeletedAt: &deletedAt}, nil).Once()
+		mockRepo.On("Restore", mock.Anything, int64(9)).Return(nil).Once()
+		mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, AgentID: 4}, nil).Once()
+		mockCache.On("Delete", mock.Anything, "property:9").Return(nil).Once()
+
+		assert.NoError(t, u.Restore(ctx, 9))
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("not in trash", func(t *testing.T) {
+		mockRepo := new(MockPropertyRepo)
+		u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: new(MockCacheRepo), Authorizer: allowAll{}, Timeout: 2 * time.Second})
+
+		mockRepo.On("GetTrashed", mock.Anything, int64(10)).Return(domain.Property{}, domain.ErrNotInTrash).Once()
+
+		assert.ErrorIs(t, u.Restore(ctx, 10), domain.ErrNotInTrash)
+		mockRepo.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything)
+	})
+}
+
+type MockRevisionRepo struct {
+	mock.Mock
+}
+
+func (m *MockRevisionRepo) Fetch(ctx context.Context, propertyID int64, limit, offset int) ([]domain.PropertyRevision, error) {
+	args := m.Called(ctx, propertyID, limit, offset)
+	return args.Get(0).([]domain.PropertyRevision), args.Error(1)
+}
+func (m *MockRevisionRepo) Get(ctx context.Context, propertyID int64, revision int) (domain.PropertyRevision, error) {
+	args := m.Called(ctx, propertyID, revision)
+	return args.Get(0).(domain.PropertyRevision), args.Error(1)
+}
+func (m *MockRevisionRepo) Store(ctx context.Context, r *domain.PropertyRevision) error {
+	args := m.Called(ctx, r)
+	return args.Error(0)
+}
+
+func TestDiffRevisions(t *testing.T) {
+	mockRepo := new(MockPropertyRepo)
+	mockRevisions := new(MockRevisionRepo)
+	u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: new(MockCacheRepo), Authorizer: allowAll{}, Revisions: mockRevisions, Timeout: 2 * time.Second})
+
+	mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, AgentID: 4}, nil).Once()
+	mockRevisions.On("Get", mock.Anything, int64(9), 1).Return(domain.PropertyRevision{Revision: 1, Property: domain.Property{ID: 9, Title: "Rumah", Price: idr(1000), UpdatedAt: time.Now()}}, nil).Once()
+	mockRevisions.On("Get", mock.Anything, int64(9), 3).Return(domain.PropertyRevision{Revision: 3, Property: domain.Property{ID: 9, Title: "Rumah", Price: idr(1200), BranchID: 2}}, nil).Once()
+
+	diff, err := u.DiffRevisions(context.Background(), 9, 1, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.FieldChange{
+		{Field: "branch_id", From: []byte("null"), To: []byte("2")},
+		{Field: "price", From: []byte(`{"amount":"1000.00","currency":"IDR"}`), To: []byte(`{"amount":"1200.00","currency":"IDR"}`)},
+	}, diff.Changes)
+}
+
+// denyResource is a domain.Authorizer that refuses every resource it was not given
+type denyResource struct {
+	allowed domain.Resource
+}
+
+func (d denyResource) Authorize(ctx context.Context, permission string, resource domain.Resource) error {
+	if resource != d.allowed {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+func TestRevisionsAuthorizeAgainstProperty(t *testing.T) {
+	mockRepo := new(MockPropertyRepo)
+	mockRevisions := new(MockRevisionRepo)
+	u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: new(MockCacheRepo), Authorizer: denyResource{allowed: domain.Resource{OwnerID: 4}}, Revisions: mockRevisions, Timeout: 2 * time.Second})
+
+	mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, AgentID: 7, BranchID: 3}, nil)
+
+	_, err := u.FetchRevisions(context.Background(), 9, 10, 0)
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	_, err = u.DiffRevisions(context.Background(), 9, 1, 2)
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	mockRevisions.AssertNotCalled(t, "Fetch", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockRevisions.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRollbackCreatesRevision(t *testing.T) {
+	mockRepo := new(MockPropertyRepo)
+	mockCache := new(MockCacheRepo)
+	mockRevisions := new(MockRevisionRepo)
+	u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: mockCache, Authorizer: allowAll{}, Revisions: mockRevisions, Timeout: 2 * time.Second})
+
+	ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 4, TenantID: 2})
+	existing := domain.Property{ID: 9, Title: "Rumah Baru", Price: idr(1500), AgentID: 4}
+	mockRepo.On("GetByID", mock.Anything, int64(9)).Return(existing, nil).Once()
+	mockRevisions.On("Get", mock.Anything, int64(9), 1).Return(domain.PropertyRevision{Revision: 1, Property: domain.Property{ID: 9, Title: "Rumah", Price: idr(1000), AgentID: 7}}, nil).Once()
+	mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Property")).Return(nil).Once()
+	mockCache.On("Delete", mock.Anything, "property:9").Return(nil).Once()
+
+	var stored domain.PropertyRevision
+	mockRevisions.On("Store", mock.Anything, mock.AnythingOfType("*domain.PropertyRevision")).Run(func(args mock.Arguments) {
+		stored = *args.Get(1).(*domain.PropertyRevision)
+	}).Return(nil).Once()
+
+	p, err := u.Rollback(ctx, 9, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Rumah", p.Title)
+	assert.Equal(t, int64(4), p.AgentID, "rollback keeps the current owner")
+	assert.Equal(t, 1, stored.RolledBackFrom)
+	assert.Equal(t, int64(4), stored.AuthorID)
+	assert.Equal(t, idr(1000), stored.Property.Price)
+	mockRepo.AssertExpectations(t)
+	mockRevisions.AssertExpectations(t)
+}
+
+type MockPriceHistoryRepo struct {
+	mock.Mock
+}
+
+func (m *MockPriceHistoryRepo) Store(ctx context.Context, c *domain.PriceChange) error {
+	args := m.Called(ctx, c)
+	return args.Error(0)
+}
+func (m *MockPriceHistoryRepo) Fetch(ctx context.Context, propertyID int64, limit, offset int) ([]domain.PriceChange, error) {
+	args := m.Called(ctx, propertyID, limit, offset)
+	return args.Get(0).([]domain.PriceChange), args.Error(1)
+}
+func (m *MockPriceHistoryRepo) Summary(ctx context.Context, currency string, since time.Time) (domain.PriceChangeSummary, error) {
+	args := m.Called(ctx, currency, since)
+	return args.Get(0).(domain.PriceChangeSummary), args.Error(1)
+}
+func (m *MockPriceHistoryRepo) FetchStale(ctx context.Context, before time.Time, limit, offset int) ([]domain.StaleListing, error) {
+	args := m.Called(ctx, before, limit, offset)
+	return args.Get(0).([]domain.StaleListing), args.Error(1)
+}
+
+func TestUpdateRecordsPriceChange(t *testing.T) {
+	mockRepo := new(MockPropertyRepo)
+	mockCache := new(MockCacheRepo)
+	mockPrices := new(MockPriceHistoryRepo)
+	u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: mockCache, Authorizer: allowAll{}, Prices: mockPrices, Timeout: 2 * time.Second})
+
+	ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 4, TenantID: 2})
+	mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, Title: "Rumah", Price: idr(1000), AgentID: 4}, nil).Twice()
+	mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Property")).Return(nil).Twice()
+	mockCache.On("Delete", mock.Anything, "property:9").Return(nil).Twice()
+
+	var change domain.PriceChange
+	mockPrices.On("Store", mock.Anything, mock.AnythingOfType("*domain.PriceChange")).Run(func(args mock.Arguments) {
+		change = *args.Get(1).(*domain.PriceChange)
+	}).Return(nil).Once()
+
+	// Only the title changes, so no price history is written
+	assert.NoError(t, u.Update(ctx, &domain.Property{ID: 9, Title: "Rumah Asri", Price: idr(1000), PropertyType: domain.PropertyTypeHouse, ListingType: domain.ListingSale}))
+	mockPrices.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+
+	assert.NoError(t, u.Update(ctx, &domain.Property{ID: 9, Title: "Rumah", Price: idr(900), PropertyType: domain.PropertyTypeHouse, ListingType: domain.ListingSale}))
+	if assert.NotNil(t, change.OldPrice) {
+		assert.Equal(t, idr(1000), *change.OldPrice)
+	}
+	assert.Equal(t, idr(900), change.NewPrice)
+	assert.Equal(t, int64(4), change.ActorID)
+	mockPrices.AssertExpectations(t)
+}
+
+func TestUpdateKeepsLocation(t *testing.T) {
+	ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 4, TenantID: 2})
+	location := &domain.GeoPoint{Lat: -6.9, Lng: 107.6}
+	geocode := &domain.Geocode{Confidence: 0.8, Provider: "gazetteer", Address: "Jl. Braga 10, Kota Bandung"}
+
+	for _, tc := range []struct {
+		name     string
+		location *domain.GeoPoint
+		want     *domain.Geocode
+	}{
+		// A client that does not send the location leaves it where it was
+		{"left out", nil, geocode},
+		{"sent back", &domain.GeoPoint{Lat: -6.9, Lng: 107.6}, geocode},
+		// A location that moved was set by hand and is not geocoded
+		{"moved", &domain.GeoPoint{Lat: -6.91, Lng: 107.6}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockPropertyRepo)
+			mockCache := new(MockCacheRepo)
+			u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: mockCache, Authorizer: allowAll{}, Timeout: 2 * time.Second})
+
+			mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, AgentID: 4, Location: location, Geocode: geocode}, nil).Once()
+			mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Property")).Return(nil).Once()
+			mockCache.On("Delete", mock.Anything, "property:9").Return(nil).Once()
+
+			p := &domain.Property{ID: 9, Title: "Rumah", Price: idr(900), PropertyType: domain.PropertyTypeHouse, ListingType: domain.ListingSale, Location: tc.location}
+			assert.NoError(t, u.Update(ctx, p))
+			assert.NotNil(t, p.Location)
+			assert.Equal(t, tc.want, p.Geocode)
+		})
+	}
+}
+
+func TestUpdateKeepsTypes(t *testing.T) {
+	ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 4, TenantID: 2})
+	mockRepo := new(MockPropertyRepo)
+	mockCache := new(MockCacheRepo)
+	u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: mockCache, Authorizer: allowAll{}, Timeout: 2 * time.Second})
+
+	mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, AgentID: 4, PropertyType: domain.PropertyTypeShophouse, ListingType: domain.ListingRent}, nil).Once()
+	mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(p *domain.Property) bool {
+		return p.PropertyType == domain.PropertyTypeShophouse && p.ListingType == domain.ListingRent
+	})).Return(nil).Once()
+	mockCache.On("Delete", mock.Anything, "property:9").Return(nil).Once()
+
+	// An update from a client that does not know about types leaves them as they were
+	assert.NoError(t, u.Update(ctx, &domain.Property{ID: 9, Title: "Ruko", Price: idr(900)}))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestFetchNearbyLimit(t *testing.T) {
+	mockRepo := new(MockPropertyRepo)
+	u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: new(MockCacheRepo), Authorizer: allowAll{}, Timeout: 2 * time.Second})
+
+	q := domain.GeoQuery{Center: domain.GeoPoint{Lat: -6.2, Lng: 106.8}, RadiusKm: 5}
+	mockRepo.On("FetchNearby", mock.Anything, domain.PropertyFilter{Limit: domain.MaxGeoResults}, q).Return([]domain.NearbyProperty{}, nil).Twice()
+
+	_, err := u.FetchNearby(context.Background(), domain.PropertyFilter{Limit: 10000}, q)
+	assert.NoError(t, err)
+	_, err = u.FetchNearby(context.Background(), domain.PropertyFilter{}, q)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateFailsWithoutPriceHistory(t *testing.T) {
+	mockRepo := new(MockPropertyRepo)
+	mockCache := new(MockCacheRepo)
+	mockPrices := new(MockPriceHistoryRepo)
+	u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: mockCache, Authorizer: allowAll{}, Prices: mockPrices, Timeout: 2 * time.Second})
+
+	ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 4, TenantID: 2})
+	mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, Title: "Rumah", Price: idr(1000), AgentID: 4}, nil).Once()
+	mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Property")).Return(nil).Once()
+	mockPrices.On("Store", mock.Anything, mock.AnythingOfType("*domain.PriceChange")).Return(errors.New("connection reset")).Once()
+
+	// The price history is written in the update's transaction, so the update fails with it
+	err := u.Update(ctx, &domain.Property{ID: 9, Title: "Rumah", Price: idr(900), PropertyType: domain.PropertyTypeHouse, ListingType: domain.ListingSale})
+	assert.Error(t, err)
+	mockCache.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestTransition(t *testing.T) {
+	ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 4, TenantID: 2})
+
+	t.Run("publishes a draft", func(t *testing.T) {
+		mockRepo := new(MockPropertyRepo)
+		mockCache := new(MockCacheRepo)
+		u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: mockCache, Authorizer: allowAll{}, Timeout: 2 * time.Second})
+
+		publishedAt := time.Now()
+		mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, AgentID: 4, Status: domain.PropertyDraft}, nil).Once()
+		mockRepo.On("UpdateStatus", mock.Anything, int64(9), domain.PropertyDraft, domain.PropertyPublished).Return(nil).Once()
+		mockCache.On("Delete", mock.Anything, "property:9").Return(nil).Once()
+		mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, AgentID: 4, Status: domain.PropertyPublished, PublishedAt: &publishedAt}, nil).Once()
+
+		p, err := u.Transition(ctx, 9, domain.PropertyPublished)
+		assert.NoError(t, err)
+		assert.Equal(t, domain.PropertyPublished, p.Status)
+		assert.Equal(t, &publishedAt, p.PublishedAt)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects relisting a sold property", func(t *testing.T) {
+		mockRepo := new(MockPropertyRepo)
+		u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: new(MockCacheRepo), Authorizer: allowAll{}, Timeout: 2 * time.Second})
+
+		mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, AgentID: 4, Status: domain.PropertySold}, nil).Once()
+
+		_, err := u.Transition(ctx, 9, domain.PropertyPublished)
+		assert.ErrorIs(t, err, domain.ErrInvalidTransition)
+		mockRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects unknown statuses", func(t *testing.T) {
+		u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: new(MockPropertyRepo), Cache: new(MockCacheRepo), Authorizer: allowAll{}, Timeout: 2 * time.Second})
+
+		_, err := u.Transition(ctx, 9, "pending")
+		assert.ErrorIs(t, err, domain.ErrInvalidStatus)
+	})
+}
+
+type MockRegionRepo struct {
+	mock.Mock
+}
+
+func (m *MockRegionRepo) Fetch(ctx context.Context, parent string, name string) ([]domain.Region, error) {
+	args := m.Called(ctx, parent, name)
+	return args.Get(0).([]domain.Region), args.Error(1)
+}
+func (m *MockRegionRepo) GetByCodes(ctx context.Context, codes []string) ([]domain.Region, error) {
+	args := m.Called(ctx, codes)
+	return args.Get(0).([]domain.Region), args.Error(1)
+}
+
+func TestUpdateResolvesAddress(t *testing.T) {
+	ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 4, TenantID: 2})
+	chain := []string{"32.73.01.1001", "32.73.01", "32.73", "32"}
+	regions := []domain.Region{
+		{Code: "32", Name: "Jawa Barat", Level: domain.RegionProvince},
+		{Code: "32.73", Name: "Kota Bandung", Level: domain.RegionRegency, ParentCode: "32"},
+		{Code: "32.73.01", Name: "Sukasari", Level: domain.RegionDistrict, ParentCode: "32.73"},
+		{Code: "32.73.01.1001", Name: "Sukarasa", Level: domain.RegionVillage, ParentCode: "32.73.01"},
+	}
+
+	t.Run("fills regions from the village", func(t *testing.T) {
+		mockRepo := new(MockPropertyRepo)
+		mockCache := new(MockCacheRepo)
+		mockRegions := new(MockRegionRepo)
+		u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: mockCache, Authorizer: allowAll{}, Regions: mockRegions, Timeout: 2 * time.Second})
+
+		mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, AgentID: 4}, nil).Once()
+		mockRegions.On("GetByCodes", mock.Anything, chain).Return(regions, nil).Once()
+		mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Property")).Return(nil).Once()
+		mockCache.On("Delete", mock.Anything, "property:9").Return(nil).Once()
+
+		p := &domain.Property{ID: 9, Title: "Rumah", PropertyType: domain.PropertyTypeHouse, ListingType: domain.ListingSale,
+			Address: domain.Address{Street: "Jl. Gegerkalong Hilir 12", RT: "3", RW: "7", RegencyCode: "32.73", VillageCode: "32.73.01.1001"}}
+		assert.NoError(t, u.Update(ctx, p))
+		assert.Equal(t, "003", p.Address.RT)
+		assert.Equal(t, "Jawa Barat", p.Address.Province)
+		assert.Equal(t, "32.73.01", p.Address.DistrictCode)
+		assert.Equal(t, "Sukarasa", p.Address.Village)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects codes from different regions", func(t *testing.T) {
+		mockRepo := new(MockPropertyRepo)
+		u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: new(MockCacheRepo), Authorizer: allowAll{}, Regions: new(MockRegionRepo), Timeout: 2 * time.Second})
+
+		mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, AgentID: 4}, nil).Once()
+
+		err := u.Update(ctx, &domain.Property{ID: 9, Title: "Rumah", PropertyType: domain.PropertyTypeHouse, ListingType: domain.ListingSale,
+			Address: domain.Address{RegencyCode: "31.71", VillageCode: "32.73.01.1001"}})
+		assert.ErrorIs(t, err, domain.ErrInvalidAddress)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects unknown regions", func(t *testing.T) {
+		mockRepo := new(MockPropertyRepo)
+		mockRegions := new(MockRegionRepo)
+		u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: new(MockCacheRepo), Authorizer: allowAll{}, Regions: mockRegions, Timeout: 2 * time.Second})
+
+		mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Property{ID: 9, AgentID: 4}, nil).Once()
+		mockRegions.On("GetByCodes", mock.Anything, chain).Return(regions[:3], nil).Once()
+
+		err := u.Update(ctx, &domain.Property{ID: 9, Title: "Rumah", PropertyType: domain.PropertyTypeHouse, ListingType: domain.ListingSale,
+			Address: domain.Address{VillageCode: "32.73.01.1001"}})
+		assert.ErrorIs(t, err, domain.ErrInvalidAddress)
+	})
+}
+
+func TestFetchNearby(t *testing.T) {
+	ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 4, TenantID: 2})
+	mockRepo := new(MockPropertyRepo)
+	u := usecase.NewPropertyUsecase(usecase.PropertyDeps{Properties: mockRepo, Cache: new(MockCacheRepo), Authorizer: allowAll{}, Timeout: 2 * time.Second})
+
+	q := domain.GeoQuery{Center: domain.GeoPoint{Lat: -8.65, Lng: 115.21}, RadiusKm: 5}
+	f := domain.PropertyFilter{Limit: 10}
+	nearby := []domain.NearbyProperty{{Property: domain.Property{ID: 9}, DistanceKm: 1.2}}
+	mockRepo.On("FetchNearby", mock.Anything, f, q).Return(nearby, nil).Once()
+
+	res, err := u.FetchNearby(ctx, f, q)
+	assert.NoError(t, err)
+	assert.Equal(t, nearby, res)
+
+	_, err = u.FetchNearby(ctx, f, domain.GeoQuery{Center: q.Center, RadiusKm: 1000})
+	assert.ErrorIs(t, err, domain.ErrInvalidGeoQuery)
+	mockRepo.AssertExpectations(t)
+}
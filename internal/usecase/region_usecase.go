@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"nusatek-backend/internal/domain"
+)
+
+// regionUsecase serves public reference data and needs no authorization
+type regionUsecase struct {
+	regionRepo domain.RegionRepository
+	timeout    time.Duration
+}
+
+func NewRegionUsecase(r domain.RegionRepository, timeout time.Duration) domain.RegionUsecase {
+	return &regionUsecase{
+		regionRepo: r,
+		timeout:    timeout,
+	}
+}
+
+func (u *regionUsecase) Fetch(c context.Context, parent string, name string) ([]domain.Region, error) {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	return u.regionRepo.Fetch(ctx, parent, strings.TrimSpace(name))
+}
+
+func (u *regionUsecase) GetByCode(c context.Context, code string) (domain.Region, error) {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if domain.RegionLevel(code) == 0 {
+		return domain.Region{}, domain.ErrRegionNotFound
+	}
+	regions, err := u.regionRepo.GetByCodes(ctx, []string{code})
+	if err != nil {
+		return domain.Region{}, err
+	}
+	if len(regions) == 0 {
+		return domain.Region{}, domain.ErrRegionNotFound
+	}
+	return regions[0], nil
+}
+
+// resolveAddress normalizes a and fills its region codes and names from the
+// most specific code given. The codes given must all lie on one chain of
+// regions, e.g. a district inside the regency given.
+func resolveAddress(ctx context.Context, repo domain.RegionRepository, a *domain.Address) error {
+	if err := a.Normalize(); err != nil {
+		return err
+	}
+	code := a.MostSpecificCode()
+	if code == "" || repo == nil {
+		return nil
+	}
+	for _, c := range a.Codes() {
+		if c != code && !strings.HasPrefix(code, c+".") {
+			return fmt.Errorf("%w: region %s does not contain %s", domain.ErrInvalidAddress, c, code)
+		}
+	}
+
+	var chain []string
+	for c := code; c != ""; c = domain.ParentRegionCode(c) {
+		chain = append(chain, c)
+	}
+	regions, err := repo.GetByCodes(ctx, chain)
+	if err != nil {
+		return err
+	}
+	if len(regions) != len(chain) {
+		return fmt.Errorf("%w: unknown region %s", domain.ErrInvalidAddress, code)
+	}
+	a.SetRegions(regions)
+	return nil
+}
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"nusatek-backend/internal/domain"
+)
+
+// recordRevision stores p as the next revision of its property. It runs in
+// the write's transaction, so a property never changes without a revision.
+func recordRevision(ctx context.Context, repo domain.PropertyRevisionRepository, p domain.Property, rolledBackFrom int) error {
+	if repo == nil {
+		return nil
+	}
+
+	r := domain.PropertyRevision{PropertyID: p.ID, Property: p, RolledBackFrom: rolledBackFrom}
+	if principal, ok := domain.PrincipalFromContext(ctx); ok {
+		r.AuthorID = principal.UserID
+	}
+	if err := repo.Store(ctx, &r); err != nil {
+		return fmt.Errorf("revision of property %d: %w", p.ID, err)
+	}
+	return nil
+}
+
+// Bookkeeping fields that change on every write, and media which is managed
+// separately, are left out of diffs
+var revisionIgnoredFields = map[string]bool{"id": true, "created_at": true, "updated_at": true, "deleted_at": true, "media": true}
+
+// diffProperties compares two snapshots field by field using their JSON
+// representation, so new Property fields are picked up without changes here
+func diffProperties(from domain.Property, to domain.Property) ([]domain.FieldChange, error) {
+	a, err := jsonFields(from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := jsonFields(to)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(a)+len(b))
+	for f := range a {
+		fields = append(fields, f)
+	}
+	for f := range b {
+		if _, ok := a[f]; !ok {
+			fields = append(fields, f)
+		}
+	}
+	sort.Strings(fields)
+
+	changes := []domain.FieldChange{}
+	for _, f := range fields {
+		if revisionIgnoredFields[f] {
+			continue
+		}
+		before, after := nullIfMissing(a[f]), nullIfMissing(b[f])
+		if !bytes.Equal(before, after) {
+			changes = append(changes, domain.FieldChange{Field: f, From: before, To: after})
+		}
+	}
+	return changes, nil
+}
+
+func jsonFields(v interface{}) (map[string]json.RawMessage, error) {
+	doc, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(doc, &fields)
+	return fields, err
+}
+
+func nullIfMissing(v json.RawMessage) json.RawMessage {
+	if v == nil {
+		return json.RawMessage("null")
+	}
+	return v
+}
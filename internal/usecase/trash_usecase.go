@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"nusatek-backend/internal/domain"
+)
+
+type trashUsecase struct {
+	propertyRepo domain.PropertyRepository
+	customerRepo domain.CustomerRepository
+	mediaRepo    domain.MediaRepository
+	blobStore    domain.BlobStore
+	authorizer   domain.Authorizer
+	timeout      time.Duration
+}
+
+func NewTrashUsecase(p domain.PropertyRepository, c domain.CustomerRepository, m domain.MediaRepository, b domain.BlobStore, az domain.Authorizer, timeout time.Duration) domain.TrashUsecase {
+	return &trashUsecase{
+		propertyRepo: p,
+		customerRepo: c,
+		mediaRepo:    m,
+		blobStore:    b,
+		authorizer:   az,
+		timeout:      timeout,
+	}
+}
+
+// Fetch returns the kinds of trash the caller may read and fails only when
+// they may read neither
+func (t *trashUsecase) Fetch(c context.Context, limit int, offset int) (domain.Trash, error) {
+	ctx, cancel := context.WithTimeout(c, t.timeout)
+	defer cancel()
+
+	trash := domain.Trash{Properties: []domain.Property{}, Customers: []domain.Customer{}}
+	allowed := false
+
+	if err := t.authorizer.Authorize(ctx, domain.PermPropertiesRead, domain.Resource{}); err == nil {
+		allowed = true
+		properties, err := t.propertyRepo.FetchTrashed(ctx, limit, offset)
+		if err != nil {
+			return domain.Trash{}, err
+		}
+		trash.Properties = append(trash.Properties, properties...)
+	} else if !errors.Is(err, domain.ErrForbidden) {
+		return domain.Trash{}, err
+	}
+
+	if err := t.authorizer.Authorize(ctx, domain.PermCustomersRead, domain.Resource{}); err == nil {
+		allowed = true
+		customers, err := t.customerRepo.FetchTrashed(ctx, limit, offset)
+		if err != nil {
+			return domain.Trash{}, err
+		}
+		trash.Customers = append(trash.Customers, customers...)
+	} else if !errors.Is(err, domain.ErrForbidden) {
+		return domain.Trash{}, err
+	}
+
+	if !allowed {
+		return domain.Trash{}, domain.ErrForbidden
+	}
+	return trash, nil
+}
+
+// Purge is called by the worker and so does not authorize. The photos of
+// purged properties are deleted first: if that fails the properties stay in
+// the trash and the next run tries again, rather than leaving files behind
+// that nothing refers to.
+func (t *trashUsecase) Purge(c context.Context, before time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(c, t.timeout)
+	defer cancel()
+
+	media, err := t.mediaRepo.FetchPurgeable(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+	for _, m := range media {
+		keys := []string{m.Key}
+		for name := range m.Variants {
+			keys = append(keys, m.VariantKey(name))
+		}
+		for _, key := range keys {
+			if err := t.blobStore.Delete(ctx, key); err != nil {
+				return 0, fmt.Errorf("deleting photo %d of property %d: %w", m.ID, m.PropertyID, err)
+			}
+		}
+	}
+
+	properties, err := t.propertyRepo.Purge(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+	customers, err := t.customerRepo.Purge(ctx, before)
+	return properties + customers, err
+}
@@ -0,0 +1,69 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"nusatek-backend/internal/domain"
+	"nusatek-backend/internal/usecase"
+)
+
+// failingBlobs is a BlobStore that cannot delete
+type failingBlobs struct {
+	memoryBlobs
+}
+
+func (failingBlobs) Delete(ctx context.Context, key string) error {
+	return errors.New("bucket unreachable")
+}
+
+func TestPurge(t *testing.T) {
+	before := time.Now().Add(-30 * 24 * time.Hour)
+	photo := domain.PropertyMedia{ID: 1, PropertyID: 9, Key: "tenants/2/properties/9/a.jpg",
+		Variants: map[string]domain.MediaVariant{"thumbnail": {}, "card": {}}}
+
+	mockProperties := new(MockPropertyRepo)
+	mockCustomers := new(MockCustomerRepo)
+	mockMedia := new(MockMediaRepo)
+	blobs := memoryBlobs{
+		photo.Key:                       []byte("photo"),
+		photo.VariantKey("thumbnail"):   []byte("thumb"),
+		photo.VariantKey("card"):        []byte("card"),
+		"tenants/2/properties/10/b.jpg": []byte("kept"),
+	}
+	u := usecase.NewTrashUsecase(mockProperties, mockCustomers, mockMedia, blobs, allowAll{}, 2*time.Second)
+
+	mockMedia.On("FetchPurgeable", mock.Anything, before).Return([]domain.PropertyMedia{photo}, nil)
+	mockProperties.On("Purge", mock.Anything, before).Return(int64(1), nil).Once()
+	mockCustomers.On("Purge", mock.Anything, before).Return(int64(2), nil).Once()
+
+	n, err := u.Purge(context.Background(), before)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	// The photo and its variants go with the property
+	assert.Equal(t, []byte("kept"), blobs["tenants/2/properties/10/b.jpg"])
+	assert.Len(t, blobs, 1)
+	mockProperties.AssertExpectations(t)
+	mockCustomers.AssertExpectations(t)
+}
+
+func TestPurgeKeepsTrashWithPhotos(t *testing.T) {
+	before := time.Now().Add(-30 * 24 * time.Hour)
+	mockProperties := new(MockPropertyRepo)
+	mockCustomers := new(MockCustomerRepo)
+	mockMedia := new(MockMediaRepo)
+	u := usecase.NewTrashUsecase(mockProperties, mockCustomers, mockMedia, failingBlobs{memoryBlobs{}}, allowAll{}, 2*time.Second)
+
+	mockMedia.On("FetchPurgeable", mock.Anything, before).
+		Return([]domain.PropertyMedia{{ID: 1, PropertyID: 9, Key: "tenants/2/properties/9/a.jpg"}}, nil)
+
+	// Purging the rows would lose track of the photo, so the next run tries again
+	_, err := u.Purge(context.Background(), before)
+	assert.Error(t, err)
+	mockProperties.AssertNotCalled(t, "Purge", mock.Anything, mock.Anything)
+	mockCustomers.AssertNotCalled(t, "Purge", mock.Anything, mock.Anything)
+}
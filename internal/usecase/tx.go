@@ -0,0 +1,15 @@
+package usecase
+
+import (
+	"context"
+
+	"nusatek-backend/internal/domain"
+)
+
+// withinTx runs fn in a transaction of tx, or directly when there is none
+func withinTx(ctx context.Context, tx domain.Transactor, fn func(ctx context.Context) error) error {
+	if tx == nil {
+		return fn(ctx)
+	}
+	return tx.WithinTx(ctx, fn)
+}
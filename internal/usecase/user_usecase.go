@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"nusatek-backend/internal/domain"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const minPasswordLength = 8
+
+type userUsecase struct {
+	userRepo   domain.UserRepository
+	authorizer domain.Authorizer
+	timeout    time.Duration
+}
+
+func NewUserUsecase(u domain.UserRepository, az domain.Authorizer, timeout time.Duration) domain.UserUsecase {
+	return &userUsecase{
+		userRepo:   u,
+		authorizer: az,
+		timeout:    timeout,
+	}
+}
+
+func (u *userUsecase) GetByID(c context.Context, id int64) (domain.User, error) {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	// Everyone may read their own account; API keys have none
+	if principal, ok := domain.PrincipalFromContext(ctx); !ok || principal.IsAPIKey() || principal.UserID != id {
+		if err := u.authorizer.Authorize(ctx, domain.PermUsersManage, domain.Resource{}); err != nil {
+			return domain.User{}, err
+		}
+	}
+
+	user, err := u.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return domain.User{}, err
+	}
+	// Users are looked up globally for sign-in, so hide other agencies' accounts here
+	if tenant, _ := domain.TenantFromContext(ctx); user.TenantID != tenant {
+		return domain.User{}, domain.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (u *userUsecase) Store(c context.Context, user *domain.User, password string) error {
+	if err := u.authorizer.Authorize(c, domain.PermUsersManage, domain.Resource{}); err != nil {
+		return err
+	}
+
+	// Operators of the platform are only seeded by EnsureAdmin or in the database
+	if user.Role == domain.RolePlatformAdmin {
+		return fmt.Errorf("%w: role %q cannot be granted", domain.ErrInvalidUser, user.Role)
+	}
+
+	// New users join the agency of whoever creates them
+	tenant, ok := domain.TenantFromContext(c)
+	if !ok {
+		return domain.ErrTenantRequired
+	}
+	user.TenantID = tenant
+	return u.create(c, user, password)
+}
+
+func (u *userUsecase) create(c context.Context, user *domain.User, password string) error {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if user.Role == "" {
+		user.Role = domain.RoleAgent
+	}
+	switch user.Role {
+	case domain.RoleAgent, domain.RoleManager, domain.RoleAdmin, domain.RolePlatformAdmin:
+	default:
+		return fmt.Errorf("%w: unknown role %q", domain.ErrInvalidUser, user.Role)
+	}
+
+	user.Email = strings.TrimSpace(user.Email)
+	if _, err := mail.ParseAddress(user.Email); err != nil {
+		return fmt.Errorf("%w: invalid email", domain.ErrInvalidUser)
+	}
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("%w: password must be at least %d characters", domain.ErrInvalidUser, minPasswordLength)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = string(hash)
+
+	return u.userRepo.Store(ctx, user)
+}
+
+func (u *userUsecase) EnsureAdmin(c context.Context, email string, password string) error {
+	if email == "" || password == "" {
+		return nil
+	}
+
+	count, err := u.userRepo.Count(c)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	return u.create(c, &domain.User{Email: email, Name: "Administrator", Role: domain.RolePlatformAdmin, TenantID: domain.DefaultTenantID}, password)
+}
@@ -0,0 +1,314 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"time"
+
+	"nusatek-backend/internal/domain"
+	"nusatek-backend/pkg/webhook"
+)
+
+// defaultSendTimeout bounds a delivery attempt made with a client that has no
+// timeout of its own
+const defaultSendTimeout = 10 * time.Second
+
+type webhookUsecase struct {
+	webhookRepo domain.WebhookRepository
+	httpClient  *http.Client
+	authorizer  domain.Authorizer
+	maxAttempts int
+	timeout     time.Duration
+}
+
+func NewWebhookUsecase(w domain.WebhookRepository, client *http.Client, az domain.Authorizer, maxAttempts int, timeout time.Duration) domain.WebhookUsecase {
+	return &webhookUsecase{
+		webhookRepo: w,
+		httpClient:  client,
+		authorizer:  az,
+		maxAttempts: maxAttempts,
+		timeout:     timeout,
+	}
+}
+
+func (u *webhookUsecase) Fetch(c context.Context) ([]domain.Webhook, error) {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizer.Authorize(ctx, domain.PermWebhooksManage, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	return u.webhookRepo.Fetch(ctx)
+}
+
+func (u *webhookUsecase) GetByID(c context.Context, id int64) (domain.Webhook, error) {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizer.Authorize(ctx, domain.PermWebhooksManage, domain.Resource{}); err != nil {
+		return domain.Webhook{}, err
+	}
+	return u.webhookRepo.GetByID(ctx, id)
+}
+
+func (u *webhookUsecase) Store(c context.Context, w *domain.Webhook) error {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizer.Authorize(ctx, domain.PermWebhooksManage, domain.Resource{}); err != nil {
+		return err
+	}
+
+	if err := validateWebhook(w); err != nil {
+		return err
+	}
+	if w.Secret == "" {
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			return err
+		}
+		w.Secret = secret
+	}
+	return u.webhookRepo.Store(ctx, w)
+}
+
+func (u *webhookUsecase) Update(c context.Context, w *domain.Webhook) error {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizer.Authorize(ctx, domain.PermWebhooksManage, domain.Resource{}); err != nil {
+		return err
+	}
+
+	if err := validateWebhook(w); err != nil {
+		return err
+	}
+	if w.Secret == "" {
+		// Keep the current secret unless a new one is supplied
+		existing, err := u.webhookRepo.GetByID(ctx, w.ID)
+		if err != nil {
+			return err
+		}
+		w.Secret = existing.Secret
+	}
+	return u.webhookRepo.Update(ctx, w)
+}
+
+func (u *webhookUsecase) Delete(c context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizer.Authorize(ctx, domain.PermWebhooksManage, domain.Resource{}); err != nil {
+		return err
+	}
+	return u.webhookRepo.Delete(ctx, id)
+}
+
+func (u *webhookUsecase) FetchDeliveries(c context.Context, webhookID int64, limit int, offset int) ([]domain.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+
+	if err := u.authorizer.Authorize(ctx, domain.PermWebhooksManage, domain.Resource{}); err != nil {
+		return nil, err
+	}
+
+	if _, err := u.webhookRepo.GetByID(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	return u.webhookRepo.FetchDeliveries(ctx, webhookID, limit, offset)
+}
+
+func (u *webhookUsecase) Redeliver(c context.Context, webhookID int64, deliveryID int64) (domain.WebhookDelivery, error) {
+	if err := u.authorizer.Authorize(c, domain.PermWebhooksManage, domain.Resource{}); err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+
+	w, err := u.webhookRepo.GetByID(c, webhookID)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	if !w.Active {
+		return domain.WebhookDelivery{}, domain.ErrWebhookDisabled
+	}
+	d, err := u.webhookRepo.GetDelivery(c, webhookID, deliveryID)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+
+	err = u.deliver(c, w, &d)
+	return d, err
+}
+
+func (u *webhookUsecase) Enqueue(c context.Context, evt domain.Event) error {
+	ctx, cancel := context.WithTimeout(domain.ContextWithTenant(c, evt.Tenant()), u.timeout)
+	defer cancel()
+
+	webhooks, err := u.webhookRepo.FetchActive(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range webhooks {
+		if !w.Subscribes(evt.Type) {
+			continue
+		}
+		d := domain.WebhookDelivery{
+			WebhookID: w.ID,
+			EventID:   evt.EventID,
+			EventType: evt.Type,
+			Payload:   payload,
+			Status:    domain.DeliveryPending,
+		}
+		if err := u.webhookRepo.StoreDelivery(ctx, &d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DispatchDue returns how many deliveries it handled. One that cannot be
+// handled is logged and left due, so the rest of the batch still goes out.
+func (u *webhookUsecase) DispatchDue(c context.Context, limit int) (int, error) {
+	// The claim outlasts a batch in which every delivery times out. Should
+	// it run out all the same, the rest is left to whoever claims it next.
+	lease := time.Duration(limit) * (u.sendTimeout() + 2*u.timeout)
+	expires := time.Now().Add(lease)
+	deliveries, err := u.webhookRepo.FetchDueDeliveries(c, limit, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	handled := 0
+	webhooks := make(map[int64]domain.Webhook)
+	for i := range deliveries {
+		d := &deliveries[i]
+		if time.Until(expires) < u.sendTimeout()+u.timeout {
+			log.Printf("webhooks: claim expiring, leaving %d deliveries for the next batch", len(deliveries)-i)
+			break
+		}
+
+		w, ok := webhooks[d.WebhookID]
+		if !ok {
+			if w, err = u.webhookRepo.GetByID(domain.ContextWithTenant(c, d.TenantID), d.WebhookID); err != nil {
+				log.Printf("webhooks: delivery %d: loading webhook %d: %v", d.ID, d.WebhookID, err)
+				continue
+			}
+			webhooks[d.WebhookID] = w
+		}
+
+		if !w.Active {
+			d.Status = domain.DeliveryFailed
+			d.LastError = domain.ErrWebhookDisabled.Error()
+			d.NextAttemptAt = nil
+			if err := u.webhookRepo.UpdateDelivery(c, d); err != nil {
+				log.Printf("webhooks: delivery %d: %v", d.ID, err)
+				continue
+			}
+			handled++
+			continue
+		}
+
+		// Failed attempts are recorded on the delivery and retried later
+		if err := u.deliver(c, w, d); err != nil {
+			log.Printf("webhooks: delivery %d: %v", d.ID, err)
+			continue
+		}
+		handled++
+	}
+	return handled, nil
+}
+
+// deliver sends d to w once and records the outcome, scheduling a retry with
+// exponential backoff on failure. Only persistence errors are returned.
+func (u *webhookUsecase) deliver(c context.Context, w domain.Webhook, d *domain.WebhookDelivery) error {
+	status, sendErr := u.send(c, w, d)
+
+	now := time.Now().UTC()
+	d.Attempts++
+	d.ResponseStatus = status
+	d.LastError = ""
+
+	switch {
+	case sendErr == nil:
+		d.Status = domain.DeliverySucceeded
+		d.DeliveredAt = &now
+		d.NextAttemptAt = nil
+	case d.Attempts >= u.maxAttempts:
+		d.Status = domain.DeliveryFailed
+		d.LastError = sendErr.Error()
+		d.NextAttemptAt = nil
+	default:
+		next := now.Add(webhook.Backoff(d.Attempts))
+		d.Status = domain.DeliveryPending
+		d.LastError = sendErr.Error()
+		d.NextAttemptAt = &next
+	}
+
+	ctx, cancel := context.WithTimeout(c, u.timeout)
+	defer cancel()
+	return u.webhookRepo.UpdateDelivery(ctx, d)
+}
+
+func (u *webhookUsecase) send(c context.Context, w domain.Webhook, d *domain.WebhookDelivery) (int, error) {
+	timestamp := time.Now().Unix()
+
+	ctx, cancel := context.WithTimeout(c, u.sendTimeout())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.HeaderEvent, d.EventType)
+	req.Header.Set(webhook.HeaderDelivery, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(webhook.HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(webhook.HeaderSignature, webhook.Sign(w.Secret, timestamp, d.Payload))
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// sendTimeout is the longest a delivery attempt may take
+func (u *webhookUsecase) sendTimeout() time.Duration {
+	if u.httpClient.Timeout > 0 {
+		return u.httpClient.Timeout
+	}
+	return defaultSendTimeout
+}
+
+func validateWebhook(w *domain.Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", domain.ErrInvalidWebhook)
+	}
+	// Hostnames are checked again on every delivery, once resolved
+	if ip, err := netip.ParseAddr(u.Hostname()); u.Hostname() == "localhost" || err == nil && !webhook.IsPublic(ip) {
+		return fmt.Errorf("%w: url must point at a public address", domain.ErrInvalidWebhook)
+	}
+	if len(w.EventTypes) == 0 {
+		return fmt.Errorf("%w: at least one event type is required", domain.ErrInvalidWebhook)
+	}
+	return nil
+}
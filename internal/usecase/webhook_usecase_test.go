@@ -0,0 +1,180 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"nusatek-backend/internal/domain"
+	"nusatek-backend/internal/usecase"
+	"nusatek-backend/pkg/webhook"
+)
+
+type MockWebhookRepo struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepo) Fetch(ctx context.Context) ([]domain.Webhook, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Webhook), args.Error(1)
+}
+func (m *MockWebhookRepo) GetByID(ctx context.Context, id int64) (domain.Webhook, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.Webhook), args.Error(1)
+}
+func (m *MockWebhookRepo) Store(ctx context.Context, w *domain.Webhook) error {
+	args := m.Called(ctx, w)
+	return args.Error(0)
+}
+func (m *MockWebhookRepo) Update(ctx context.Context, w *domain.Webhook) error {
+	args := m.Called(ctx, w)
+	return args.Error(0)
+}
+func (m *MockWebhookRepo) Delete(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *MockWebhookRepo) FetchActive(ctx context.Context) ([]domain.Webhook, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Webhook), args.Error(1)
+}
+func (m *MockWebhookRepo) StoreDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	args := m.Called(ctx, d)
+	return args.Error(0)
+}
+func (m *MockWebhookRepo) FetchDeliveries(ctx context.Context, webhookID int64, limit int, offset int) ([]domain.WebhookDelivery, error) {
+	args := m.Called(ctx, webhookID, limit, offset)
+	return args.Get(0).([]domain.WebhookDelivery), args.Error(1)
+}
+func (m *MockWebhookRepo) GetDelivery(ctx context.Context, webhookID int64, id int64) (domain.WebhookDelivery, error) {
+	args := m.Called(ctx, webhookID, id)
+	return args.Get(0).(domain.WebhookDelivery), args.Error(1)
+}
+func (m *MockWebhookRepo) FetchDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+	args := m.Called(ctx, limit, lease)
+	return args.Get(0).([]domain.WebhookDelivery), args.Error(1)
+}
+func (m *MockWebhookRepo) UpdateDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	args := m.Called(ctx, d)
+	return args.Error(0)
+}
+
+func TestStoreWebhook(t *testing.T) {
+	ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 4, TenantID: 2})
+	mockRepo := new(MockWebhookRepo)
+	u := usecase.NewWebhookUsecase(mockRepo, http.DefaultClient, allowAll{}, 3, 2*time.Second)
+
+	for _, url := range []string{"ftp://partner.example.com", "http://localhost:8080/hook", "http://127.0.0.1/hook", "http://169.254.169.254/latest/meta-data", "http://[::1]/hook", "https://10.0.0.5/hook"} {
+		err := u.Store(ctx, &domain.Webhook{URL: url, EventTypes: []string{"*"}})
+		assert.ErrorIs(t, err, domain.ErrInvalidWebhook, url)
+	}
+
+	mockRepo.On("Store", mock.Anything, mock.AnythingOfType("*domain.Webhook")).Return(nil).Once()
+	w := domain.Webhook{URL: "https://partner.example.com/hooks", EventTypes: []string{"property.created"}}
+	assert.NoError(t, u.Store(ctx, &w))
+	assert.Contains(t, w.Secret, "whsec_")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDispatchDue(t *testing.T) {
+	var received []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+		if !webhook.Verify("whsec_test", ts, body, r.Header.Get(webhook.HeaderSignature)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		received = append(received, r.Header.Get(webhook.HeaderDelivery))
+	}))
+	defer srv.Close()
+
+	mockRepo := new(MockWebhookRepo)
+	u := usecase.NewWebhookUsecase(mockRepo, srv.Client(), allowAll{}, 3, 2*time.Second)
+
+	// The claim outlasts ten deliveries that each time out
+	outlastsBatch := mock.MatchedBy(func(lease time.Duration) bool { return lease >= 10*(10*time.Second+2*time.Second) })
+	mockRepo.On("FetchDueDeliveries", mock.Anything, 10, outlastsBatch).Return([]domain.WebhookDelivery{
+		{ID: 1, WebhookID: 7, TenantID: 2, EventType: "property.created", Payload: []byte(`{}`), Status: domain.DeliveryPending},
+		{ID: 2, WebhookID: 8, TenantID: 2, EventType: "property.created", Payload: []byte(`{"id":1}`), Status: domain.DeliveryPending},
+		{ID: 3, WebhookID: 9, TenantID: 2, EventType: "property.created", Payload: []byte(`{}`), Status: domain.DeliveryPending},
+	}, nil)
+	mockRepo.On("GetByID", mock.Anything, int64(7)).Return(domain.Webhook{}, errors.New("connection reset"))
+	mockRepo.On("GetByID", mock.Anything, int64(8)).Return(domain.Webhook{ID: 8, URL: srv.URL, Secret: "whsec_test", Active: true}, nil)
+	mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Webhook{ID: 9, URL: srv.URL, Secret: "whsec_test", Active: false}, nil)
+	mockRepo.On("UpdateDelivery", mock.Anything, mock.MatchedBy(func(d *domain.WebhookDelivery) bool {
+		return d.ID == 2 && d.Status == domain.DeliverySucceeded && d.Attempts == 1 && d.DeliveredAt != nil
+	})).Return(nil).Once()
+	mockRepo.On("UpdateDelivery", mock.Anything, mock.MatchedBy(func(d *domain.WebhookDelivery) bool {
+		return d.ID == 3 && d.Status == domain.DeliveryFailed
+	})).Return(nil).Once()
+
+	// The webhook that cannot be loaded does not hold back the others
+	n, err := u.DispatchDue(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"2"}, received)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDispatchDueRetries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	mockRepo := new(MockWebhookRepo)
+	u := usecase.NewWebhookUsecase(mockRepo, srv.Client(), allowAll{}, 3, 2*time.Second)
+	mockRepo.On("GetByID", mock.Anything, int64(8)).Return(domain.Webhook{ID: 8, URL: srv.URL, Secret: "whsec_test", Active: true}, nil)
+
+	var saved []domain.WebhookDelivery
+	mockRepo.On("UpdateDelivery", mock.Anything, mock.AnythingOfType("*domain.WebhookDelivery")).Run(func(args mock.Arguments) {
+		saved = append(saved, *args.Get(1).(*domain.WebhookDelivery))
+	}).Return(nil)
+
+	for attempts := 0; attempts < 3; attempts++ {
+		mockRepo.On("FetchDueDeliveries", mock.Anything, 10, mock.Anything).Return([]domain.WebhookDelivery{
+			{ID: 2, WebhookID: 8, TenantID: 2, Payload: []byte(`{}`), Status: domain.DeliveryPending, Attempts: attempts},
+		}, nil).Once()
+		_, err := u.DispatchDue(context.Background(), 10)
+		assert.NoError(t, err)
+	}
+
+	// Retries wait 30 s, then twice as long, until the last attempt fails the delivery
+	for i, d := range saved[:2] {
+		assert.Equal(t, domain.DeliveryPending, d.Status)
+		assert.Equal(t, http.StatusServiceUnavailable, d.ResponseStatus)
+		assert.Contains(t, d.LastError, "503")
+		if assert.NotNil(t, d.NextAttemptAt) {
+			assert.WithinDuration(t, time.Now().Add(webhook.Backoff(i+1)), *d.NextAttemptAt, 5*time.Second)
+		}
+	}
+	assert.Equal(t, domain.DeliveryFailed, saved[2].Status)
+	assert.Equal(t, 3, saved[2].Attempts)
+	assert.Nil(t, saved[2].NextAttemptAt)
+}
+
+func TestRedeliverDisabledWebhook(t *testing.T) {
+	sent := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent++
+	}))
+	defer srv.Close()
+
+	ctx := domain.ContextWithPrincipal(context.Background(), domain.Principal{UserID: 4, TenantID: 2})
+	mockRepo := new(MockWebhookRepo)
+	u := usecase.NewWebhookUsecase(mockRepo, srv.Client(), allowAll{}, 3, 2*time.Second)
+	mockRepo.On("GetByID", mock.Anything, int64(9)).Return(domain.Webhook{ID: 9, URL: srv.URL, Secret: "whsec_test", Active: false}, nil)
+
+	_, err := u.Redeliver(ctx, 9, 3)
+	assert.ErrorIs(t, err, domain.ErrWebhookDisabled)
+	assert.Zero(t, sent)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,166 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"nusatek-backend/internal/domain"
+	"nusatek-backend/pkg/rabbitmq"
+
+	"github.com/streadway/amqp"
+)
+
+// HandlerFunc processes a single event. Returning an error causes a retry.
+type HandlerFunc func(ctx context.Context, evt domain.Event) error
+
+// Consumer reads events from a queue and dispatches them to handlers by event type.
+// Every event is claimed in the inbox first so handlers run once per event id,
+// even when RabbitMQ redelivers a message or a publisher retries.
+type Consumer struct {
+	name        string
+	queue       string
+	mqChannel   *amqp.Channel
+	inbox       domain.InboxRepository
+	handlers    map[string][]HandlerFunc
+	maxAttempts int
+}
+
+func NewConsumer(name string, queue string, ch *amqp.Channel, inbox domain.InboxRepository, maxAttempts int) *Consumer {
+	return &Consumer{
+		name:        name,
+		queue:       queue,
+		mqChannel:   ch,
+		inbox:       inbox,
+		handlers:    make(map[string][]HandlerFunc),
+		maxAttempts: maxAttempts,
+	}
+}
+
+// Handle registers h for events of eventType
+func (c *Consumer) Handle(eventType string, h HandlerFunc) {
+	c.handlers[eventType] = append(c.handlers[eventType], h)
+}
+
+// Run consumes messages until ctx is cancelled or the channel is closed
+func (c *Consumer) Run(ctx context.Context) error {
+	if c.mqChannel == nil {
+		return rabbitmq.ErrNoChannel
+	}
+
+	if err := rabbitmq.DeclareRetryQueues(c.mqChannel, c.queue, c.maxAttempts); err != nil {
+		return err
+	}
+	if err := c.mqChannel.Qos(10, 0, false); err != nil {
+		return err
+	}
+
+	deliveries, err := c.mqChannel.Consume(c.queue, c.name, false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case d, ok := <-deliveries:
+			if !ok {
+				return amqp.ErrClosed
+			}
+			c.process(ctx, d)
+		}
+	}
+}
+
+func (c *Consumer) process(ctx context.Context, d amqp.Delivery) {
+	var evt domain.Event
+	if err := json.Unmarshal(d.Body, &evt); err != nil {
+		c.deadLetter(d, err)
+		return
+	}
+	if evt.EventID == "" {
+		evt.EventID = rabbitmq.MessageID(d)
+	}
+
+	handlers := c.handlers[evt.Type]
+	if len(handlers) == 0 {
+		_ = d.Ack(false)
+		return
+	}
+
+	claimed, err := c.inbox.Claim(ctx, c.name, evt.EventID)
+	if err != nil {
+		// Inbox unavailable: try again later rather than risk a duplicate
+		log.Printf("worker %s: inbox claim for %s failed: %v", c.name, evt.EventID, err)
+		_ = d.Nack(false, true)
+		return
+	}
+	if !claimed {
+		log.Printf("worker %s: skipping duplicate event %s", c.name, evt.EventID)
+		_ = d.Ack(false)
+		return
+	}
+
+	for _, h := range handlers {
+		if err := h(ctx, evt); err != nil {
+			_ = c.inbox.Release(ctx, c.name, evt.EventID)
+			c.retry(d, err)
+			return
+		}
+	}
+
+	if err := c.inbox.MarkProcessed(ctx, c.name, evt.EventID); err != nil {
+		log.Printf("worker %s: marking %s processed failed: %v", c.name, evt.EventID, err)
+	}
+	_ = d.Ack(false)
+}
+
+// retry parks d with an incremented attempt counter in the retry queue for
+// that attempt, which hands it back after rabbitmq.RetryDelay, or dead-letters
+// it once maxAttempts is reached.
+func (c *Consumer) retry(d amqp.Delivery, cause error) {
+	attempts := rabbitmq.Death(d).Count + 1
+	if attempts >= int64(c.maxAttempts) {
+		c.deadLetter(d, cause)
+		return
+	}
+
+	log.Printf("worker %s: attempt %d for %s failed, retrying in %s: %v", c.name, attempts, rabbitmq.MessageID(d), rabbitmq.RetryDelay(attempts), cause)
+	if err := c.mqChannel.Publish("", rabbitmq.RetryQueue(c.queue, attempts), false, false, c.republishing(d, attempts, cause)); err != nil {
+		_ = d.Nack(false, true)
+		return
+	}
+	_ = d.Ack(false)
+}
+
+func (c *Consumer) deadLetter(d amqp.Delivery, cause error) {
+	attempts := rabbitmq.Death(d).Count + 1
+
+	log.Printf("worker %s: dead-lettering %s after %d attempts: %v", c.name, rabbitmq.MessageID(d), attempts, cause)
+	if err := c.mqChannel.Publish(rabbitmq.DeadLetterExchange, c.queue, false, false, c.republishing(d, attempts, cause)); err != nil {
+		// Fall back to the queue's own dead-letter routing
+		_ = d.Nack(false, false)
+		return
+	}
+	_ = d.Ack(false)
+}
+
+// republishing copies d for publishing again. The broker's x-death entries
+// are dropped: they would name the retry queue as the origin, and the attempt
+// count lives in HeaderAttempts.
+func (c *Consumer) republishing(d amqp.Delivery, attempts int64, cause error) amqp.Publishing {
+	headers := rabbitmq.WithoutDeaths(d.Headers)
+	headers[rabbitmq.HeaderAttempts] = attempts
+	headers[rabbitmq.HeaderLastError] = cause.Error()
+
+	return amqp.Publishing{
+		Headers:      headers,
+		ContentType:  d.ContentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    rabbitmq.MessageID(d),
+		Timestamp:    d.Timestamp,
+		Type:         d.Type,
+		Body:         d.Body,
+	}
+}
@@ -0,0 +1,108 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"nusatek-backend/internal/domain"
+	"nusatek-backend/pkg/rabbitmq"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryInbox struct {
+	mu   sync.Mutex
+	seen map[string]string
+}
+
+func (m *memoryInbox) Claim(ctx context.Context, consumer, id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.seen[consumer+id]; ok {
+		return false, nil
+	}
+	m.seen[consumer+id] = "processing"
+	return true, nil
+}
+func (m *memoryInbox) MarkProcessed(ctx context.Context, consumer, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seen[consumer+id] = "processed"
+	return nil
+}
+func (m *memoryInbox) Release(ctx context.Context, consumer, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.seen, consumer+id)
+	return nil
+}
+
+type countingAcknowledger struct {
+	acks, nacks int
+}
+
+func (a *countingAcknowledger) Ack(tag uint64, multiple bool) error { a.acks++; return nil }
+func (a *countingAcknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	a.nacks++
+	return nil
+}
+func (a *countingAcknowledger) Reject(tag uint64, requeue bool) error { a.nacks++; return nil }
+
+func TestConsumerSkipsDuplicates(t *testing.T) {
+	inbox := &memoryInbox{seen: map[string]string{}}
+	c := NewConsumer("test", "property_events", nil, inbox, 3)
+
+	calls := 0
+	c.Handle(domain.EventPropertyCreated, func(ctx context.Context, evt domain.Event) error {
+		calls++
+		return nil
+	})
+
+	body, _ := json.Marshal(domain.Event{EventID: "evt-1", Type: domain.EventPropertyCreated, EntityID: 7})
+	ack := &countingAcknowledger{}
+	d := amqp.Delivery{Acknowledger: ack, Body: body}
+
+	c.process(context.Background(), d)
+	c.process(context.Background(), d)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 2, ack.acks)
+	assert.Equal(t, "processed", inbox.seen["testevt-1"])
+}
+
+func TestRepublishingForgetsRetryQueue(t *testing.T) {
+	c := NewConsumer("test", "property_events", nil, &memoryInbox{seen: map[string]string{}}, 5)
+
+	// A message handed back by the retry queue of its second attempt
+	d := amqp.Delivery{
+		MessageId: "evt-1",
+		Body:      []byte(`{}`),
+		Headers: amqp.Table{
+			rabbitmq.HeaderAttempts: int64(2),
+			"tenant":                "2",
+			"x-first-death-queue":   "property_events.retry.2",
+			"x-death": []interface{}{amqp.Table{
+				"reason": "expired", "count": int64(1), "queue": "property_events.retry.2", "exchange": "", "routing-keys": []interface{}{"property_events.retry.2"},
+			}},
+		},
+		RoutingKey: "property_events",
+	}
+	attempts := rabbitmq.Death(d).Count + 1
+	assert.Equal(t, int64(3), attempts)
+
+	msg := c.republishing(d, attempts, errors.New("smtp down"))
+	assert.Equal(t, amqp.Table{
+		rabbitmq.HeaderAttempts:  int64(3),
+		rabbitmq.HeaderLastError: "smtp down",
+		"tenant":                 "2",
+	}, msg.Headers)
+
+	// Once dead-lettered it is replayed onto the work queue, not the retry queue
+	death := rabbitmq.Death(amqp.Delivery{Headers: msg.Headers, RoutingKey: "property_events"})
+	assert.Equal(t, "property_events", death.RoutingKey)
+	assert.Equal(t, int64(3), death.Count)
+}
@@ -0,0 +1,14 @@
+package worker
+
+import (
+	"context"
+
+	"nusatek-backend/internal/domain"
+)
+
+// GeocodeProperties returns a handler that locates properties whose address changed
+func GeocodeProperties(uc domain.GeocodeUsecase) HandlerFunc {
+	return func(ctx context.Context, evt domain.Event) error {
+		return uc.GeocodeProperty(domain.ContextWithTenant(ctx, evt.Tenant()), evt.EntityID)
+	}
+}
@@ -0,0 +1,14 @@
+package worker
+
+import (
+	"context"
+	"log"
+
+	"nusatek-backend/internal/domain"
+)
+
+// LogEvent records every consumed event in the worker log
+func LogEvent(ctx context.Context, evt domain.Event) error {
+	log.Printf("event %s: %s id=%d at %s", evt.EventID, evt.Type, evt.EntityID, evt.OccurredAt.Format("2006-01-02T15:04:05Z07:00"))
+	return nil
+}
@@ -0,0 +1,19 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+
+	"nusatek-backend/internal/domain"
+)
+
+// ProcessMedia returns a handler that cleans and resizes uploaded photos
+func ProcessMedia(uc domain.MediaProcessingUsecase) HandlerFunc {
+	return func(ctx context.Context, evt domain.Event) error {
+		var m domain.PropertyMedia
+		if err := json.Unmarshal(evt.Data, &m); err != nil {
+			return err
+		}
+		return uc.Process(domain.ContextWithTenant(ctx, evt.Tenant()), evt.EntityID, m.ID)
+	}
+}
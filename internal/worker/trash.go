@@ -0,0 +1,32 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"nusatek-backend/internal/domain"
+)
+
+// RunTrashPurge permanently removes records that have been in the trash for
+// longer than retention, checking every interval until ctx is cancelled
+func RunTrashPurge(ctx context.Context, uc domain.TrashUsecase, interval time.Duration, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := uc.Purge(ctx, time.Now().Add(-retention))
+			if err != nil {
+				log.Printf("trash purge: %v", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("trash purge: removed %d records deleted over %s ago", n, retention)
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"nusatek-backend/internal/domain"
+)
+
+// EnqueueWebhooks returns a handler that queues a delivery for every webhook subscribed to the event
+func EnqueueWebhooks(uc domain.WebhookUsecase) HandlerFunc {
+	return func(ctx context.Context, evt domain.Event) error {
+		return uc.Enqueue(ctx, evt)
+	}
+}
+
+// RunWebhookDispatcher sends due webhook deliveries every interval until ctx is cancelled
+func RunWebhookDispatcher(ctx context.Context, uc domain.WebhookUsecase, interval time.Duration, batch int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Keep draining while full batches are returned
+			for {
+				n, err := uc.DispatchDue(ctx, batch)
+				if err != nil {
+					log.Printf("webhook dispatcher: %v", err)
+					break
+				}
+				if n < batch {
+					break
+				}
+			}
+		}
+	}
+}
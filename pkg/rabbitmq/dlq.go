@@ -0,0 +1,218 @@
+package rabbitmq
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	// DeadLetterExchange receives every message rejected or expired on a work queue
+	DeadLetterExchange = "events.dlx"
+	// DeadLetterQueue holds dead-lettered messages until an operator replays them
+	DeadLetterQueue = "events.dlq"
+
+	// HeaderLastError carries the failure reason set by a consumer before dead-lettering
+	HeaderLastError = "x-last-error"
+	// HeaderAttempts carries the number of delivery attempts made by a consumer
+	HeaderAttempts = "x-attempts"
+)
+
+var ErrNoChannel = errors.New("rabbitmq channel is not available")
+
+// DeathInfo describes why and from where a message was dead-lettered
+type DeathInfo struct {
+	Reason     string
+	Count      int64
+	Queue      string
+	Exchange   string
+	RoutingKey string
+	Time       time.Time
+}
+
+// Channel is the part of *amqp.Channel used to inspect and replay queues
+type Channel interface {
+	Publisher
+	QueueDeclarePassive(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	Get(queue string, autoAck bool) (amqp.Delivery, bool, error)
+	Ack(tag uint64, multiple bool) error
+	Nack(tag uint64, multiple, requeue bool) error
+}
+
+// DeclareDeadLetterTopology declares the fanout dead-letter exchange and its queue
+func DeclareDeadLetterTopology(ch *amqp.Channel) error {
+	if err := ch.ExchangeDeclare(
+		DeadLetterExchange, // name
+		"fanout",           // kind
+		true,               // durable
+		false,              // auto-deleted
+		false,              // internal
+		false,              // no-wait
+		nil,                // arguments
+	); err != nil {
+		return err
+	}
+
+	if _, err := ch.QueueDeclare(DeadLetterQueue, true, false, false, false, nil); err != nil {
+		return err
+	}
+
+	return ch.QueueBind(DeadLetterQueue, "", DeadLetterExchange, false, nil)
+}
+
+// PeekMessages reads up to max messages from queue and puts them all back.
+// Messages are held unacknowledged while reading so none is returned twice.
+func PeekMessages(ch Channel, queue string, max int) ([]amqp.Delivery, error) {
+	if ch == nil {
+		return nil, ErrNoChannel
+	}
+
+	var deliveries []amqp.Delivery
+	for len(deliveries) < max {
+		d, ok, err := ch.Get(queue, false)
+		if err != nil {
+			requeue(ch, deliveries)
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err := requeue(ch, deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ReplayMessages reads the messages waiting in queue once, republishing every
+// message accepted by match to the exchange and routing key it was originally
+// dead-lettered from. Messages that do not match are returned to the queue
+// untouched.
+func ReplayMessages(ch Channel, queue string, match func(amqp.Delivery) bool) (int, error) {
+	if ch == nil {
+		return 0, ErrNoChannel
+	}
+	// Passive, so a missing queue is an error rather than created empty
+	q, err := ch.QueueDeclarePassive(queue, true, false, false, false, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		skipped  []amqp.Delivery
+		replayed int
+	)
+	// A replayed message that fails again is dead-lettered behind the others,
+	// so only the messages waiting now are read
+	for i := 0; i < q.Messages; i++ {
+		d, ok, err := ch.Get(queue, false)
+		if err != nil {
+			requeue(ch, skipped)
+			return replayed, err
+		}
+		if !ok {
+			break
+		}
+
+		if !match(d) {
+			skipped = append(skipped, d)
+			continue
+		}
+
+		if err := republish(ch, d); err != nil {
+			_ = ch.Nack(d.DeliveryTag, false, true)
+			requeue(ch, skipped)
+			return replayed, err
+		}
+		if err := ch.Ack(d.DeliveryTag, false); err != nil {
+			requeue(ch, skipped)
+			return replayed, err
+		}
+		replayed++
+	}
+
+	return replayed, requeue(ch, skipped)
+}
+
+// MessageID returns the publisher message id, falling back to a hash of the body
+// for messages published without one.
+func MessageID(d amqp.Delivery) string {
+	if d.MessageId != "" {
+		return d.MessageId
+	}
+	sum := sha1.Sum(d.Body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Death extracts the most recent x-death entry set by the broker
+func Death(d amqp.Delivery) DeathInfo {
+	var info DeathInfo
+
+	deaths, _ := d.Headers["x-death"].([]interface{})
+	if len(deaths) > 0 {
+		if entry, ok := deaths[0].(amqp.Table); ok {
+			info.Reason, _ = entry["reason"].(string)
+			info.Count, _ = entry["count"].(int64)
+			info.Queue, _ = entry["queue"].(string)
+			info.Exchange, _ = entry["exchange"].(string)
+			info.Time, _ = entry["time"].(time.Time)
+			if keys, ok := entry["routing-keys"].([]interface{}); ok && len(keys) > 0 {
+				info.RoutingKey, _ = keys[0].(string)
+			}
+		}
+	}
+
+	if reason, ok := d.Headers[HeaderLastError].(string); ok && reason != "" {
+		info.Reason = reason
+	}
+	if attempts := headerInt(d.Headers[HeaderAttempts]); attempts > info.Count {
+		info.Count = attempts
+	}
+	if info.RoutingKey == "" {
+		info.RoutingKey = d.RoutingKey
+	}
+
+	return info
+}
+
+// republish sends d back where it was dead-lettered from as a fresh message,
+// so the consumer gives it all its attempts again
+func republish(ch Publisher, d amqp.Delivery) error {
+	death := Death(d)
+	return ch.Publish(death.Exchange, death.RoutingKey, false, false, amqp.Publishing{
+		Headers:      WithoutDeaths(d.Headers, HeaderAttempts, HeaderLastError),
+		ContentType:  d.ContentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    MessageID(d),
+		Timestamp:    d.Timestamp,
+		Type:         d.Type,
+		Body:         d.Body,
+	})
+}
+
+func requeue(ch Channel, deliveries []amqp.Delivery) error {
+	var firstErr error
+	for _, d := range deliveries {
+		if err := ch.Nack(d.DeliveryTag, false, true); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func headerInt(v interface{}) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	case int32:
+		return int64(n)
+	case int:
+		return int64(n)
+	}
+	return 0
+}
@@ -0,0 +1,149 @@
+package rabbitmq
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// broker is a Channel over in-memory queues. Publishing to a queue named in
+// bounce dead-letters the message straight back to DeadLetterQueue, as a
+// consumer that keeps failing would.
+type broker struct {
+	queues    map[string][]amqp.Delivery
+	unacked   map[uint64]amqp.Delivery
+	published []amqp.Publishing
+	bounce    map[string]bool
+	tag       uint64
+}
+
+func newBroker(dead ...amqp.Delivery) *broker {
+	return &broker{
+		queues:  map[string][]amqp.Delivery{DeadLetterQueue: dead},
+		unacked: map[uint64]amqp.Delivery{},
+		bounce:  map[string]bool{},
+	}
+}
+
+func (b *broker) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	b.published = append(b.published, msg)
+	if b.bounce[key] {
+		b.queues[DeadLetterQueue] = append(b.queues[DeadLetterQueue], deadLetter(msg.MessageId, key))
+	}
+	return nil
+}
+
+func (b *broker) QueueDeclarePassive(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error) {
+	q, ok := b.queues[name]
+	if !ok {
+		return amqp.Queue{}, errors.New("NOT_FOUND - no queue '" + name + "'")
+	}
+	return amqp.Queue{Name: name, Messages: len(q)}, nil
+}
+
+func (b *broker) Get(queue string, autoAck bool) (amqp.Delivery, bool, error) {
+	q := b.queues[queue]
+	if len(q) == 0 {
+		return amqp.Delivery{}, false, nil
+	}
+	b.tag++
+	d := q[0]
+	d.DeliveryTag = b.tag
+	b.queues[queue] = q[1:]
+	b.unacked[d.DeliveryTag] = d
+	return d, true, nil
+}
+
+func (b *broker) Ack(tag uint64, multiple bool) error {
+	delete(b.unacked, tag)
+	return nil
+}
+
+func (b *broker) Nack(tag uint64, multiple, requeue bool) error {
+	d := b.unacked[tag]
+	delete(b.unacked, tag)
+	if requeue {
+		b.queues[DeadLetterQueue] = append(b.queues[DeadLetterQueue], d)
+	}
+	return nil
+}
+
+func deadLetter(id, queue string) amqp.Delivery {
+	return amqp.Delivery{
+		MessageId: id,
+		Body:      []byte(`{"id":"` + id + `"}`),
+		Headers: amqp.Table{"x-death": []interface{}{amqp.Table{
+			"reason": "rejected", "count": int64(1), "queue": queue, "exchange": "", "routing-keys": []interface{}{queue},
+		}}},
+	}
+}
+
+func TestReplayMessages(t *testing.T) {
+	b := newBroker(deadLetter("a", "property_events"), deadLetter("b", "customer_events"), deadLetter("c", "property_events"))
+
+	n, err := ReplayMessages(b, DeadLetterQueue, func(d amqp.Delivery) bool { return MessageID(d) != "b" })
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	require.Len(t, b.published, 2)
+	assert.Equal(t, "a", b.published[0].MessageId)
+	assert.Equal(t, "c", b.published[1].MessageId)
+
+	// The skipped message is back in the queue and nothing is left unacknowledged
+	require.Len(t, b.queues[DeadLetterQueue], 1)
+	assert.Equal(t, "b", b.queues[DeadLetterQueue][0].MessageId)
+	assert.Empty(t, b.unacked)
+}
+
+func TestReplayMessagesResetsAttempts(t *testing.T) {
+	d := deadLetter("a", "property_events")
+	d.Headers[HeaderAttempts] = int64(5)
+	d.Headers[HeaderLastError] = "smtp down"
+	d.Headers["x-first-death-queue"] = "property_events"
+	d.Headers["tenant"] = "2"
+	b := newBroker(d)
+
+	_, err := ReplayMessages(b, DeadLetterQueue, func(amqp.Delivery) bool { return true })
+	require.NoError(t, err)
+	require.Len(t, b.published, 1)
+
+	// The replayed message starts over with all of its attempts
+	assert.Equal(t, amqp.Table{"tenant": "2"}, b.published[0].Headers)
+	assert.Equal(t, int64(0), Death(amqp.Delivery{Headers: b.published[0].Headers}).Count)
+}
+
+func TestReplayMessagesFailingAgain(t *testing.T) {
+	b := newBroker(deadLetter("a", "property_events"), deadLetter("b", "property_events"))
+	b.bounce["property_events"] = true
+
+	// Messages dead-lettered again while replaying wait for the next replay
+	n, err := ReplayMessages(b, DeadLetterQueue, func(amqp.Delivery) bool { return true })
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Len(t, b.published, 2)
+	assert.Len(t, b.queues[DeadLetterQueue], 2)
+}
+
+func TestReplayMessagesMissingQueue(t *testing.T) {
+	b := newBroker()
+
+	_, err := ReplayMessages(b, "events.missing", func(amqp.Delivery) bool { return true })
+	assert.Error(t, err)
+	assert.NotContains(t, b.queues, "events.missing", "a passive declare creates nothing")
+
+	_, err = ReplayMessages(nil, DeadLetterQueue, func(amqp.Delivery) bool { return true })
+	assert.ErrorIs(t, err, ErrNoChannel)
+}
+
+func TestPeekMessages(t *testing.T) {
+	b := newBroker(deadLetter("a", "property_events"), deadLetter("b", "property_events"), deadLetter("c", "property_events"))
+
+	deliveries, err := PeekMessages(b, DeadLetterQueue, 2)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, "property_events", Death(deliveries[0]).RoutingKey)
+	assert.Len(t, b.queues[DeadLetterQueue], 3)
+	assert.Empty(t, b.unacked)
+}
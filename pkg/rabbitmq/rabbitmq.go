@@ -1,7 +1,11 @@
 package rabbitmq
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"log"
+	"strconv"
+	"time"
 
 	"github.com/streadway/amqp"
 )
@@ -17,31 +21,63 @@ func ConnectRabbitMQ(url string) (*amqp.Connection, *amqp.Channel, error) {
 		return nil, nil, err
 	}
 
-	// Declare a queue for property events
-	_, err = ch.QueueDeclare(
-		"property_events", // name
-		true,              // durable
-		false,             // delete when unused
-		false,             // exclusive
-		false,             // no-wait
-		nil,               // arguments
-	)
-	if err != nil {
+	// Failed messages are routed to the dead-letter queue instead of being dropped
+	if err := DeclareDeadLetterTopology(ch); err != nil {
 		return nil, nil, err
 	}
 
+	// Declare a queue for property and customer events
+	for _, queue := range []string{"property_events", "customer_events"} {
+		_, err = ch.QueueDeclare(
+			queue, // name
+			true,  // durable
+			false, // delete when unused
+			false, // exclusive
+			false, // no-wait
+			amqp.Table{"x-dead-letter-exchange": DeadLetterExchange}, // arguments
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	log.Println("Successfully connected to RabbitMQ")
 	return conn, ch, nil
 }
 
-func PublishEvent(ch *amqp.Channel, queueName string, body []byte) error {
+// Publisher is the part of *amqp.Channel that publishes messages
+type Publisher interface {
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+}
+
+func PublishEvent(ch Publisher, queueName string, body []byte) error {
+	return PublishEventWithID(ch, queueName, NewMessageID(), body)
+}
+
+// PublishEventWithID publishes body using messageID so consumers can deduplicate it
+func PublishEventWithID(ch Publisher, queueName string, messageID string, body []byte) error {
+	if ch == nil {
+		return ErrNoChannel
+	}
 	return ch.Publish(
 		"",        // exchange
 		queueName, // routing key
 		false,     // mandatory
 		false,     // immediate
 		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			MessageId:    messageID,
+			Timestamp:    time.Now().UTC(),
+			Body:         body,
 		})
 }
+
+// NewMessageID returns a random identifier suitable for amqp.Publishing.MessageId
+func NewMessageID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
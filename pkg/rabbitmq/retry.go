@@ -0,0 +1,76 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// Retry delays grow from BaseRetryDelay, doubling per attempt, up to MaxRetryDelay
+const (
+	BaseRetryDelay = 5 * time.Second
+	MaxRetryDelay  = 5 * time.Minute
+)
+
+// RetryDelay is how long a message waits before its next delivery after
+// failing attempts times
+func RetryDelay(attempts int64) time.Duration {
+	delay := BaseRetryDelay
+	for i := int64(1); i < attempts && delay < MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > MaxRetryDelay {
+		delay = MaxRetryDelay
+	}
+	return delay
+}
+
+// RetryQueue names the queue where messages of queue wait after failing
+// attempts times
+func RetryQueue(queue string, attempts int64) string {
+	return fmt.Sprintf("%s.retry.%d", queue, attempts)
+}
+
+// DeclareRetryQueues declares a retry queue for every attempt before
+// maxAttempts. Each queue holds its messages for RetryDelay and then
+// dead-letters them back onto queue. The broker only expires messages at the
+// head of a queue, so every delay gets a queue of its own rather than one
+// queue with per-message expiry, where a short wait would queue behind a long one.
+func DeclareRetryQueues(ch *amqp.Channel, queue string, maxAttempts int) error {
+	for attempts := int64(1); attempts < int64(maxAttempts); attempts++ {
+		_, err := ch.QueueDeclare(
+			RetryQueue(queue, attempts), // name
+			true,                        // durable
+			false,                       // delete when unused
+			false,                       // exclusive
+			false,                       // no-wait
+			amqp.Table{
+				"x-message-ttl":             RetryDelay(attempts).Milliseconds(),
+				"x-dead-letter-exchange":    "",
+				"x-dead-letter-routing-key": queue,
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithoutDeaths copies headers without the dead-lettering history the broker
+// records, nor any of keys
+func WithoutDeaths(headers amqp.Table, keys ...string) amqp.Table {
+	out := amqp.Table{}
+	for k, v := range headers {
+		if k == "x-death" || strings.HasPrefix(k, "x-first-death-") || strings.HasPrefix(k, "x-last-death-") {
+			continue
+		}
+		out[k] = v
+	}
+	for _, k := range keys {
+		delete(out, k)
+	}
+	return out
+}
@@ -0,0 +1,39 @@
+package rabbitmq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDelay(t *testing.T) {
+	for attempts, want := range map[int64]time.Duration{
+		0:  BaseRetryDelay,
+		1:  5 * time.Second,
+		2:  10 * time.Second,
+		4:  40 * time.Second,
+		7:  MaxRetryDelay,
+		50: MaxRetryDelay,
+	} {
+		assert.Equal(t, want, RetryDelay(attempts), attempts)
+	}
+	assert.Equal(t, "property_events.retry.3", RetryQueue("property_events", 3))
+}
+
+func TestWithoutDeaths(t *testing.T) {
+	headers := amqp.Table{
+		HeaderAttempts:          int64(4),
+		HeaderLastError:         "timeout",
+		"tenant":                "2",
+		"x-death":               []interface{}{amqp.Table{"count": int64(1)}},
+		"x-first-death-queue":   "property_events",
+		"x-first-death-reason":  "rejected",
+		"x-last-death-exchange": "",
+	}
+
+	assert.Equal(t, amqp.Table{HeaderAttempts: int64(4), HeaderLastError: "timeout", "tenant": "2"}, WithoutDeaths(headers))
+	assert.Equal(t, amqp.Table{"tenant": "2"}, WithoutDeaths(headers, HeaderAttempts, HeaderLastError))
+	assert.Len(t, headers, 7, "the original headers are left alone")
+}
@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned when a webhook URL resolves to an address
+// inside our own network
+var ErrForbiddenAddress = errors.New("webhook: address is not publicly routable")
+
+// Retry delays grow from BaseBackoff, doubling per attempt, up to MaxBackoff
+const (
+	BaseBackoff = 30 * time.Second
+	MaxBackoff  = 6 * time.Hour
+)
+
+// Backoff is how long to wait before retrying a delivery that has failed
+// attempts times
+func Backoff(attempts int) time.Duration {
+	delay := BaseBackoff
+	for i := 1; i < attempts && delay < MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > MaxBackoff {
+		delay = MaxBackoff
+	}
+	return delay
+}
+
+// nonPublic are the ranges that are private, shared or reserved but not
+// flagged by the netip.Addr methods
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),   // reserved, and broadcast
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+}
+
+// IsPublic reports whether ip may be the address of a partner's server. It
+// refuses loopback, private, link-local (including the 169.254.169.254 cloud
+// metadata service), multicast and reserved addresses.
+func IsPublic(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || ip.IsUnspecified() || ip.IsLoopback() || ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, p := range nonPublic {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewClient returns an HTTP client for deliveries that only connects to
+// public addresses. The address is checked when dialling, after DNS
+// resolution and for every redirect, so a hostname cannot be pointed at
+// an internal service after the webhook was registered.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !IsPublic(addr.Addr()) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr.Addr())
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// A proxy from the environment would be dialled instead of the partner
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConnsPerHost:   2,
+		},
+	}
+}
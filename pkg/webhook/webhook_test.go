@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignatureRoundTrip(t *testing.T) {
+	secret, err := NewSecret()
+	require.NoError(t, err)
+	body := []byte(`{"type":"property.created","entity_id":9}`)
+	ts := time.Now().Unix()
+
+	sig := Sign(secret, ts, body)
+	assert.True(t, Verify(secret, ts, body, sig))
+	assert.False(t, Verify(secret, ts+1, body, sig), "the timestamp is signed")
+	assert.False(t, Verify(secret, ts, []byte(`{"type":"property.deleted","entity_id":9}`), sig))
+	assert.False(t, Verify("whsec_other", ts, body, sig))
+}
+
+func TestBackoff(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{
+		0:  30 * time.Second,
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		8:  64 * time.Minute,
+		10: 256 * time.Minute,
+		11: MaxBackoff,
+		50: MaxBackoff,
+	} {
+		assert.Equal(t, want, Backoff(attempts), strconv.Itoa(attempts))
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	for addr, public := range map[string]bool{
+		"203.0.113.10":    true,
+		"8.8.8.8":         true,
+		"2001:4860::8888": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"255.255.255.255": false,
+		"::1":             false,
+		"fe80::1":         false,
+		"fd00::1":         false,
+		"::ffff:10.0.0.1": false,
+	} {
+		assert.Equal(t, public, IsPublic(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestClientRefusesInternalAddresses(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	defer srv.Close()
+
+	// The test server listens on loopback, like an internal service would
+	_, err := NewClient(time.Second).Post(srv.URL, "application/json", nil)
+	assert.True(t, errors.Is(err, ErrForbiddenAddress), err)
+	assert.False(t, called)
+}
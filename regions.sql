@@ -0,0 +1,108 @@
+-- Seed for the regions table: every province and a sample of regencies,
+-- districts and villages in the main markets. Apply after schema.sql. For
+-- production, load the complete Kemendagri dataset in the same format.
+INSERT INTO regions (code, name, level, parent_code) VALUES
+    ('11', 'Aceh', 1, NULL),
+    ('12', 'Sumatera Utara', 1, NULL),
+    ('13', 'Sumatera Barat', 1, NULL),
+    ('14', 'Riau', 1, NULL),
+    ('15', 'Jambi', 1, NULL),
+    ('16', 'Sumatera Selatan', 1, NULL),
+    ('17', 'Bengkulu', 1, NULL),
+    ('18', 'Lampung', 1, NULL),
+    ('19', 'Kepulauan Bangka Belitung', 1, NULL),
+    ('21', 'Kepulauan Riau', 1, NULL),
+    ('31', 'DKI Jakarta', 1, NULL),
+    ('32', 'Jawa Barat', 1, NULL),
+    ('33', 'Jawa Tengah', 1, NULL),
+    ('34', 'DI Yogyakarta', 1, NULL),
+    ('35', 'Jawa Timur', 1, NULL),
+    ('36', 'Banten', 1, NULL),
+    ('51', 'Bali', 1, NULL),
+    ('52', 'Nusa Tenggara Barat', 1, NULL),
+    ('53', 'Nusa Tenggara Timur', 1, NULL),
+    ('61', 'Kalimantan Barat', 1, NULL),
+    ('62', 'Kalimantan Tengah', 1, NULL),
+    ('63', 'Kalimantan Selatan', 1, NULL),
+    ('64', 'Kalimantan Timur', 1, NULL),
+    ('65', 'Kalimantan Utara', 1, NULL),
+    ('71', 'Sulawesi Utara', 1, NULL),
+    ('72', 'Sulawesi Tengah', 1, NULL),
+    ('73', 'Sulawesi Selatan', 1, NULL),
+    ('74', 'Sulawesi Tenggara', 1, NULL),
+    ('75', 'Gorontalo', 1, NULL),
+    ('76', 'Sulawesi Barat', 1, NULL),
+    ('81', 'Maluku', 1, NULL),
+    ('82', 'Maluku Utara', 1, NULL),
+    ('91', 'Papua', 1, NULL),
+    ('92', 'Papua Barat', 1, NULL),
+    ('93', 'Papua Selatan', 1, NULL),
+    ('94', 'Papua Tengah', 1, NULL),
+    ('95', 'Papua Pegunungan', 1, NULL),
+    ('96', 'Papua Barat Daya', 1, NULL),
+
+    ('31.01', 'Kabupaten Administrasi Kepulauan Seribu', 2, '31'),
+    ('31.71', 'Kota Administrasi Jakarta Selatan', 2, '31'),
+    ('31.72', 'Kota Administrasi Jakarta Timur', 2, '31'),
+    ('31.73', 'Kota Administrasi Jakarta Pusat', 2, '31'),
+    ('31.74', 'Kota Administrasi Jakarta Barat', 2, '31'),
+    ('31.75', 'Kota Administrasi Jakarta Utara', 2, '31'),
+    ('32.04', 'Kabupaten Bandung', 2, '32'),
+    ('32.71', 'Kota Bogor', 2, '32'),
+    ('32.73', 'Kota Bandung', 2, '32'),
+    ('32.75', 'Kota Bekasi', 2, '32'),
+    ('32.76', 'Kota Depok', 2, '32'),
+    ('34.71', 'Kota Yogyakarta', 2, '34'),
+    ('35.15', 'Kabupaten Sidoarjo', 2, '35'),
+    ('35.78', 'Kota Surabaya', 2, '35'),
+    ('36.71', 'Kota Tangerang', 2, '36'),
+    ('36.74', 'Kota Tangerang Selatan', 2, '36'),
+    ('51.03', 'Kabupaten Badung', 2, '51'),
+    ('51.04', 'Kabupaten Gianyar', 2, '51'),
+    ('51.71', 'Kota Denpasar', 2, '51'),
+
+    ('31.71.01', 'Tebet', 3, '31.71'),
+    ('31.71.02', 'Setiabudi', 3, '31.71'),
+    ('31.71.03', 'Mampang Prapatan', 3, '31.71'),
+    ('31.71.04', 'Pasar Minggu', 3, '31.71'),
+    ('31.71.05', 'Kebayoran Lama', 3, '31.71'),
+    ('31.71.06', 'Cilandak', 3, '31.71'),
+    ('31.71.07', 'Kebayoran Baru', 3, '31.71'),
+    ('31.71.08', 'Pancoran', 3, '31.71'),
+    ('31.71.09', 'Jagakarsa', 3, '31.71'),
+    ('31.71.10', 'Pesanggrahan', 3, '31.71'),
+    ('32.73.01', 'Sukasari', 3, '32.73'),
+    ('32.73.02', 'Coblong', 3, '32.73'),
+    ('32.73.03', 'Babakan Ciparay', 3, '32.73'),
+    ('32.73.04', 'Bojongloa Kaler', 3, '32.73'),
+    ('35.78.01', 'Karang Pilang', 3, '35.78'),
+    ('35.78.02', 'Wonocolo', 3, '35.78'),
+    ('35.78.03', 'Rungkut', 3, '35.78'),
+    ('35.78.04', 'Wonokromo', 3, '35.78'),
+    ('51.03.01', 'Kuta Selatan', 3, '51.03'),
+    ('51.03.02', 'Kuta', 3, '51.03'),
+    ('51.03.06', 'Kuta Utara', 3, '51.03'),
+    ('51.71.01', 'Denpasar Selatan', 3, '51.71'),
+    ('51.71.02', 'Denpasar Timur', 3, '51.71'),
+    ('51.71.03', 'Denpasar Barat', 3, '51.71'),
+    ('51.71.04', 'Denpasar Utara', 3, '51.71'),
+
+    ('31.71.01.1001', 'Tebet Barat', 4, '31.71.01'),
+    ('31.71.01.1002', 'Tebet Timur', 4, '31.71.01'),
+    ('31.71.01.1003', 'Kebon Baru', 4, '31.71.01'),
+    ('31.71.02.1001', 'Setiabudi', 4, '31.71.02'),
+    ('31.71.02.1002', 'Karet', 4, '31.71.02'),
+    ('31.71.02.1003', 'Karet Semanggi', 4, '31.71.02'),
+    ('32.73.01.1001', 'Sukarasa', 4, '32.73.01'),
+    ('32.73.01.1002', 'Gegerkalong', 4, '32.73.01'),
+    ('32.73.01.1003', 'Isola', 4, '32.73.01'),
+    ('32.73.01.1004', 'Sarijadi', 4, '32.73.01'),
+    ('35.78.03.1001', 'Rungkut Kidul', 4, '35.78.03'),
+    ('35.78.03.1002', 'Medokan Ayu', 4, '35.78.03'),
+    ('51.03.01.2001', 'Pecatu', 4, '51.03.01'),
+    ('51.03.01.2002', 'Ungasan', 4, '51.03.01'),
+    ('51.03.01.2003', 'Kutuh', 4, '51.03.01'),
+    ('51.03.01.1004', 'Benoa', 4, '51.03.01'),
+    ('51.03.01.1005', 'Tanjung Benoa', 4, '51.03.01'),
+    ('51.03.01.1006', 'Jimbaran', 4, '51.03.01')
+ON CONFLICT (code) DO UPDATE SET name = EXCLUDED.name, level = EXCLUDED.level, parent_code = EXCLUDED.parent_code;
@@ -18,3 +18,449 @@ CREATE TABLE IF NOT EXISTS customers (
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+CREATE TABLE IF NOT EXISTS webhooks (
+    id SERIAL PRIMARY KEY,
+    url VARCHAR(2048) NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    secret VARCHAR(255) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id SERIAL PRIMARY KEY,
+    webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_id VARCHAR(64) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    response_status INTEGER,
+    last_error TEXT,
+    delivered_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (webhook_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+-- Records deleted rows so the change feed can report them
+CREATE TABLE IF NOT EXISTS tombstones (
+    id SERIAL PRIMARY KEY,
+    entity VARCHAR(50) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    deleted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tombstones_deleted_at ON tombstones (deleted_at, entity, entity_id);
+CREATE INDEX IF NOT EXISTS idx_properties_updated_at ON properties (updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_customers_updated_at ON customers (updated_at, id);
+
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Authorization: roles hold scoped permissions, users belong to a branch
+CREATE TABLE IF NOT EXISTS branches (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS roles (
+    name VARCHAR(50) PRIMARY KEY,
+    description TEXT
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role VARCHAR(50) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission VARCHAR(100) NOT NULL,
+    scope VARCHAR(20) NOT NULL DEFAULT 'any' CHECK (scope IN ('own', 'branch', 'any')),
+    PRIMARY KEY (role, permission)
+);
+
+INSERT INTO roles (name, description) VALUES
+    ('agent', 'Creates listings and edits their own'),
+    ('manager', 'Edits every listing in their branch'),
+    ('admin', 'Full access')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission, scope) VALUES
+    ('agent', 'properties:read', 'any'),
+    ('agent', 'properties:create', 'any'),
+    ('agent', 'properties:update', 'own'),
+    ('agent', 'properties:delete', 'own'),
+    ('agent', 'customers:read', 'any'),
+    ('agent', 'customers:create', 'any'),
+    ('agent', 'customers:update', 'any'),
+    ('manager', 'properties:read', 'any'),
+    ('manager', 'properties:create', 'any'),
+    ('manager', 'properties:update', 'branch'),
+    ('manager', 'properties:delete', 'branch'),
+    ('manager', 'customers:read', 'any'),
+    ('manager', 'customers:create', 'any'),
+    ('manager', 'customers:update', 'any'),
+    ('admin', 'properties:read', 'any'),
+    ('admin', 'properties:create', 'any'),
+    ('admin', 'properties:update', 'any'),
+    ('admin', 'properties:delete', 'any'),
+    ('admin', 'customers:read', 'any'),
+    ('admin', 'customers:create', 'any'),
+    ('admin', 'customers:update', 'any'),
+    ('admin', 'customers:delete', 'any'),
+    ('admin', 'users:manage', 'any'),
+    ('admin', 'webhooks:manage', 'any')
+ON CONFLICT (role, permission) DO NOTHING;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(50) NOT NULL DEFAULT 'agent' REFERENCES roles(name);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS branch_id INTEGER REFERENCES branches(id);
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS agent_id INTEGER REFERENCES users(id);
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS branch_id INTEGER REFERENCES branches(id);
+CREATE INDEX IF NOT EXISTS idx_properties_branch ON properties (branch_id);
+
+-- API keys for partner integrations; only a SHA-256 hash of each key is stored
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(20) NOT NULL,
+    key_hash CHAR(64) UNIQUE NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    last_used_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO role_permissions (role, permission, scope) VALUES
+    ('admin', 'api_keys:manage', 'any')
+ON CONFLICT (role, permission) DO NOTHING;
+
+-- Multi-tenancy: every agency is a tenant; rows created before this belong to tenant 1
+CREATE TABLE IF NOT EXISTS tenants (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO tenants (id, name) VALUES (1, 'Default') ON CONFLICT (id) DO NOTHING;
+SELECT setval(pg_get_serial_sequence('tenants', 'id'), GREATEST((SELECT MAX(id) FROM tenants), 1));
+
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants(id);
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants(id);
+ALTER TABLE tombstones ADD COLUMN IF NOT EXISTS tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants(id);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants(id);
+ALTER TABLE branches ADD COLUMN IF NOT EXISTS tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants(id);
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants(id);
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants(id);
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants(id);
+
+-- Customer emails only need to be unique within an agency
+ALTER TABLE customers DROP CONSTRAINT IF EXISTS customers_email_key;
+
+CREATE INDEX IF NOT EXISTS idx_properties_tenant_updated_at ON properties (tenant_id, updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_customers_tenant_updated_at ON customers (tenant_id, updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_tombstones_tenant_deleted_at ON tombstones (tenant_id, deleted_at, entity, entity_id);
+CREATE INDEX IF NOT EXISTS idx_webhooks_tenant ON webhooks (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant ON api_keys (tenant_id);
+
+-- Append-only audit trail of mutations
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id),
+    actor_id INTEGER,
+    api_key_id INTEGER,
+    action VARCHAR(20) NOT NULL,
+    entity VARCHAR(50) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    before JSONB,
+    after JSONB,
+    request_id VARCHAR(64),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log (tenant_id, entity, entity_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log (tenant_id, created_at DESC);
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+INSERT INTO role_permissions (role, permission, scope) VALUES
+    ('manager', 'audit:read', 'any'),
+    ('admin', 'audit:read', 'any')
+ON CONFLICT (role, permission) DO NOTHING;
+
+-- Soft delete: deleted rows stay in the trash until the worker purges them
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_properties_deleted_at ON properties (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_customers_deleted_at ON customers (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- A customer in the trash does not hold on to their email
+DROP INDEX IF EXISTS idx_customers_tenant_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_tenant_email_active ON customers (tenant_id, email) WHERE deleted_at IS NULL;
+
+-- Snapshots of a property after each write, numbered per property
+CREATE TABLE IF NOT EXISTS property_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id),
+    property_id INTEGER NOT NULL REFERENCES properties(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    snapshot JSONB NOT NULL,
+    author_id INTEGER,
+    rolled_back_from INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (property_id, revision)
+);
+
+-- Existing properties start their history at their current state
+INSERT INTO property_revisions (tenant_id, property_id, revision, snapshot, author_id, created_at)
+SELECT p.tenant_id, p.id, 1,
+    jsonb_build_object('id', p.id, 'title', p.title, 'description', p.description, 'address', p.address,
+        'price', p.price, 'agent_id', COALESCE(p.agent_id, 0), 'branch_id', COALESCE(p.branch_id, 0)),
+    p.agent_id, p.updated_at
+FROM properties p
+WHERE NOT EXISTS (SELECT 1 FROM property_revisions r WHERE r.property_id = p.id);
+
+-- Every asking price a property has had; old_price is NULL for the listing price
+CREATE TABLE IF NOT EXISTS price_history (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id),
+    property_id INTEGER NOT NULL REFERENCES properties(id) ON DELETE CASCADE,
+    old_price NUMERIC(15, 2),
+    new_price NUMERIC(15, 2) NOT NULL,
+    actor_id INTEGER,
+    changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_history_property ON price_history (property_id, changed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_price_history_tenant ON price_history (tenant_id, changed_at);
+
+INSERT INTO price_history (tenant_id, property_id, new_price, actor_id, changed_at)
+SELECT p.tenant_id, p.id, p.price, p.agent_id, p.created_at
+FROM properties p
+WHERE p.price IS NOT NULL AND NOT EXISTS (SELECT 1 FROM price_history h WHERE h.property_id = p.id);
+
+-- Prices are exact amounts in an ISO 4217 currency
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE price_history ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'IDR';
+
+-- Listing lifecycle. Properties created before it existed were already public.
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS status VARCHAR(20);
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS published_at TIMESTAMP;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS reserved_at TIMESTAMP;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS sold_at TIMESTAMP;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS rented_at TIMESTAMP;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;
+UPDATE properties SET status = 'published', published_at = created_at WHERE status IS NULL;
+ALTER TABLE properties ALTER COLUMN status SET DEFAULT 'draft';
+ALTER TABLE properties ALTER COLUMN status SET NOT NULL;
+ALTER TABLE properties DROP CONSTRAINT IF EXISTS properties_status_check;
+ALTER TABLE properties ADD CONSTRAINT properties_status_check
+    CHECK (status IN ('draft', 'published', 'reserved', 'sold', 'rented', 'archived'));
+
+CREATE INDEX IF NOT EXISTS idx_properties_tenant_status ON properties (tenant_id, status) WHERE deleted_at IS NULL;
+
+-- Property attributes; NULL means unknown
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS property_type VARCHAR(20);
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS listing_type VARCHAR(10);
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS bedrooms SMALLINT;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS bathrooms SMALLINT;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS land_area NUMERIC(10, 2);
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS building_area NUMERIC(10, 2);
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS floors SMALLINT;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS certificate VARCHAR(10);
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS furnishing VARCHAR(20);
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS year_built SMALLINT;
+
+CREATE INDEX IF NOT EXISTS idx_properties_search ON properties (tenant_id, listing_type, property_type, price) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_properties_bedrooms ON properties (tenant_id, bedrooms) WHERE deleted_at IS NULL;
+
+-- Kemendagri administrative regions shared by all tenants. Codes are dotted,
+-- e.g. 32 → 32.73 → 32.73.01 → 32.73.01.1001. regions.sql seeds the provinces
+-- and a sample of the main cities; load the full dataset into this table.
+CREATE TABLE IF NOT EXISTS regions (
+    code VARCHAR(13) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    level SMALLINT NOT NULL CHECK (level BETWEEN 1 AND 4),
+    parent_code VARCHAR(13) REFERENCES regions(code)
+);
+
+CREATE INDEX IF NOT EXISTS idx_regions_parent ON regions (parent_code, code);
+
+-- Structured address; the existing address column holds the street
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS rt VARCHAR(3);
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS rw VARCHAR(3);
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS village_code VARCHAR(13) REFERENCES regions(code);
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS village VARCHAR(255);
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS district_code VARCHAR(13) REFERENCES regions(code);
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS district VARCHAR(255);
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS regency_code VARCHAR(13) REFERENCES regions(code);
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS regency VARCHAR(255);
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS province_code VARCHAR(13) REFERENCES regions(code);
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS province VARCHAR(255);
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS postal_code VARCHAR(5);
+
+CREATE INDEX IF NOT EXISTS idx_properties_province ON properties (tenant_id, province_code) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_properties_regency ON properties (tenant_id, regency_code) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_properties_district ON properties (tenant_id, district_code) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_properties_village ON properties (tenant_id, village_code) WHERE deleted_at IS NULL;
+
+-- Coordinates for map search. Radius search uses the earthdistance extension.
+CREATE EXTENSION IF NOT EXISTS cube;
+CREATE EXTENSION IF NOT EXISTS earthdistance;
+
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+ALTER TABLE properties DROP CONSTRAINT IF EXISTS properties_location_check;
+ALTER TABLE properties ADD CONSTRAINT properties_location_check
+    CHECK ((latitude IS NULL) = (longitude IS NULL) AND latitude BETWEEN -90 AND 90 AND longitude BETWEEN -180 AND 180);
+
+CREATE INDEX IF NOT EXISTS idx_properties_earth ON properties USING gist (ll_to_earth(latitude, longitude))
+    WHERE deleted_at IS NULL AND latitude IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_properties_lat_lng ON properties (tenant_id, latitude, longitude)
+    WHERE deleted_at IS NULL AND latitude IS NOT NULL;
+
+-- How a location was geocoded from the address; NULL when entered by hand
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS geocode_confidence NUMERIC(3, 2);
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS geocode_provider VARCHAR(20);
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS geocoded_address TEXT;
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS geocoded_at TIMESTAMP;
+
+-- Photos of a property; the files live in the configured blob store
+CREATE TABLE IF NOT EXISTS property_media (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id),
+    property_id INTEGER NOT NULL REFERENCES properties(id) ON DELETE CASCADE,
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    url TEXT NOT NULL,
+    filename VARCHAR(255),
+    content_type VARCHAR(50) NOT NULL,
+    size BIGINT NOT NULL,
+    position INTEGER NOT NULL,
+    is_cover BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_property_media_property ON property_media (property_id, position);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_property_media_cover ON property_media (property_id) WHERE is_cover;
+
+-- Filled in by the worker once it has stripped a photo's metadata and resized
+-- it; phash holds the 64 bits of the perceptual hash
+ALTER TABLE property_media ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'pending';
+ALTER TABLE property_media ADD COLUMN IF NOT EXISTS width INTEGER;
+ALTER TABLE property_media ADD COLUMN IF NOT EXISTS height INTEGER;
+ALTER TABLE property_media ADD COLUMN IF NOT EXISTS phash BIGINT;
+ALTER TABLE property_media ADD COLUMN IF NOT EXISTS variants JSONB;
+ALTER TABLE property_media ADD COLUMN IF NOT EXISTS processed_at TIMESTAMP;
+
+-- A duplicate listing merged into another is deleted and points at it
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS merged_into INTEGER REFERENCES properties(id) ON DELETE SET NULL;
+
+-- Each tenant's catalogue of amenities, linked to properties many-to-many.
+-- Amenities with a unit are measured, like the PLN connection in VA.
+CREATE TABLE IF NOT EXISTS amenities (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id),
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    category VARCHAR(20) NOT NULL CHECK (category IN ('facility', 'security', 'access', 'utility')),
+    unit VARCHAR(20),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (tenant_id, code)
+);
+
+CREATE TABLE IF NOT EXISTS property_amenities (
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id),
+    property_id INTEGER NOT NULL REFERENCES properties(id) ON DELETE CASCADE,
+    amenity_id INTEGER NOT NULL REFERENCES amenities(id) ON DELETE CASCADE,
+    value NUMERIC(12, 2),
+    PRIMARY KEY (property_id, amenity_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_property_amenities_amenity ON property_amenities (amenity_id, property_id);
+
+-- Every existing tenant starts with the common amenities
+INSERT INTO amenities (tenant_id, code, name, category, unit)
+SELECT t.id, a.code, a.name, a.category, a.unit
+FROM tenants t CROSS JOIN (VALUES
+    ('pool', 'Kolam renang', 'facility', NULL),
+    ('carport', 'Carport', 'facility', NULL),
+    ('garage', 'Garasi', 'facility', NULL),
+    ('garden', 'Taman', 'facility', NULL),
+    ('cctv', 'CCTV', 'security', NULL),
+    ('security_24h', 'Keamanan 24 jam', 'security', NULL),
+    ('one_gate', 'One gate system', 'security', NULL),
+    ('near_toll_gate', 'Dekat gerbang tol', 'access', NULL),
+    ('near_station', 'Dekat stasiun', 'access', NULL),
+    ('near_school', 'Dekat sekolah', 'access', NULL),
+    ('pln', 'Listrik PLN', 'utility', 'VA'),
+    ('pdam', 'Air PDAM', 'utility', NULL),
+    ('water_well', 'Sumur bor', 'utility', NULL)
+) AS a(code, name, category, unit)
+ON CONFLICT (tenant_id, code) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission, scope) VALUES
+    ('manager', 'amenities:manage', 'any'),
+    ('admin', 'amenities:manage', 'any')
+ON CONFLICT (role, permission) DO NOTHING;
+
+-- The dead letter queue holds the events of every tenant, so only operators
+-- of the platform may read or replay it, not the admins of an agency
+INSERT INTO roles (name, description) VALUES
+    ('platform_admin', 'Full access, and manages what all agencies share')
+ON CONFLICT (name) DO NOTHING;
+
+DELETE FROM role_permissions WHERE permission = 'dead_letters:manage';
+
+INSERT INTO role_permissions (role, permission, scope)
+SELECT 'platform_admin', permission, scope FROM role_permissions WHERE role = 'admin'
+UNION ALL
+SELECT 'platform_admin', 'platform:dead_letters', 'any'
+ON CONFLICT (role, permission) DO NOTHING;
+
+-- The change feed is ordered by the transaction that made each change. A
+-- change only enters the feed once every older transaction has finished, so
+-- one that commits late can never land behind a position already handed out.
+ALTER TABLE properties ADD COLUMN IF NOT EXISTS change_xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS change_xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+ALTER TABLE tombstones ADD COLUMN IF NOT EXISTS change_xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE OR REPLACE FUNCTION set_change_xid() RETURNS trigger AS $$
+BEGIN
+    IF NEW.updated_at IS DISTINCT FROM OLD.updated_at THEN
+        NEW.change_xid := pg_current_xact_id();
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS properties_change_xid ON properties;
+CREATE TRIGGER properties_change_xid BEFORE UPDATE ON properties
+    FOR EACH ROW EXECUTE FUNCTION set_change_xid();
+DROP TRIGGER IF EXISTS customers_change_xid ON customers;
+CREATE TRIGGER customers_change_xid BEFORE UPDATE ON customers
+    FOR EACH ROW EXECUTE FUNCTION set_change_xid();
+
+CREATE INDEX IF NOT EXISTS idx_properties_tenant_change_xid ON properties (tenant_id, change_xid, id);
+CREATE INDEX IF NOT EXISTS idx_customers_tenant_change_xid ON customers (tenant_id, change_xid, id);
+CREATE INDEX IF NOT EXISTS idx_tombstones_tenant_change_xid ON tombstones (tenant_id, change_xid, entity, entity_id);
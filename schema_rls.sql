@@ -0,0 +1,24 @@
+-- Optional PostgreSQL row-level security for tenant-owned tables.
+--
+-- The application scopes every query by tenant_id itself. Apply this file to
+-- also enforce isolation in the database for roles that do not own the tables
+-- (reporting users, ad-hoc access). Such sessions must run
+--     SET app.tenant_id = '<tenant id>';
+-- and see no rows otherwise. Table owners and BYPASSRLS roles, such as the
+-- role the API connects as, are not affected.
+
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY['properties', 'customers', 'tombstones', 'users', 'branches', 'api_keys', 'webhooks', 'webhook_deliveries', 'audit_log', 'property_revisions', 'price_history', 'property_media', 'amenities', 'property_amenities']
+    LOOP
+        EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t);
+        EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %I', t);
+        EXECUTE format(
+            'CREATE POLICY tenant_isolation ON %I USING (tenant_id = NULLIF(current_setting(''app.tenant_id'', true), '''')::int)',
+            t
+        );
+    END LOOP;
+END
+$$;
@@ -4,7 +4,11 @@ param (
 
 switch ($Command) {
     "run" { go run cmd/api/main.go }
-    "build" { go build -o bin/nusatek-backend.exe cmd/api/main.go }
+    "run-worker" { go run cmd/worker/main.go }
+    "build" {
+        go build -o bin/nusatek-backend.exe cmd/api/main.go
+        go build -o bin/nusatek-backend-worker.exe cmd/worker/main.go
+    }
     "test" { go test -v ./... }
     "clean" { if (Test-Path bin) { Remove-Item bin -Recurse -Force } }
     "docker-up" { docker-compose up -d }
@@ -13,6 +17,7 @@ switch ($Command) {
         Write-Host "Usage: .\tasks.ps1 [command]"
         Write-Host "Commands:"
         Write-Host "  run         - Run the application"
+        Write-Host "  run-worker  - Run the event worker"
         Write-Host "  build       - Build the binary"
         Write-Host "  test        - Run unit tests"
         Write-Host "  docker-up   - Start database and queue"
@@ -1,6 +1,14 @@
 document.addEventListener('DOMContentLoaded', () => {
-    fetchProperties();
-    fetchCustomers();
+    const loginForm = document.getElementById('login-form');
+    if (loginForm) {
+        loginForm.addEventListener('submit', handleLogin);
+    }
+
+    if (getAccessToken()) {
+        loadDashboard();
+    } else {
+        showLoginModal();
+    }
     
     // Initialize icons if lucide is available
     if (window.lucide) {
@@ -18,6 +26,119 @@ document.addEventListener('DOMContentLoaded', () => {
     }
 });
 
+function loadDashboard() {
+    fetchProperties();
+    fetchCustomers();
+    subscribeToChanges();
+    fetchCurrentUser();
+}
+
+// --- AUTH ---
+
+function getAccessToken() {
+    return localStorage.getItem('access_token');
+}
+
+function storeTokens(tokens) {
+    localStorage.setItem('access_token', tokens.access_token);
+    localStorage.setItem('refresh_token', tokens.refresh_token);
+}
+
+function clearTokens() {
+    localStorage.removeItem('access_token');
+    localStorage.removeItem('refresh_token');
+}
+
+// apiFetch is fetch with the access token attached. On 401 it refreshes the
+// token pair once and retries; if that fails the login form is shown.
+async function apiFetch(url, options = {}, retried = false) {
+    const headers = Object.assign({}, options.headers, {
+        'Authorization': `Bearer ${getAccessToken()}`
+    });
+    const response = await fetch(url, Object.assign({}, options, { headers }));
+
+    if (response.status === 401 && !retried && await refreshTokens()) {
+        return apiFetch(url, options, true);
+    }
+    if (response.status === 401) {
+        clearTokens();
+        showLoginModal();
+    }
+    return response;
+}
+
+// Refresh tokens rotate and reusing one revokes the session, so concurrent
+// callers share a single in-flight refresh.
+let pendingRefresh = null;
+
+function refreshTokens() {
+    if (!pendingRefresh) {
+        pendingRefresh = doRefreshTokens().finally(() => { pendingRefresh = null; });
+    }
+    return pendingRefresh;
+}
+
+async function doRefreshTokens() {
+    const refreshToken = localStorage.getItem('refresh_token');
+    if (!refreshToken) return false;
+
+    const response = await fetch('/api/v1/auth/refresh', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ refresh_token: refreshToken })
+    });
+    if (!response.ok) return false;
+
+    storeTokens(await response.json());
+    return true;
+}
+
+async function handleLogin(e) {
+    e.preventDefault();
+    const formData = new FormData(e.target);
+
+    try {
+        const response = await fetch('/api/v1/auth/login', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({
+                email: formData.get('email'),
+                password: formData.get('password')
+            })
+        });
+
+        if (!response.ok) throw new Error('Invalid email or password');
+
+        storeTokens(await response.json());
+        closeLoginModal();
+        e.target.reset();
+        loadDashboard();
+    } catch (error) {
+        console.error(error);
+        alert('Failed: ' + error.message);
+    }
+}
+
+window.logout = async function() {
+    await apiFetch('/api/v1/auth/logout', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ refresh_token: localStorage.getItem('refresh_token') })
+    });
+    clearTokens();
+    if (changeSource) changeSource.close();
+    showLoginModal();
+}
+
+async function fetchCurrentUser() {
+    const response = await apiFetch('/api/v1/auth/me');
+    if (!response.ok) return;
+
+    const user = await response.json();
+    const el = document.getElementById('current-user');
+    if (el) el.textContent = user.name;
+}
+
 // View Switching Logic
 window.switchView = function(viewName, element) {
     // Hide all views
@@ -47,7 +168,7 @@ window.switchView = function(viewName, element) {
 
 async function fetchProperties() {
     try {
-        const response = await fetch('/api/v1/properties');
+        const response = await apiFetch('/api/v1/properties');
         if (!response.ok) throw new Error('Failed to fetch properties');
         
         const data = await response.json();
@@ -76,7 +197,7 @@ function renderProperties(properties) {
                 <div style="font-weight: 600;">${property.title}</div>
                 <div style="font-size: 0.75rem; color: var(--text-secondary);">${new Date(property.created_at).toLocaleDateString()}</div>
             </td>
-            <td>${property.address}</td>
+            <td>${formatAddress(property.address)}</td>
             <td style="font-family: monospace; font-size: 0.9rem;">${formatCurrency(property.price)}</td>
             <td><span class="status-badge active">Active</span></td>
             <td>
@@ -97,11 +218,13 @@ async function handleAddProperty(e) {
         title: formData.get('title'),
         address: formData.get('address'),
         description: formData.get('description'),
-        price: parseFloat(formData.get('price'))
+        price: { amount: formData.get('price'), currency: 'IDR' },
+        property_type: formData.get('property_type'),
+        listing_type: formData.get('listing_type')
     };
 
     try {
-        const response = await fetch('/api/v1/properties', {
+        const response = await apiFetch('/api/v1/properties', {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify(data)
@@ -121,7 +244,7 @@ async function handleAddProperty(e) {
 async function deleteProperty(id) {
     if (!confirm('Delete this property?')) return;
     try {
-        await fetch(`/api/v1/properties/${id}`, { method: 'DELETE' });
+        await apiFetch(`/api/v1/properties/${id}`, { method: 'DELETE' });
         await fetchProperties();
     } catch (error) {
         console.error(error);
@@ -133,7 +256,7 @@ async function deleteProperty(id) {
 
 async function fetchCustomers() {
     try {
-        const response = await fetch('/api/v1/customers');
+        const response = await apiFetch('/api/v1/customers');
         if (!response.ok) throw new Error('Failed to fetch customers');
         
         const data = await response.json();
@@ -186,7 +309,7 @@ async function handleAddCustomer(e) {
     };
 
     try {
-        const response = await fetch('/api/v1/customers', {
+        const response = await apiFetch('/api/v1/customers', {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify(data)
@@ -206,7 +329,7 @@ async function handleAddCustomer(e) {
 async function deleteCustomer(id) {
     if (!confirm('Delete this customer?')) return;
     try {
-        await fetch(`/api/v1/customers/${id}`, { method: 'DELETE' });
+        await apiFetch(`/api/v1/customers/${id}`, { method: 'DELETE' });
         await fetchCustomers();
     } catch (error) {
         console.error(error);
@@ -214,6 +337,43 @@ async function deleteCustomer(id) {
     }
 }
 
+// --- LIVE UPDATES ---
+
+let changeSource = null;
+let lastChangeId = '';
+
+// Refresh the tables when anyone changes a property or customer.
+// EventSource cannot send headers, so a stream token good for a minute goes in
+// the query string; when the stream drops it is reopened with a new one from
+// the last event id.
+async function subscribeToChanges() {
+    if (!window.EventSource) return;
+    if (changeSource) changeSource.close();
+
+    const response = await apiFetch('/api/v1/auth/stream-token', { method: 'POST' });
+    if (!response.ok) return;
+    const { token } = await response.json();
+
+    const params = new URLSearchParams({ types: 'property,customer', access_token: token });
+    if (lastChangeId) params.set('last_event_id', lastChangeId);
+
+    changeSource = new EventSource(`/api/v1/stream?${params}`);
+    const track = handler => event => {
+        lastChangeId = event.lastEventId;
+        handler();
+    };
+    ['property_created', 'property_updated', 'property_deleted'].forEach(type => {
+        changeSource.addEventListener(type, track(fetchProperties));
+    });
+    ['customer_created', 'customer_updated', 'customer_deleted'].forEach(type => {
+        changeSource.addEventListener(type, track(fetchCustomers));
+    });
+    changeSource.onerror = () => {
+        changeSource.close();
+        setTimeout(subscribeToChanges, 1000);
+    };
+}
+
 // --- UTILS ---
 
 function updateStats(data, type) {
@@ -226,8 +386,20 @@ function updateStats(data, type) {
     }
 }
 
-function formatCurrency(amount) {
-    return new Intl.NumberFormat('id-ID', { style: 'currency', currency: 'IDR' }).format(amount);
+// formatAddress joins the parts of an address as sent by the API, e.g.
+// "Jl. Braga 10, Braga, Sumur Bandung, Kota Bandung"
+function formatAddress(address) {
+    if (!address) return '-';
+    if (typeof address === 'string') return address;
+    return [address.street, address.village, address.district, address.regency]
+        .filter(Boolean).join(', ') || '-';
+}
+
+// formatCurrency formats a price as sent by the API, {amount: "1500000000.00", currency: "IDR"}
+function formatCurrency(price) {
+    if (!price) return '-';
+    const currency = price.currency || 'IDR';
+    return new Intl.NumberFormat('id-ID', { style: 'currency', currency }).format(Number(price.amount));
 }
 
 // --- MODALS ---
@@ -252,6 +424,16 @@ window.closeAddCustomerModal = function() {
     if (modal) modal.style.display = 'none';
 }
 
+function showLoginModal() {
+    const modal = document.getElementById('login-modal');
+    if (modal) modal.style.display = 'block';
+}
+
+function closeLoginModal() {
+    const modal = document.getElementById('login-modal');
+    if (modal) modal.style.display = 'none';
+}
+
 // Close modal when clicking outside
 window.onclick = function(event) {
     const propModal = document.getElementById('add-modal');